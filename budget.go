@@ -0,0 +1,233 @@
+package sheet2inv
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/shopspring/decimal"
+)
+
+// Budget scopes
+const (
+	BudgetInvoice = "invoice" // limit per invoice
+	BudgetIssue   = "issue"   // limit for matching issues across all invoices
+	BudgetPeriod  = "period"  // limit per invoicing period across all invoices
+)
+
+// Budget policies
+const (
+	PolicyWarn = "warn" // print a warning
+	PolicyCap  = "cap"  // cap the billable amount
+	PolicyFail = "fail" // fail the invoice generation
+)
+
+// Budget is the not-to-exceed limit in hours, amount or both.
+type Budget struct {
+	Name    string
+	Scope   string
+	Pattern string          `yaml:",omitempty"` // issue regexp, empty matches all issues
+	Hours   decimal.Decimal `yaml:",omitempty"`
+	Amount  decimal.Decimal `yaml:",omitempty"`
+	Policy  string
+	Spent   []BudgetSpend `yaml:",omitempty"` // spend history for issue and period budgets
+
+	re *regexp.Regexp
+}
+
+// BudgetSpend is the amount billed against the budget by one invoice.
+type BudgetSpend struct {
+	Invoice string
+	Period  string
+	Hours   decimal.Decimal
+	Amount  decimal.Decimal
+}
+
+// BudgetStatus is the budget burn-down for one invoice.
+type BudgetStatus struct {
+	Name   string
+	Policy string
+
+	LimitHours  decimal.Decimal `yaml:",omitempty"`
+	LimitAmount decimal.Decimal `yaml:",omitempty"`
+
+	PriorHours  decimal.Decimal `yaml:",omitempty"` // billed by previous invoices
+	PriorAmount decimal.Decimal `yaml:",omitempty"`
+	Hours       decimal.Decimal // billed by this invoice
+	Amount      decimal.Decimal
+
+	Exceeded bool
+
+	budget *Budget
+	period string
+}
+
+func (b *Budget) validate() error {
+	switch b.Scope {
+	case "":
+		b.Scope = BudgetInvoice
+	case BudgetInvoice, BudgetIssue, BudgetPeriod:
+	default:
+		return fmt.Errorf("budget %q: invalid scope: %q", b.Name, b.Scope)
+	}
+	switch b.Policy {
+	case "":
+		b.Policy = PolicyWarn
+	case PolicyWarn, PolicyCap, PolicyFail:
+	default:
+		return fmt.Errorf("budget %q: invalid policy: %q", b.Name, b.Policy)
+	}
+	if !b.Hours.IsPositive() && !b.Amount.IsPositive() {
+		return fmt.Errorf("budget %q: either hours or amount must be set", b.Name)
+	}
+	re, err := regexp.Compile(b.Pattern)
+	if err != nil {
+		return fmt.Errorf("budget %q: %s", b.Name, err)
+	}
+	b.re = re
+	return nil
+}
+
+func (b *Budget) matches(issue string) bool {
+	if b.re == nil {
+		return b.Pattern == "" || b.Pattern == issue
+	}
+	return b.re.MatchString(issue)
+}
+
+// prior returns hours and amount billed against the budget by other
+// invoices.
+func (b *Budget) prior(invoiceID, period string) (hours, amount decimal.Decimal) {
+	for _, s := range b.Spent {
+		if s.Invoice == invoiceID {
+			continue
+		}
+		if b.Scope == BudgetPeriod && s.Period != period {
+			continue
+		}
+		hours = hours.Add(s.Hours)
+		amount = amount.Add(s.Amount)
+	}
+	return
+}
+
+// record records the spend for the invoice, replacing the previous one.
+func (b *Budget) record(st *BudgetStatus, invoiceID string) {
+	if b.Scope == BudgetInvoice {
+		return
+	}
+	s := BudgetSpend{Invoice: invoiceID, Period: st.period, Hours: st.Hours, Amount: st.Amount}
+	for i := range b.Spent {
+		if b.Spent[i].Invoice == invoiceID {
+			b.Spent[i] = s
+			return
+		}
+	}
+	b.Spent = append(b.Spent, s)
+}
+
+// apply checks the budget against the invoice entries, capping them if
+// the policy says so.
+func (b *Budget) apply(invoiceID, period string, entries map[string]InvoiceEntry) (*BudgetStatus, error) {
+	st := BudgetStatus{
+		Name:        b.Name,
+		Policy:      b.Policy,
+		LimitHours:  b.Hours,
+		LimitAmount: b.Amount,
+		budget:      b,
+		period:      period,
+	}
+	if b.Scope != BudgetInvoice {
+		st.PriorHours, st.PriorAmount = b.prior(invoiceID, period)
+	}
+	remHours := b.Hours.Sub(st.PriorHours)
+	remAmount := b.Amount.Sub(st.PriorAmount)
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		if b.matches(entries[k].Issue) {
+			keys = append(keys, k)
+		}
+	}
+	sortNatural(keys)
+
+	for _, k := range keys {
+		entry := entries[k]
+		hours := entry.hours()
+		amount := entry.Total
+		if b.Hours.IsPositive() && hours.GreaterThan(decimal.Max(remHours, decimal.Zero)) {
+			st.Exceeded = true
+			if b.Policy == PolicyCap {
+				hours = decimal.Max(remHours, decimal.Zero)
+				amount = entry.Rate.Mul(hours)
+			}
+		}
+		if b.Amount.IsPositive() && amount.GreaterThan(decimal.Max(remAmount, decimal.Zero)) {
+			st.Exceeded = true
+			if b.Policy == PolicyCap {
+				amount = decimal.Max(remAmount, decimal.Zero)
+				if entry.Rate.IsPositive() {
+					hours = amount.Div(entry.Rate)
+				}
+			}
+		}
+		if b.Policy == PolicyCap && amount.LessThan(entry.Total) {
+			entry.Total = amount
+			entry.Capped = true
+			entries[k] = entry
+		}
+		remHours = remHours.Sub(hours)
+		remAmount = remAmount.Sub(amount)
+		st.Hours = st.Hours.Add(hours)
+		st.Amount = st.Amount.Add(amount)
+	}
+
+	if st.Exceeded && b.Policy == PolicyFail {
+		return &st, fmt.Errorf("invoice %s: budget %q exceeded", invoiceID, b.Name)
+	}
+	return &st, nil
+}
+
+// RemainingHours returns the hours left in the budget.
+func (st *BudgetStatus) RemainingHours() decimal.Decimal {
+	return st.LimitHours.Sub(st.PriorHours).Sub(st.Hours)
+}
+
+// RemainingAmount returns the amount left in the budget.
+func (st *BudgetStatus) RemainingAmount() decimal.Decimal {
+	return st.LimitAmount.Sub(st.PriorAmount).Sub(st.Amount)
+}
+
+// String returns the burn-down summary line.
+func (st *BudgetStatus) String() string {
+	var s = fmt.Sprintf("%s (%s):", st.Name, st.Policy)
+	if st.LimitHours.IsPositive() {
+		used := st.PriorHours.Add(st.Hours)
+		s += fmt.Sprintf(" %s of %s hrs (%s%%), %s hrs remaining;",
+			used.StringFixed(2), st.LimitHours.StringFixed(2),
+			used.Mul(decimal.New(100, 0)).Div(st.LimitHours).StringFixed(0),
+			st.RemainingHours().StringFixed(2))
+	}
+	if st.LimitAmount.IsPositive() {
+		used := st.PriorAmount.Add(st.Amount)
+		s += fmt.Sprintf(" %s of %s (%s%%), %s remaining;",
+			used.StringFixedBank(2), st.LimitAmount.StringFixedBank(2),
+			used.Mul(decimal.New(100, 0)).Div(st.LimitAmount).StringFixed(0),
+			st.RemainingAmount().StringFixedBank(2))
+	}
+	if st.Exceeded {
+		s += " EXCEEDED"
+	}
+	return s
+}
+
+// BudgetSummary prints the burn-down summary for each budget of the
+// invoice.
+func (i *Invoice) BudgetSummary(w io.Writer) error {
+	for _, st := range i.Budgets {
+		if _, err := fmt.Fprintf(w, "invoice %s: budget %s\n", i.InvoiceID, st.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package sheet2inv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBudget_apply(t *testing.T) {
+	entries := func() map[string]InvoiceEntry {
+		return map[string]InvoiceEntry{
+			"A-1": {Issue: "A-1", Duration: 6 * time.Hour, Rate: dec("100"), Total: dec("600")},
+			"A-2": {Issue: "A-2", Duration: 6 * time.Hour, Rate: dec("100"), Total: dec("600")},
+			"B-1": {Issue: "B-1", Duration: 2 * time.Hour, Rate: dec("100"), Total: dec("200")},
+		}
+	}
+	tests := []struct {
+		name         string
+		budget       Budget
+		wantExceeded bool
+		wantErr      bool
+		wantTotals   map[string]string
+	}{
+		{"within budget",
+			Budget{Name: "b", Scope: BudgetInvoice, Policy: PolicyCap, Hours: dec("20")},
+			false, false,
+			map[string]string{"A-1": "600", "A-2": "600", "B-1": "200"},
+		},
+		{"warn",
+			Budget{Name: "b", Scope: BudgetInvoice, Policy: PolicyWarn, Hours: dec("10")},
+			true, false,
+			map[string]string{"A-1": "600", "A-2": "600", "B-1": "200"},
+		},
+		{"cap hours on pattern",
+			Budget{Name: "b", Scope: BudgetIssue, Pattern: "^A-", Policy: PolicyCap, Hours: dec("8")},
+			true, false,
+			map[string]string{"A-1": "600", "A-2": "200", "B-1": "200"},
+		},
+		{"cap amount with prior spend",
+			Budget{Name: "b", Scope: BudgetPeriod, Policy: PolicyCap, Amount: dec("1000"),
+				Spent: []BudgetSpend{{Invoice: "0", Period: "2020-01", Amount: dec("500")}, {Invoice: "x", Period: "2019-12", Amount: dec("500")}}},
+			true, false,
+			map[string]string{"A-1": "500", "A-2": "0", "B-1": "0"},
+		},
+		{"fail",
+			Budget{Name: "b", Scope: BudgetInvoice, Policy: PolicyFail, Amount: dec("1000")},
+			true, true,
+			map[string]string{"A-1": "600", "A-2": "600", "B-1": "200"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.budget.validate(); err != nil {
+				t.Fatal(err)
+			}
+			ee := entries()
+			st, err := tt.budget.apply("1", "2020-01", ee)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Budget.apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if st.Exceeded != tt.wantExceeded {
+				t.Errorf("Budget.apply() Exceeded = %v, want %v", st.Exceeded, tt.wantExceeded)
+			}
+			for k, want := range tt.wantTotals {
+				if got := ee[k].Total; !got.Equal(decimal.RequireFromString(want)) {
+					t.Errorf("Budget.apply() %s total = %v, want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
@@ -25,12 +25,20 @@ type Ticketer interface {
 	Name(issueID string) (name string, err error)
 }
 
+// EpicFinder is implemented by the ticketing systems, that know the epic
+// (parent) of the issue.
+type EpicFinder interface {
+	// Epic returns the epic ID of the issue, or empty string, if the issue
+	// has no epic.
+	Epic(issueID string) (epicID string, err error)
+}
+
 type Jira struct {
 	Site  string `json:"site"`
 	Email string `json:"email"`
 	Token string `json:"token"`
 
-	nameCache map[string]*string
+	cache map[string]*Ticket // nil ticket does not exist
 }
 
 type Ticket struct {
@@ -39,7 +47,14 @@ type Ticket struct {
 }
 
 type Fields struct {
-	Summary string `json:"summary"`
+	Summary string  `json:"summary"`
+	Parent  *Parent `json:"parent,omitempty"`
+}
+
+// Parent is the parent issue, i.e. the epic.
+type Parent struct {
+	Key    string `json:"key"`
+	Fields Fields `json:"fields"`
 }
 
 // NewJira creates a new Jira instance.
@@ -49,7 +64,7 @@ func NewJira(site, email, token string) *Jira {
 		Email: email,
 		Token: token,
 
-		nameCache: make(map[string]*string),
+		cache: make(map[string]*Ticket),
 	}
 }
 
@@ -71,40 +86,65 @@ func JiraFromFile(filename string) (*Jira, error) {
 
 // Name returns the name of the ticket
 func (j *Jira) Name(ticket string) (string, error) {
-	name, ok := j.nameCache[ticket]
+	t, err := j.ticket(ticket)
+	if err != nil {
+		return "", err
+	}
+	return t.Fields.Summary, nil
+}
+
+// Epic returns the epic (parent) of the ticket.
+func (j *Jira) Epic(ticket string) (string, error) {
+	t, err := j.ticket(ticket)
+	if err != nil {
+		return "", err
+	}
+	if t.Fields.Parent == nil {
+		return "", nil
+	}
+	if _, ok := j.cache[t.Fields.Parent.Key]; !ok {
+		j.cache[t.Fields.Parent.Key] = &Ticket{Fields: t.Fields.Parent.Fields}
+	}
+	return t.Fields.Parent.Key, nil
+}
+
+// ticket returns the ticket, fetching it, if it's not cached.
+func (j *Jira) ticket(ticket string) (*Ticket, error) {
+	t, ok := j.cache[ticket]
 	if ok {
-		if name == nil {
-			return "", ErrTicketNotExist
+		if t == nil {
+			return nil, ErrTicketNotExist
 		}
-		return *name, nil
+		return t, nil
 	}
 	uri := fmt.Sprintf(baseURL, j.Site, ticket)
 
 	req, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.SetBasicAuth(j.Email, j.Token)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer resp.Body.Close()
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	t := Ticket{}
-	if err := json.Unmarshal(data, &t); err != nil {
-		return "", err
+	t = &Ticket{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
 	}
 	if t.ErrorMsgs != nil {
 		msg := t.ErrorMsgs[0]
 		if strings.Compare(msg, jiraNotExist) == 0 {
-			j.nameCache[ticket] = nil
-			return "", ErrTicketNotExist
+			j.cache[ticket] = nil
+			return nil, ErrTicketNotExist
 		}
-		return "", errors.New(msg)
+		return nil, errors.New(msg)
 	}
-	j.nameCache[ticket] = &t.Fields.Summary
-	return t.Fields.Summary, nil
+	j.cache[ticket] = t
+	return t, nil
 }
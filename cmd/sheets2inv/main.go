@@ -7,11 +7,14 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/rusq/sheet2inv"
 	"github.com/rusq/sheet2inv/bugtracker"
+	"github.com/rusq/sheet2inv/forms"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/sheets/v4"
 	"gopkg.in/yaml.v3"
@@ -25,10 +28,31 @@ var (
 	ticketCreds = flag.String("ticket-cred", "ticket-creds.json", "ticketing system credentials `filename`")
 	export      = flag.String("export", "", "export timesheet to `file` in yaml or json format, use \"-\" for stdout")
 	cfgFile     = flag.String("f", "fields.yaml", "timesheet config `file`")
+	htmlOut     = flag.Bool("html", false, "also generate html invoices")
+	ublOut      = flag.Bool("ubl", false, "also generate UBL 2.1 (Peppol BIS Billing 3.0) xml e-invoices")
+	facturX     = flag.String("facturx", "", "generate Factur-X hybrid pdf invoices with the CII `profile` (MINIMUM, BASIC or EN16931), overrides the config")
+	status      = flag.String("status", "", "print invoices with the `status` stamp: draft, paid or void, overrides the config; drafts and void invoices don't draw down the retainer and budgets")
+	signKey     = flag.String("sign-key", "", "sign pdf invoices with the PKCS#12 key `file`, the signature is plain PKCS#7 (adbe.pkcs7.detached), not PAdES; unlicensed unipdf prints its notice to stderr, see UNIPDF_LICENSE_KEY")
+	signPass    = flag.String("sign-pass", "", "signing key password `source`: pass:password, env:VAR, file:path or stdin")
+	trustFile   = flag.String("trust", "", "PEM `file` with the trusted certificates for verify, default are the system roots")
+	journalOut  = flag.String("journal", "", "export the accounting journal of all generated invoices to `file`, the format is chosen by -journal-format, the extension (.ledger, .journal, .beancount) or the config")
+	journalFmt  = flag.String("journal-format", "", "journal `format`: ledger, hledger or beancount, overrides the extension and the config")
+	journalOpen = flag.Bool("journal-open", true, "start the beancount journal with the open directives of the accounts, disable when appending to the journal with the accounts open")
+	linesOut    = flag.String("lines", "", "export the lines of all generated invoices to the csv or xlsx `file`, the format is chosen by the extension")
+	clients     = flag.String("clients", sheet2inv.AllClients, "comma separated client profile `names` to generate invoices for, or \"all\"")
 
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
 )
 
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [invoice no.]\n", os.Args[0])
+	fmt.Fprintf(out, "       %s [flags] recurring\n", os.Args[0])
+	fmt.Fprintf(out, "       %s [flags] verify file.pdf...\n\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nEnvironment:\n  UNIPDF_LICENSE_KEY, UNIPDF_CUSTOMER\n    \tunipdf license, used by the unipdf renderer, -sign-key and verify;\n    \twithout it unipdf prints the unlicensed notice to stderr, and the\n    \tunipdf renderer adds the watermark\n")
+}
+
 func invoiceFromArgs() string {
 	args := flag.Args()
 	if len(args) > 0 {
@@ -38,10 +62,70 @@ func invoiceFromArgs() string {
 }
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
+	if key := os.Getenv("UNIPDF_LICENSE_KEY"); key != "" {
+		if err := forms.SetUnipdfLicense(key, os.Getenv("UNIPDF_CUSTOMER")); err != nil {
+			log.Fatalf("unipdf license: %s", err)
+		}
+	}
+
+	if err := forms.ValidStatus(*status); err != nil {
+		log.Fatal(err)
+	}
+	if err := sheet2inv.ValidJournalFormat(*journalFmt); err != nil {
+		log.Fatal(err)
+	}
+
+	if flag.Arg(0) == "verify" {
+		warnUnlicensed()
+		if err := runVerify(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *signKey != "" {
+		warnUnlicensed()
+		var err error
+		if signPassword, err = readPassword(*signPass); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	profiles, err := sheet2inv.NewProfilesFromFile(*cfgFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names, err := profiles.Select(*clients)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, name := range names {
+		if cfg := profiles.Get(name); cfg.Values != nil && strings.EqualFold(cfg.Values.Renderer, forms.RendererUnipdf) {
+			warnUnlicensed()
+			break
+		}
+	}
+
+	if flag.Arg(0) == "recurring" {
+		if err := runRecurring(profiles, names); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	invoiceNo := invoiceFromArgs()
 
+	// the journal format is checked before the invoices are generated.
+	var journalFormat string
+	if *journalOut != "" {
+		if journalFormat, err = journalFormatOf(*journalOut, profiles, names); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	b, err := ioutil.ReadFile(*credFile)
 	if err != nil {
 		log.Fatalf("Unable to read client secret file: %v", err)
@@ -59,39 +143,79 @@ func main() {
 		log.Fatalf("Unable to retrieve Sheets client: %v", err)
 	}
 
-	cfg, err := sheet2inv.NewConfigFromFile(*cfgFile)
+	jira, err := bugtracker.JiraFromFile(*ticketCreds)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	timesheet, err := sheet2inv.NewFromSheets(srv, cfg, invoiceNo)
+	// rows of the spreadsheets shared between client profiles are fetched
+	// once.
+	var (
+		sheetRows = make(map[string][][]interface{})
+		routed    = make(map[string]map[string]*sheet2inv.Timesheet)
+		out       exports
+	)
+	for _, name := range names {
+		cfg := profiles.Get(name)
+		key := cfg.Spreadsheet.ID + "!" + cfg.Spreadsheet.Range
+		rows, ok := sheetRows[key]
+		if !ok {
+			rows, err = sheet2inv.FetchRows(srv, cfg.Spreadsheet)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sheetRows[key] = rows
+		}
 
-	jira, err := bugtracker.JiraFromFile(*ticketCreds)
-	if err != nil {
-		log.Fatal(err)
-	}
+		var timesheet *sheet2inv.Timesheet
+		if profiles.Routed() {
+			timesheets, ok := routed[key]
+			if !ok {
+				var unrouted []sheet2inv.Unrouted
+				timesheets, unrouted, err = profiles.RouteRows(cfg.Spreadsheet, rows, invoiceNo)
+				if err != nil {
+					log.Fatal(err)
+				}
+				for _, u := range unrouted {
+					fmt.Fprintln(os.Stderr, u)
+				}
+				routed[key] = timesheets
+			}
+			if timesheet = timesheets[name]; timesheet == nil {
+				timesheet = sheet2inv.New(cfg)
+			}
+		} else {
+			timesheet, err = sheet2inv.NewFromRows(cfg, rows, invoiceNo)
+			if err != nil {
+				log.Fatalf("client %q: %s", name, err)
+			}
+		}
 
-	if *export != "" {
-		saveTo(*export, timesheet)
+		if *export != "" {
+			if err := saveTo(exportFilename(*export, name, len(names) > 1), timesheet); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if err := generate(cfg, timesheet.Invoices(jira), &out); err != nil {
+			log.Fatalf("client %q: %s", name, err)
+		}
 	}
 
-	invoices := timesheet.Invoices(jira)
-	for no := range invoices.Invoices {
-		if err := invoices.Get(no).ToPDF(fmt.Sprintf("invoice-%s.pdf", no)); err != nil {
+	if *linesOut != "" {
+		if err := writeLines(*linesOut, out.lines); err != nil {
 			log.Fatal(err)
 		}
+		fmt.Println(*linesOut)
 	}
-
-	if debug {
-		// debugging output
-		if data, err := yaml.Marshal(invoices); err != nil {
+	if *journalOut != "" {
+		if err := writeJournal(*journalOut, journalFormat, out.txns); err != nil {
 			log.Fatal(err)
-		} else {
-			fmt.Println(string(data))
 		}
+		fmt.Println(*journalOut)
 	}
 
-	if err := timesheet.SaveConfig(*cfgFile); err != nil {
+	if err := profiles.Save(*cfgFile); err != nil {
 		log.Fatal(err)
 	}
 
@@ -110,9 +234,175 @@ func main() {
 
 }
 
+// exports are the lines and journal transactions of the generated invoices
+// of all clients.
+type exports struct {
+	lines []sheet2inv.LineRow
+	txns  []sheet2inv.Transaction
+}
+
+// generate generates the invoice files, and adds the lines and transactions
+// of the generated invoices to out.
+func generate(cfg *sheet2inv.TimesheetConfig, invoices *sheet2inv.Invoices, out *exports) error {
+	for _, no := range invoices.IDs() {
+		// recalculating, as the previous invoice could have drawn down
+		// the retainer.
+		inv := invoices.Get(no).Recalculate()
+		if err := inv.BudgetSummary(os.Stdout); err != nil {
+			return err
+		}
+		if err := write(cfg, inv); err != nil {
+			return err
+		}
+		rows, err := inv.LineRows()
+		if err != nil {
+			return err
+		}
+		txns, err := inv.Transactions()
+		if err != nil {
+			return err
+		}
+		out.lines = append(out.lines, rows...)
+		out.txns = append(out.txns, txns...)
+		inv.Commit()
+	}
+
+	if debug {
+		// debugging output
+		data, err := yaml.Marshal(invoices)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+// journalExts are the journal formats by the file extension.
+var journalExts = map[string]string{
+	".ledger":    sheet2inv.JournalLedger,
+	".journal":   sheet2inv.JournalHledger,
+	".hledger":   sheet2inv.JournalHledger,
+	".beancount": sheet2inv.JournalBeancount,
+	".bean":      sheet2inv.JournalBeancount,
+}
+
+// writeJournal writes the transactions to the journal file in the format.
+func writeJournal(filename, format string, txns []sheet2inv.Transaction) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := sheet2inv.WriteJournal(f, format, txns, *journalOpen); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// journalFormatOf returns the journal format: the flag value, the format of
+// the file extension, or the configured format, if all clients use the same.
+func journalFormatOf(filename string, profiles *sheet2inv.Profiles, names []string) (string, error) {
+	if *journalFmt != "" {
+		return *journalFmt, nil
+	}
+	if f, ok := journalExts[strings.ToLower(filepath.Ext(filename))]; ok {
+		return f, nil
+	}
+	var configured []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if f := profiles.Get(name).Values.JournalFormat(); !seen[f] {
+			seen[f] = true
+			configured = append(configured, f)
+		}
+	}
+	if len(configured) > 1 {
+		return "", fmt.Errorf("journal: clients use different formats (%s), set -journal-format", strings.Join(configured, ", "))
+	}
+	if len(configured) == 0 {
+		return sheet2inv.JournalLedger, nil
+	}
+	return configured[0], nil
+}
+
+// writeLines writes the invoice lines to the csv or xlsx file.
+func writeLines(filename string, lines []sheet2inv.LineRow) error {
+	write := sheet2inv.WriteLinesCSV
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".csv":
+	case ".xlsx":
+		write = sheet2inv.WriteLinesXLSX
+	default:
+		return fmt.Errorf("unsupported lines export format %q, must be .csv or .xlsx", ext)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f, lines); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// write writes the invoice files in all requested formats.
+func write(cfg *sheet2inv.TimesheetConfig, inv *sheet2inv.Invoice) error {
+	if *status != "" {
+		if err := inv.SetStatus(*status); err != nil {
+			return err
+		}
+	}
+	filename := cfg.Filename(inv.InvoiceID, ".pdf")
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	profile := cfg.FacturX()
+	if *facturX != "" {
+		profile = *facturX
+	}
+	if profile != "" {
+		if err := inv.ToFacturX(filename, profile); err != nil {
+			return err
+		}
+	} else if err := inv.ToPDF(filename); err != nil {
+		return err
+	}
+	if err := sign(cfg, filename); err != nil {
+		return err
+	}
+	fmt.Println(filename)
+	if *htmlOut {
+		filename := cfg.Filename(inv.InvoiceID, ".html")
+		if err := inv.ToHTML(filename, cfg.HTMLTemplates()...); err != nil {
+			return err
+		}
+		fmt.Println(filename)
+	}
+	if *ublOut {
+		filename := cfg.Filename(inv.InvoiceID, ".xml")
+		if err := inv.ToUBL(filename); err != nil {
+			return err
+		}
+		fmt.Println(filename)
+	}
+	return nil
+}
+
+// exportFilename returns the timesheet export filename for the client.  If
+// there are several clients, client name is added to the filename.
+func exportFilename(filename, client string, several bool) string {
+	if filename == "-" || !several {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-" + client + ext
+}
+
 func saveTo(filename string, timesheet *sheet2inv.Timesheet) error {
 	var output io.Writer
-	if *export == "-" {
+	if filename == "-" {
 		output = os.Stdout
 	} else {
 		f, err := os.Create(filename)
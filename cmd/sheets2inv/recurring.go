@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rusq/sheet2inv"
+)
+
+// runRecurring generates all recurring invoices of the selected clients
+// that are due since the last run and records the issued periods in the
+// config file.
+func runRecurring(profiles *sheet2inv.Profiles, names []string) error {
+	var n int
+	for _, name := range names {
+		cfg := profiles.Get(name)
+		for _, inv := range cfg.RecurringDue(time.Now()) {
+			if err := write(cfg, inv); err != nil {
+				return err
+			}
+			inv.Commit()
+			n++
+		}
+	}
+	if n == 0 {
+		fmt.Println("No recurring invoices due.")
+		return nil
+	}
+	return profiles.Save(*cfgFile)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusq/sheet2inv"
+	"github.com/rusq/sheet2inv/forms"
+)
+
+// signPassword is the signing key password, read once from the password
+// source.
+var signPassword string
+
+// readPassword reads the password from the source: "pass:password",
+// "env:VAR", "file:path" (the first line of the file) or "stdin".  Empty
+// source is the empty password.
+func readPassword(source string) (string, error) {
+	kind, arg := source, ""
+	if i := strings.IndexByte(source, ':'); i >= 0 {
+		kind, arg = source[:i], source[i+1:]
+	}
+	switch kind {
+	case "":
+		return "", nil
+	case "pass":
+		return arg, nil
+	case "env":
+		pass, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", fmt.Errorf("password source: environment variable %q is not set", arg)
+		}
+		return pass, nil
+	case "file":
+		data, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return "", fmt.Errorf("password source: %s", err)
+		}
+		return firstLine(string(data)), nil
+	case "stdin":
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("password source: %s", err)
+		}
+		return firstLine(line), nil
+	default:
+		return "", fmt.Errorf("password source: unknown source %q, must be pass:, env:, file: or stdin", source)
+	}
+}
+
+// firstLine returns the first line of s without the line ending.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "\r")
+}
+
+// warnUnlicensed warns on stderr, that unipdf is not licensed: it prints
+// its notice, and the unipdf renderer output is watermarked.
+func warnUnlicensed() {
+	if !forms.UnipdfLicensed() {
+		unlicensedOnce.Do(func() {
+			fmt.Fprintln(os.Stderr, "warning: unipdf is not licensed, it prints the unlicensed notice to stderr, and the unipdf renderer adds the watermark to invoices; set UNIPDF_LICENSE_KEY and UNIPDF_CUSTOMER to remove them")
+		})
+	}
+}
+
+var unlicensedOnce sync.Once
+
+// sign signs the pdf invoice file, if the signing key is given.
+func sign(cfg *sheet2inv.TimesheetConfig, filename string) error {
+	if *signKey == "" {
+		return nil
+	}
+	s, err := forms.LoadSigner(*signKey, signPassword, cfg.SignOptions())
+	if err != nil {
+		return err
+	}
+	return s.SignFile(filename)
+}
+
+// runVerify checks the signatures of the pdf files.  The file is valid, if
+// all signatures are verified, and one of them covers the whole document.
+// It returns an error, if any of the files is not valid.
+func runVerify(files []string) error {
+	if len(files) == 0 {
+		return errors.New("verify: no files given")
+	}
+	var roots *x509.CertPool
+	if *trustFile != "" {
+		var err error
+		if roots, err = forms.LoadRoots(*trustFile); err != nil {
+			return err
+		}
+	}
+	var invalid int
+	for _, filename := range files {
+		sts, err := forms.VerifyFile(filename, roots)
+		if err != nil {
+			fmt.Printf("%s: %s\n", filename, err)
+			invalid++
+			continue
+		}
+		valid, whole := true, false
+		for _, st := range sts {
+			fmt.Printf("%s: signed by %q at %s\n", filename, st.Name, st.Date.Format(time.RFC3339))
+			fmt.Printf("\tcertificate: %s\n", st.Subject)
+			if st.Reason != "" {
+				fmt.Printf("\treason: %s\n", st.Reason)
+			}
+			switch {
+			case !st.Verified:
+				fmt.Printf("\tINVALID: %s\n", st.Err)
+				valid = false
+			case st.Whole:
+				fmt.Println("\tsignature is valid")
+			default:
+				fmt.Println("\tsignature is valid for the earlier revision")
+			}
+			if st.Verified && !st.Trusted {
+				fmt.Println("\twarning: the certificate is not trusted")
+			}
+			whole = whole || st.Whole
+		}
+		if valid && !whole {
+			fmt.Printf("%s: INVALID: the document was changed after signing\n", filename)
+		}
+		if !valid || !whole {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("verify: %d of %d files are not valid", invalid, len(files))
+	}
+	return nil
+}
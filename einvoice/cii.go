@@ -0,0 +1,390 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Factur-X profiles
+const (
+	ProfileMinimum = "MINIMUM"
+	ProfileBasic   = "BASIC"
+	ProfileEN16931 = "EN16931"
+)
+
+const (
+	rsmNS = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	ramNS = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	udtNS = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+	qdtNS = "urn:un:unece:uncefact:data:standard:QualifiedDataType:100"
+
+	ciiDateFmt    = "20060102"
+	ciiDateFormat = "102" // UNTDID 2379 CCYYMMDD
+	schemeVAT     = "VA"
+	schemeFiscal  = "FC" // tax registration other than VAT
+)
+
+// profiles maps the profile to the guideline identifier and the XMP
+// conformance level.
+var profiles = map[string]struct {
+	guideline string
+	level     string
+}{
+	ProfileMinimum: {"urn:factur-x.eu:1p0:minimum", "MINIMUM"},
+	ProfileBasic:   {"urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic", "BASIC"},
+	ProfileEN16931: {"urn:cen.eu:en16931:2017", "EN 16931"},
+}
+
+var reIBAN = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{10,30}$`)
+
+// NormaliseProfile returns the canonical Factur-X profile name, or an
+// error if the profile is unknown.
+func NormaliseProfile(profile string) (string, error) {
+	p := strings.ToUpper(strings.Replace(profile, " ", "", -1))
+	if _, ok := profiles[p]; !ok {
+		return "", fmt.Errorf("unknown Factur-X profile: %q", profile)
+	}
+	return p, nil
+}
+
+type ciiInvoice struct {
+	XMLName xml.Name `xml:"rsm:CrossIndustryInvoice"`
+	RSM     string   `xml:"xmlns:rsm,attr"`
+	RAM     string   `xml:"xmlns:ram,attr"`
+	UDT     string   `xml:"xmlns:udt,attr"`
+	QDT     string   `xml:"xmlns:qdt,attr"`
+
+	Guideline   string         `xml:"rsm:ExchangedDocumentContext>ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+	Document    ciiDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction ciiTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiDocument struct {
+	ID        string    `xml:"ram:ID"`
+	TypeCode  string    `xml:"ram:TypeCode"`
+	IssueDate ciiDate   `xml:"ram:IssueDateTime"`
+	Notes     []ciiNote `xml:"ram:IncludedNote"`
+}
+
+type ciiNote struct {
+	Content string `xml:"ram:Content"`
+}
+
+type ciiDate struct {
+	Value ciiDateString `xml:"udt:DateTimeString"`
+}
+
+type ciiDateString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiTransaction struct {
+	Lines      []ciiLine     `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  ciiAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}      `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement ciiSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLine struct {
+	LineID     string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Name       string            `xml:"ram:SpecifiedTradeProduct>ram:Name"`
+	NetPrice   string            `xml:"ram:SpecifiedLineTradeAgreement>ram:NetPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   ciiQty            `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement ciiLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiQty struct {
+	Unit  string `xml:"unitCode,attr"`
+	Value string `xml:",chardata"`
+}
+
+type ciiLineSettlement struct {
+	Tax   ciiTax `xml:"ram:ApplicableTradeTax"`
+	Total string `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation>ram:LineTotalAmount"`
+}
+
+type ciiTax struct {
+	CalculatedAmount string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode         string `xml:"ram:TypeCode"`
+	ExemptionReason  string `xml:"ram:ExemptionReason,omitempty"`
+	BasisAmount      string `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode     string `xml:"ram:CategoryCode"`
+	Percent          string `xml:"ram:RateApplicablePercent,omitempty"`
+}
+
+type ciiAgreement struct {
+	BuyerReference string   `xml:"ram:BuyerReference,omitempty"`
+	Seller         ciiParty `xml:"ram:SellerTradeParty"`
+	Buyer          ciiParty `xml:"ram:BuyerTradeParty"`
+	BuyerOrder     *ciiID   `xml:"ram:BuyerOrderReferencedDocument>ram:IssuerAssignedID,omitempty"`
+	Contract       *ciiID   `xml:"ram:ContractReferencedDocument>ram:IssuerAssignedID,omitempty"`
+}
+
+type ciiParty struct {
+	Name     string      `xml:"ram:Name"`
+	LegalOrg *ciiID      `xml:"ram:SpecifiedLegalOrganization>ram:ID,omitempty"`
+	Address  *ciiAddress `xml:"ram:PostalTradeAddress,omitempty"`
+	URI      *ciiID      `xml:"ram:URIUniversalCommunication>ram:URIID,omitempty"`
+	TaxRegs  []ciiTaxReg `xml:"ram:SpecifiedTaxRegistration"`
+}
+
+type ciiTaxReg struct {
+	ID ciiID `xml:"ram:ID"`
+}
+
+type ciiAddress struct {
+	Postcode string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne  string `xml:"ram:LineOne,omitempty"`
+	LineTwo  string `xml:"ram:LineTwo,omitempty"`
+	City     string `xml:"ram:CityName,omitempty"`
+	Country  string `xml:"ram:CountryID"`
+}
+
+type ciiID struct {
+	Scheme string `xml:"schemeID,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiSettlement struct {
+	PaymentReference string               `xml:"ram:PaymentReference,omitempty"`
+	Currency         string               `xml:"ram:InvoiceCurrencyCode"`
+	PaymentMeans     *ciiPaymentMeans     `xml:"ram:SpecifiedTradeSettlementPaymentMeans,omitempty"`
+	Tax              *ciiTax              `xml:"ram:ApplicableTradeTax,omitempty"`
+	Period           *ciiPeriod           `xml:"ram:BillingSpecifiedPeriod,omitempty"`
+	AllowanceCharges []ciiAllowanceCharge `xml:"ram:SpecifiedTradeAllowanceCharge"`
+	DueDate          *ciiDate             `xml:"ram:SpecifiedTradePaymentTerms>ram:DueDateDateTime,omitempty"`
+	Summation        ciiSummation         `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type ciiPaymentMeans struct {
+	TypeCode string           `xml:"ram:TypeCode"`
+	Account  *ciiCreditorAcct `xml:"ram:PayeePartyCreditorFinancialAccount,omitempty"`
+	BIC      *ciiID           `xml:"ram:PayeeSpecifiedCreditorFinancialInstitution>ram:BICID,omitempty"`
+}
+
+type ciiCreditorAcct struct {
+	IBAN          string `xml:"ram:IBANID,omitempty"`
+	AccountName   string `xml:"ram:AccountName,omitempty"`
+	ProprietaryID string `xml:"ram:ProprietaryID,omitempty"`
+}
+
+type ciiPeriod struct {
+	Start *ciiDate `xml:"ram:StartDateTime,omitempty"`
+	End   *ciiDate `xml:"ram:EndDateTime,omitempty"`
+}
+
+type ciiAllowanceCharge struct {
+	ChargeIndicator bool   `xml:"ram:ChargeIndicator>udt:Indicator"`
+	Amount          string `xml:"ram:ActualAmount"`
+	Reason          string `xml:"ram:Reason,omitempty"`
+	Tax             ciiTax `xml:"ram:CategoryTradeTax"`
+}
+
+type ciiSummation struct {
+	LineTotal      string    `xml:"ram:LineTotalAmount,omitempty"`
+	ChargeTotal    string    `xml:"ram:ChargeTotalAmount,omitempty"`
+	AllowanceTotal string    `xml:"ram:AllowanceTotalAmount,omitempty"`
+	TaxBasis       string    `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotal       ciiAmount `xml:"ram:TaxTotalAmount"`
+	GrandTotal     string    `xml:"ram:GrandTotalAmount"`
+	DuePayable     string    `xml:"ram:DuePayableAmount"`
+}
+
+type ciiAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// WriteCII validates the document for the Factur-X profile and writes it to
+// w as UN/CEFACT Cross Industry Invoice.
+func (d *Document) WriteCII(w io.Writer, profile string) error {
+	profile, err := NormaliseProfile(profile)
+	if err != nil {
+		return err
+	}
+	level := levelEN16931
+	if profile == ProfileMinimum {
+		level = levelMinimum
+	}
+	if err := d.validate(level); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d.cii(profile)); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// ciiDay returns the date in CII format, or an empty string for zero time.
+func ciiDay(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(ciiDateFmt)
+}
+
+func (d *Document) ciiTax(withAmounts bool) ciiTax {
+	t := ciiTax{TypeCode: taxSchemeVAT, CategoryCode: d.TaxCategory}
+	if d.TaxCategory != TaxNotSubject {
+		t.Percent = d.TaxPercent.String()
+	}
+	if withAmounts {
+		t.CalculatedAmount = amount(d.Tax())
+		t.BasisAmount = amount(d.TaxExclusive())
+		switch d.TaxCategory {
+		case TaxExempt:
+			t.ExemptionReason = "Exempt from tax"
+		case TaxNotSubject:
+			t.ExemptionReason = "Not subject to VAT"
+		}
+	}
+	return t
+}
+
+func (d *Document) cii(profile string) *ciiInvoice {
+	minimum := profile == ProfileMinimum
+	dt := func(t string) *ciiDate {
+		if t == "" {
+			return nil
+		}
+		return &ciiDate{ciiDateString{Format: ciiDateFormat, Value: t}}
+	}
+	inv := ciiInvoice{
+		RSM: rsmNS,
+		RAM: ramNS,
+		UDT: udtNS,
+		QDT: qdtNS,
+
+		Guideline: profiles[profile].guideline,
+		Document: ciiDocument{
+			ID:        d.ID,
+			TypeCode:  invoiceTypeCommercial,
+			IssueDate: *dt(ciiDay(d.IssueDate)),
+		},
+	}
+	tr := &inv.Transaction
+	tr.Agreement = ciiAgreement{
+		BuyerReference: d.BuyerReference,
+		Seller:         d.Seller.cii(minimum),
+		Buyer:          d.Buyer.cii(minimum),
+		BuyerOrder:     optID(d.OrderReference),
+		Contract:       optID(d.ContractReference),
+	}
+	if minimum {
+		// buyer name is the only required buyer element, and contract
+		// reference is not part of MINIMUM
+		tr.Agreement.Buyer = ciiParty{Name: d.Buyer.Name}
+		tr.Agreement.Contract = nil
+	}
+	tr.Settlement = ciiSettlement{
+		Currency: d.Currency,
+		Summation: ciiSummation{
+			TaxBasis:   amount(d.TaxExclusive()),
+			TaxTotal:   ciiAmount{Currency: d.Currency, Value: amount(d.Tax())},
+			GrandTotal: amount(d.TaxInclusive()),
+			DuePayable: amount(d.TaxInclusive()),
+		},
+	}
+	if minimum {
+		return &inv
+	}
+
+	if d.Note != "" {
+		inv.Document.Notes = []ciiNote{{d.Note}}
+	}
+	for _, l := range d.Lines {
+		tr.Lines = append(tr.Lines, ciiLine{
+			LineID:     l.ID,
+			Name:       l.Name,
+			NetPrice:   l.Price.String(),
+			Quantity:   ciiQty{Unit: unitHour, Value: l.Quantity.Round(4).String()},
+			Settlement: ciiLineSettlement{Tax: d.ciiTax(false), Total: amount(l.Net)},
+		})
+	}
+
+	st := &tr.Settlement
+	st.PaymentReference = d.Payment.Reference
+	st.PaymentMeans = &ciiPaymentMeans{TypeCode: paymentCreditTransfer, Account: &ciiCreditorAcct{AccountName: d.Payment.AccountName}}
+	if acc := strings.Replace(d.Payment.Account, " ", "", -1); reIBAN.MatchString(acc) {
+		st.PaymentMeans.Account.IBAN = acc
+	} else {
+		st.PaymentMeans.Account.ProprietaryID = d.Payment.Account
+	}
+	if profile == ProfileEN16931 {
+		st.PaymentMeans.BIC = optID(d.Payment.Bank)
+	} else {
+		// account name is not part of the BASIC profile
+		st.PaymentMeans.Account.AccountName = ""
+	}
+	tax := d.ciiTax(true)
+	st.Tax = &tax
+	if !d.PeriodStart.IsZero() || !d.PeriodEnd.IsZero() {
+		st.Period = &ciiPeriod{Start: dt(ciiDay(d.PeriodStart)), End: dt(ciiDay(d.PeriodEnd))}
+	}
+	for _, a := range d.Adjustments {
+		st.AllowanceCharges = append(st.AllowanceCharges, ciiAllowanceCharge{
+			ChargeIndicator: !a.Amount.IsNegative(),
+			Amount:          amount(a.Amount.Abs()),
+			Reason:          a.Reason,
+			Tax:             d.ciiTax(false),
+		})
+	}
+	st.DueDate = dt(ciiDay(d.DueDate))
+
+	allowance, charge := d.allowances()
+	st.Summation.LineTotal = amount(d.LineTotal())
+	st.Summation.ChargeTotal = amount(charge)
+	st.Summation.AllowanceTotal = amount(allowance)
+
+	return &inv
+}
+
+// optID returns the identifier, or nil, if the value is empty, so that the
+// element is omitted.
+func optID(value string) *ciiID {
+	if value == "" {
+		return nil
+	}
+	return &ciiID{Value: value}
+}
+
+func (p *Party) cii(minimum bool) ciiParty {
+	party := ciiParty{Name: p.Name}
+	if p.LegalID != "" {
+		party.LegalOrg = &ciiID{Value: p.LegalID}
+	}
+	if p.VATID != "" {
+		party.TaxRegs = append(party.TaxRegs, ciiTaxReg{ciiID{Scheme: schemeVAT, Value: p.VATID}})
+	}
+	if p.TaxID != "" {
+		party.TaxRegs = append(party.TaxRegs, ciiTaxReg{ciiID{Scheme: schemeFiscal, Value: p.TaxID}})
+	}
+	if minimum {
+		// only the country is required in MINIMUM profile
+		if p.CountryCode != "" {
+			party.Address = &ciiAddress{Country: p.CountryCode}
+		}
+		return party
+	}
+	party.Address = &ciiAddress{
+		Postcode: p.Postcode,
+		LineOne:  p.Street,
+		LineTwo:  p.Additional,
+		City:     p.City,
+		Country:  p.CountryCode,
+	}
+	if p.Email != "" {
+		party.URI = &ciiID{Scheme: endpointEmail, Value: p.Email}
+	}
+	return party
+}
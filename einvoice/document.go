@@ -0,0 +1,223 @@
+// Package einvoice implements structured electronic invoice formats: UBL 2.1
+// (Peppol BIS Billing 3.0) and UN/CEFACT Cross Industry Invoice (Factur-X).
+package einvoice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tax categories (UNCL5305)
+const (
+	TaxStandard   = "S" // standard rate
+	TaxZero       = "Z" // zero rated goods
+	TaxExempt     = "E" // exempt from tax
+	TaxNotSubject = "O" // services outside scope of tax
+)
+
+const (
+	unitHour = "HUR" // UN/ECE Rec 20 unit code for hour
+	dateFmt  = "2006-01-02"
+)
+
+// Document is the electronic invoice document, mapped from the invoice.
+type Document struct {
+	ID        string
+	IssueDate time.Time
+	DueDate   time.Time
+	Currency  string // ISO 4217 currency code
+	Note      string
+
+	BuyerReference    string // buyer reference (BT-10)
+	OrderReference    string // purchase order reference (BT-13)
+	ContractReference string // contract reference (BT-12)
+
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+
+	Seller Party
+	Buyer  Party
+
+	Lines       []Line
+	Adjustments []Adjustment // document level allowances and charges
+
+	TaxCategory string          // tax category code, see Tax* constants
+	TaxPercent  decimal.Decimal // tax rate, i.e. 15 for 15%
+
+	Payment Payment
+
+	Info *Info // PDF document information of Factur-X, default is derived from the document
+}
+
+// Info is the PDF document information, mirrored in the XMP metadata, as
+// PDF/A requires both to match.
+type Info struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string    // creator application
+	Created  time.Time // creation date, written without the time zone, as gofpdf does
+}
+
+// Party is the seller or the buyer.
+type Party struct {
+	Name        string
+	Street      string
+	Additional  string // additional street line
+	City        string
+	Postcode    string
+	CountryCode string // ISO 3166-1 alpha-2 country code
+	VATID       string // VAT identifier, with the country prefix
+	TaxID       string // tax registration identifier (BT-32), other than VAT
+	LegalID     string // legal registration identifier (BT-30)
+	Email       string
+	Phone       string
+}
+
+// Line is the invoice line.
+type Line struct {
+	ID       string
+	Name     string
+	Quantity decimal.Decimal // quantity in hours
+	Price    decimal.Decimal // unit price
+	Net      decimal.Decimal // line net amount
+}
+
+// Adjustment is the document level allowance (negative amount) or charge
+// (positive amount).
+type Adjustment struct {
+	Reason string
+	Amount decimal.Decimal
+}
+
+// Payment is the payment instructions.
+type Payment struct {
+	Account     string // payment account identifier, i.e. IBAN
+	AccountName string
+	Bank        string // payment service provider identifier, i.e. BIC
+	Reference   string // remittance information
+}
+
+// ValidationError contains all missing or invalid elements of the document.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid e-invoice: " + strings.Join(e, "; ")
+}
+
+// validation levels
+const (
+	levelMinimum = iota // Factur-X MINIMUM
+	levelEN16931        // EN 16931 core, Factur-X BASIC and EN16931
+	levelPeppol         // Peppol BIS Billing 3.0
+)
+
+// Validate checks that the document has all elements required by
+// EN 16931 and Peppol BIS Billing 3.0.
+func (d *Document) Validate() error {
+	return d.validate(levelPeppol)
+}
+
+func (d *Document) validate(level int) error {
+	var errs ValidationError
+	req := func(ok bool, format string, a ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, a...))
+		}
+	}
+	req(d.ID != "", "invoice number (BT-1) is missing")
+	req(!d.IssueDate.IsZero(), "invoice date (BT-2) is missing")
+	req(len(d.Currency) == 3, "invoice currency code (BT-5) is missing or invalid: %q", d.Currency)
+	req(d.DueDate.IsZero() || !d.DueDate.Before(d.IssueDate), "due date (BT-9) is before the invoice date")
+
+	req(d.Seller.Name != "", "seller name (BT-27) is missing")
+	req(len(d.Seller.CountryCode) == 2, "seller country code (BT-40) is missing or invalid: %q", d.Seller.CountryCode)
+	req(d.Buyer.Name != "", "buyer name (BT-44) is missing")
+
+	switch d.TaxCategory {
+	case TaxStandard:
+		req(d.TaxPercent.IsPositive(), "standard rated tax requires positive tax rate")
+		fallthrough
+	case TaxZero, TaxExempt:
+		req(d.Seller.VATID != "" || d.Seller.TaxID != "", "seller VAT identifier (BT-31) or tax registration identifier (BT-32) is missing")
+	case TaxNotSubject:
+		req(d.Seller.VATID == "" && d.Buyer.VATID == "", "VAT identifiers must not be set for services outside scope of tax")
+	default:
+		errs = append(errs, fmt.Sprintf("invalid tax category: %q", d.TaxCategory))
+	}
+
+	if level >= levelEN16931 {
+		req(len(d.Buyer.CountryCode) == 2, "buyer country code (BT-55) is missing or invalid: %q", d.Buyer.CountryCode)
+		req(len(d.Lines) > 0, "invoice must have at least one line (BG-25)")
+		for i, l := range d.Lines {
+			req(l.Name != "", "line %d: item name (BT-153) is missing", i+1)
+		}
+		req(d.Payment.Account != "", "payment account identifier (BT-84) is missing")
+	}
+	if level >= levelPeppol {
+		req(d.BuyerReference != "", "buyer reference (BT-10) is missing")
+		req(d.Seller.Email != "", "seller electronic address (BT-34) is missing")
+		req(d.Buyer.Email != "", "buyer electronic address (BT-49) is missing")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LineTotal returns the sum of line net amounts.
+func (d *Document) LineTotal() decimal.Decimal {
+	var sum decimal.Decimal
+	for _, l := range d.Lines {
+		sum = sum.Add(round(l.Net))
+	}
+	return sum
+}
+
+// allowances returns the sum of allowances and charges.
+func (d *Document) allowances() (allowance, charge decimal.Decimal) {
+	for _, a := range d.Adjustments {
+		if a.Amount.IsNegative() {
+			allowance = allowance.Add(round(a.Amount.Neg()))
+		} else {
+			charge = charge.Add(round(a.Amount))
+		}
+	}
+	return
+}
+
+// TaxExclusive returns the total amount without tax.
+func (d *Document) TaxExclusive() decimal.Decimal {
+	allowance, charge := d.allowances()
+	return d.LineTotal().Sub(allowance).Add(charge)
+}
+
+// Tax returns the total tax amount.
+func (d *Document) Tax() decimal.Decimal {
+	return round(d.TaxExclusive().Mul(d.TaxPercent).Div(decimal.New(100, 0)))
+}
+
+// TaxInclusive returns the total amount with tax.
+func (d *Document) TaxInclusive() decimal.Decimal {
+	return d.TaxExclusive().Add(d.Tax())
+}
+
+func round(d decimal.Decimal) decimal.Decimal {
+	return d.RoundBank(2)
+}
+
+func amount(d decimal.Decimal) string {
+	return round(d).StringFixed(2)
+}
+
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateFmt)
+}
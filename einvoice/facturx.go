@@ -0,0 +1,586 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/unidoc/unipdf/v3/core"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+//go:generate go run gen_srgb.go
+
+// FacturXFilename is the name of the embedded invoice xml file.
+const FacturXFilename = "factur-x.xml"
+
+// WriteFacturX writes the hybrid Factur-X invoice to w.  It takes the
+// visual invoice PDF, and appends the Cross Industry Invoice xml of the
+// profile as the associated file, the XMP metadata with the Factur-X
+// identification, and the sRGB output intent, as an incremental update.  If
+// the PDF has a single revision, and its header is not followed by the
+// binary comment, the comment is added, and the update replaces the
+// original cross-reference section.
+//
+// The PDF/A-3B identification is added only if the PDF conforms to it, so
+// far as it can be checked: the file is not encrypted, the header is
+// followed by the binary comment, and all fonts are embedded.
+func (d *Document) WriteFacturX(w io.Writer, pdf []byte, profile string) error {
+	profile, err := NormaliseProfile(profile)
+	if err != nil {
+		return err
+	}
+	var xmlBuf bytes.Buffer
+	if err := d.WriteCII(&xmlBuf, profile); err != nil {
+		return err
+	}
+	u, err := newUpdate(pdf)
+	if err != nil {
+		return err
+	}
+	info := u.info()
+	if info.Modified.IsZero() {
+		info.Modified = time.Now()
+	}
+
+	if err := u.attach(FacturXFilename, "Factur-X Invoice", xmlBuf.Bytes(), info.Modified); err != nil {
+		return err
+	}
+	if u.catalog.Get("OutputIntents") == nil {
+		icc, err := u.stream(srgbICC, core.NewFlateEncoder())
+		if err != nil {
+			return err
+		}
+		icc.Set("N", core.MakeInteger(3))
+		intent := core.MakeDict()
+		intent.Set("Type", core.MakeName("OutputIntent"))
+		intent.Set("S", core.MakeName("GTS_PDFA1"))
+		intent.Set("OutputConditionIdentifier", core.MakeString("sRGB"))
+		intent.Set("Info", core.MakeString("sRGB IEC61966-2.1"))
+		intent.Set("DestOutputProfile", icc)
+		u.catalog.Set("OutputIntents", core.MakeArray(u.object(intent)))
+	}
+	metadata, err := u.stream(d.xmp(profile, info, u.pdfa()), nil)
+	if err != nil {
+		return err
+	}
+	metadata.Set("Type", core.MakeName("Metadata"))
+	metadata.Set("Subtype", core.MakeName("XML"))
+	u.catalog.Set("Metadata", metadata)
+	if v := u.parser.PdfVersion(); v.Major == 1 && v.Minor < 7 {
+		u.catalog.Set("Version", core.MakeName("1.7")) // associated files
+	}
+	return u.write(w)
+}
+
+// update is the PDF incremental update.  It is built with the unipdf object
+// model rather than model.PdfAppender, that takes the catalog from its own
+// read-only reader, and cannot add the catalog entries.
+type update struct {
+	pdf     []byte
+	parser  *core.PdfParser
+	trailer *core.PdfObjectDictionary
+	root    *core.PdfIndirectObject   // catalog object
+	catalog *core.PdfObjectDictionary // updated catalog
+	size    int64                     // next object number
+	objects []core.PdfObject          // objects of the update
+	// xrefs are the original objects, if the update writes the complete
+	// cross-reference table.
+	xrefs []xrefEntry
+}
+
+// xrefEntry is the in use cross-reference table entry.
+type xrefEntry struct {
+	num, gen int64
+	offset   int
+}
+
+// pdfComment is the binary comment, that marks the PDF as binary data.
+const pdfComment = "%\xe2\xe3\xcf\xd3\n"
+
+func newUpdate(pdf []byte) (*update, error) {
+	parser, err := core.NewParser(bytes.NewReader(pdf))
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %s", err)
+	}
+	if t := parser.GetXrefType(); t != nil && *t == core.XrefTypeObjectStream {
+		return nil, errors.New("pdf: cross-reference streams are not supported")
+	}
+	u := update{pdf: pdf, parser: parser, trailer: parser.GetTrailer()}
+	size, ok := core.GetIntVal(u.trailer.Get("Size"))
+	if !ok {
+		return nil, errors.New("pdf: trailer /Size is not found")
+	}
+	u.size = int64(size)
+	ref, ok := u.trailer.Get("Root").(*core.PdfObjectReference)
+	if !ok {
+		return nil, errors.New("pdf: trailer /Root is not found")
+	}
+	root, err := parser.LookupByReference(*ref)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %s", err)
+	}
+	if u.root, ok = root.(*core.PdfIndirectObject); !ok {
+		return nil, errors.New("pdf: catalog is not found")
+	}
+	if u.catalog, ok = core.GetDict(u.root); !ok {
+		return nil, errors.New("pdf: catalog is not a dictionary")
+	}
+	u.objects = append(u.objects, u.root)
+	if !binaryComment(pdf) {
+		u.addComment()
+	}
+	return &u, nil
+}
+
+// addComment inserts the binary comment after the header of the PDF, that
+// has a single revision.  The original cross-reference section is dropped,
+// and the update writes the complete one with the shifted offsets.
+func (u *update) addComment() {
+	if u.trailer.Get("Prev") != nil {
+		return // offsets of the previous revisions can't be shifted
+	}
+	header := bytes.IndexAny(u.pdf, "\r\n")
+	xref := int(u.parser.GetXrefOffset())
+	if header < 0 || xref <= header || xref > len(u.pdf) {
+		return
+	}
+	if bytes.HasPrefix(u.pdf[header:], []byte("\r\n")) {
+		header++
+	}
+	header++
+	for _, x := range u.parser.GetXrefTable().ObjectMap {
+		if x.XType != core.XrefTypeTableEntry {
+			return
+		}
+		u.xrefs = append(u.xrefs, xrefEntry{int64(x.ObjectNumber), int64(x.Generation), int(x.Offset) + len(pdfComment)})
+	}
+	pdf := make([]byte, 0, xref+len(pdfComment))
+	pdf = append(pdf, u.pdf[:header]...)
+	pdf = append(pdf, pdfComment...)
+	u.pdf = append(pdf, u.pdf[header:xref]...)
+}
+
+// resolve returns the object, that the reference refers to, or the object
+// itself, if it's not a reference.
+func (u *update) resolve(obj core.PdfObject) (core.PdfObject, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	obj, err := u.parser.Resolve(obj)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %s", err)
+	}
+	return obj, nil
+}
+
+// object adds the indirect object with the new object number to the update.
+func (u *update) object(obj core.PdfObject) *core.PdfIndirectObject {
+	ind := core.MakeIndirectObject(obj)
+	ind.ObjectNumber = u.size
+	u.size++
+	u.objects = append(u.objects, ind)
+	return ind
+}
+
+// stream adds the stream with the new object number to the update.  Nil
+// encoder leaves the data as is.
+func (u *update) stream(data []byte, encoder core.StreamEncoder) (*core.PdfObjectStream, error) {
+	s, err := core.MakeStream(data, encoder)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %s", err)
+	}
+	s.ObjectNumber = u.size
+	u.size++
+	u.objects = append(u.objects, s)
+	return s, nil
+}
+
+// attach adds the xml file as the associated file of the document, and to
+// the embedded files of the catalog.
+func (u *update) attach(filename, desc string, data []byte, modified time.Time) error {
+	embedded, err := u.stream(data, core.NewFlateEncoder())
+	if err != nil {
+		return err
+	}
+	modDate, err := model.NewPdfDateFromTime(modified)
+	if err != nil {
+		return err
+	}
+	params := core.MakeDict()
+	params.Set("ModDate", modDate.ToPdfObject())
+	params.Set("Size", core.MakeInteger(int64(len(data))))
+	embedded.Set("Type", core.MakeName("EmbeddedFile"))
+	embedded.Set("Subtype", core.MakeName("text/xml"))
+	embedded.Set("Params", params)
+
+	ef := core.MakeDict()
+	ef.Set("F", embedded)
+	ef.Set("UF", embedded)
+	spec := core.MakeDict()
+	spec.Set("Type", core.MakeName("Filespec"))
+	spec.Set("F", core.MakeString(filename))
+	spec.Set("UF", core.MakeString(filename))
+	spec.Set("Desc", core.MakeString(desc))
+	spec.Set("AFRelationship", core.MakeName("Data"))
+	spec.Set("EF", ef)
+	filespec := u.object(spec)
+
+	af := core.MakeArray()
+	obj, err := u.resolve(u.catalog.Get("AF"))
+	if err != nil {
+		return err
+	}
+	if arr, ok := core.GetArray(obj); ok {
+		af.Append(arr.Elements()...)
+	}
+	af.Append(filespec)
+	u.catalog.Set("AF", af)
+	return u.addEmbeddedFile(filename, filespec)
+}
+
+// addEmbeddedFile adds the file specification to the embedded files name
+// tree of the catalog, replacing the file with the same name.
+func (u *update) addEmbeddedFile(filename string, filespec core.PdfObject) error {
+	names := core.MakeDict()
+	obj, err := u.resolve(u.catalog.Get("Names"))
+	if err != nil {
+		return err
+	}
+	if dict, ok := core.GetDict(obj); ok {
+		for _, key := range dict.Keys() {
+			names.Set(key, dict.Get(key))
+		}
+	}
+	type entry struct {
+		name string
+		spec core.PdfObject
+	}
+	var files []entry
+	if obj, err = u.resolve(names.Get("EmbeddedFiles")); err != nil {
+		return err
+	}
+	if tree, ok := core.GetDict(obj); ok {
+		if tree.Get("Kids") != nil {
+			return errors.New("pdf: embedded files name tree with kids is not supported")
+		}
+		obj, err := u.resolve(tree.Get("Names"))
+		if err != nil {
+			return err
+		}
+		arr, _ := core.GetArray(obj)
+		for i := 0; arr != nil && i+1 < arr.Len(); i += 2 {
+			name, ok := core.GetStringVal(arr.Get(i))
+			if ok && name != filename {
+				files = append(files, entry{name, arr.Get(i + 1)})
+			}
+		}
+	}
+	files = append(files, entry{filename, filespec})
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	arr := core.MakeArray()
+	for _, f := range files {
+		arr.Append(core.MakeString(f.name), f.spec)
+	}
+	tree := core.MakeDict()
+	tree.Set("Names", arr)
+	names.Set("EmbeddedFiles", tree)
+	u.catalog.Set("Names", names)
+	return nil
+}
+
+// pdfInfo is the PDF document information, that the XMP metadata must
+// match.
+type pdfInfo struct {
+	Producer string
+	Created  time.Time
+	Modified time.Time
+}
+
+// info returns the document information of the PDF.
+func (u *update) info() pdfInfo {
+	var pi pdfInfo
+	obj, err := u.resolve(u.trailer.Get("Info"))
+	if err != nil {
+		return pi
+	}
+	dict, ok := core.GetDict(obj)
+	if !ok {
+		return pi
+	}
+	if s, ok := core.GetString(dict.Get("Producer")); ok {
+		pi.Producer = s.Decoded()
+	}
+	date := func(key core.PdfObjectName) time.Time {
+		s, ok := core.GetStringVal(dict.Get(key))
+		if !ok {
+			return time.Time{}
+		}
+		d, err := model.NewPdfDate(s)
+		if err != nil {
+			return time.Time{}
+		}
+		return d.ToGoTime()
+	}
+	pi.Created, pi.Modified = date("CreationDate"), date("ModDate")
+	return pi
+}
+
+// pdfa returns true if the original PDF conforms to PDF/A-3B in what the
+// update can't change: the file is not encrypted, the header is followed by
+// the binary comment, and all fonts are embedded.
+func (u *update) pdfa() bool {
+	if u.trailer.Get("Encrypt") != nil || !binaryComment(u.pdf) {
+		return false
+	}
+	for _, num := range u.parser.GetObjectNums() {
+		obj, err := u.parser.LookupByNumber(num)
+		if err != nil {
+			return false
+		}
+		dict, ok := core.GetDict(obj)
+		if !ok {
+			continue
+		}
+		typ, _ := core.GetNameVal(dict.Get("Type"))
+		switch typ {
+		case "FontDescriptor":
+			if dict.Get("FontFile") == nil && dict.Get("FontFile2") == nil && dict.Get("FontFile3") == nil {
+				return false
+			}
+		case "Font":
+			switch subtype, _ := core.GetNameVal(dict.Get("Subtype")); subtype {
+			case "Type0", "Type3":
+				// descendant fonts are checked on their own, Type 3
+				// glyphs are content streams.
+			default:
+				if dict.Get("FontDescriptor") == nil {
+					return false // standard font
+				}
+			}
+		}
+	}
+	return true
+}
+
+// binaryComment returns true if the PDF header is followed by the comment
+// with at least four binary characters.
+func binaryComment(pdf []byte) bool {
+	eol := bytes.IndexAny(pdf, "\r\n")
+	if eol < 0 {
+		return false
+	}
+	line := bytes.TrimLeft(pdf[eol:], "\r\n")
+	if end := bytes.IndexAny(line, "\r\n"); end >= 0 {
+		line = line[:end]
+	}
+	if len(line) < 5 || line[0] != '%' {
+		return false
+	}
+	var n int
+	for _, c := range line[1:] {
+		if c > 127 {
+			n++
+		}
+	}
+	return n >= 4
+}
+
+// write writes the original PDF followed by the update objects, the xref
+// section and the trailer.
+func (u *update) write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.Write(u.pdf)
+	if !bytes.HasSuffix(u.pdf, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	xrefs := make(map[int64]xrefEntry, len(u.xrefs)+len(u.objects))
+	for _, x := range u.xrefs {
+		xrefs[x.num] = x
+	}
+	for _, obj := range u.objects {
+		var (
+			ref  core.PdfObjectReference
+			body string
+			data []byte
+		)
+		switch o := obj.(type) {
+		case *core.PdfIndirectObject:
+			ref, body = o.PdfObjectReference, o.PdfObject.WriteString()
+		case *core.PdfObjectStream:
+			ref, body, data = o.PdfObjectReference, o.PdfObjectDictionary.WriteString(), o.Stream
+		}
+		xrefs[ref.ObjectNumber] = xrefEntry{ref.ObjectNumber, ref.GenerationNumber, buf.Len()}
+		fmt.Fprintf(&buf, "%d %d obj\n%s\n", ref.ObjectNumber, ref.GenerationNumber, body)
+		if data != nil {
+			buf.WriteString("stream\n")
+			buf.Write(data)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+	entries := make([]xrefEntry, 0, len(xrefs))
+	for _, x := range xrefs {
+		entries = append(entries, x)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].num < entries[j].num })
+
+	xref := buf.Len()
+	buf.WriteString("xref\n0 1\n0000000000 65535 f \n")
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].num == entries[j-1].num+1 {
+			j++
+		}
+		fmt.Fprintf(&buf, "%d %d\n", entries[i].num, j-i)
+		for _, x := range entries[i:j] {
+			fmt.Fprintf(&buf, "%010d %05d n \n", x.offset, x.gen)
+		}
+		i = j
+	}
+
+	id := md5.Sum(buf.Bytes())
+	first := core.MakeHexString(string(id[:]))
+	if arr, ok := core.GetArray(u.trailer.Get("ID")); ok && arr.Len() == 2 {
+		if s, ok := core.GetString(arr.Get(0)); ok {
+			first = s // the permanent identifier
+		}
+	}
+	trailer := core.MakeDict()
+	trailer.Set("Size", core.MakeInteger(u.size))
+	trailer.Set("Root", &u.root.PdfObjectReference)
+	trailer.SetIfNotNil("Info", u.trailer.Get("Info"))
+	if u.xrefs == nil {
+		trailer.Set("Prev", core.MakeInteger(u.parser.GetXrefOffset()))
+	}
+	trailer.Set("ID", core.MakeArray(first, core.MakeHexString(string(id[:]))))
+	fmt.Fprintf(&buf, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer.WriteString(), xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// xmp returns the XMP metadata with the Factur-X extension schema, and the
+// PDF/A-3B identification, if the PDF conforms to it.  The dates and the
+// producer are those of the PDF document information.
+func (d *Document) xmp(profile string, pi pdfInfo, pdfa bool) []byte {
+	info := d.info()
+	if !pi.Created.IsZero() {
+		info.Created = pi.Created
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xmpHeader)
+	if pdfa {
+		buf.WriteString(xmpPDFA)
+	}
+	fmt.Fprintf(&buf, xmpDC, xmlEscape(info.Title), xmlEscape(info.Author), xmlEscape(info.Subject))
+	buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\">\n")
+	fmt.Fprintf(&buf, "<xmp:ModifyDate>%[1]s</xmp:ModifyDate>\n<xmp:MetadataDate>%[1]s</xmp:MetadataDate>\n", pi.Modified.Format(time.RFC3339))
+	if !info.Created.IsZero() {
+		fmt.Fprintf(&buf, "<xmp:CreateDate>%s</xmp:CreateDate>\n", info.Created.Format(time.RFC3339))
+	}
+	if info.Creator != "" {
+		fmt.Fprintf(&buf, "<xmp:CreatorTool>%s</xmp:CreatorTool>\n", xmlEscape(info.Creator))
+	}
+	buf.WriteString("</rdf:Description>\n")
+	if info.Keywords != "" || pi.Producer != "" {
+		buf.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+		if info.Keywords != "" {
+			fmt.Fprintf(&buf, "<pdf:Keywords>%s</pdf:Keywords>\n", xmlEscape(info.Keywords))
+		}
+		if pi.Producer != "" {
+			fmt.Fprintf(&buf, "<pdf:Producer>%s</pdf:Producer>\n", xmlEscape(pi.Producer))
+		}
+		buf.WriteString("</rdf:Description>\n")
+	}
+	fmt.Fprintf(&buf, xmpFacturX, FacturXFilename, profiles[profile].level)
+	if pdfa {
+		buf.WriteString(xmpExtension)
+	}
+	buf.WriteString(xmpFooter)
+	return buf.Bytes()
+}
+
+// info returns the document information, or the default one, if not set.
+func (d *Document) info() Info {
+	if d.Info != nil {
+		return *d.Info
+	}
+	return Info{
+		Title:   fmt.Sprintf("Invoice %s", d.ID),
+		Author:  d.Seller.Name,
+		Subject: fmt.Sprintf("Invoice %s from %s to %s", d.ID, d.Seller.Name, d.Buyer.Name),
+	}
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '&':
+			buf.WriteString("&amp;")
+		case '"':
+			buf.WriteString("&quot;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// XMP metadata parts.
+const (
+	xmpHeader = `<?xpacket begin="` + "\xef\xbb\xbf" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+`
+	xmpPDFA = `<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+<pdfaid:part>3</pdfaid:part>
+<pdfaid:conformance>B</pdfaid:conformance>
+</rdf:Description>
+`
+	xmpDC = `<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:title>
+<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>
+<dc:description><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:description>
+</rdf:Description>
+`
+	xmpFacturX = `<rdf:Description rdf:about="" xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#">
+<fx:DocumentType>INVOICE</fx:DocumentType>
+<fx:DocumentFileName>%s</fx:DocumentFileName>
+<fx:Version>1.0</fx:Version>
+<fx:ConformanceLevel>%s</fx:ConformanceLevel>
+</rdf:Description>
+`
+	xmpExtension = `<rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#" xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+<pdfaExtension:schemas>
+<rdf:Bag>
+<rdf:li rdf:parseType="Resource">
+<pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+<pdfaSchema:namespaceURI>urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#</pdfaSchema:namespaceURI>
+<pdfaSchema:prefix>fx</pdfaSchema:prefix>
+<pdfaSchema:property>
+<rdf:Seq>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>DocumentFileName</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>name of the embedded XML invoice file</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>DocumentType</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>INVOICE</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>Version</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The actual version of the Factur-X XML schema</pdfaProperty:description></rdf:li>
+<rdf:li rdf:parseType="Resource"><pdfaProperty:name>ConformanceLevel</pdfaProperty:name><pdfaProperty:valueType>Text</pdfaProperty:valueType><pdfaProperty:category>external</pdfaProperty:category><pdfaProperty:description>The conformance level of the embedded Factur-X data</pdfaProperty:description></rdf:li>
+</rdf:Seq>
+</pdfaSchema:property>
+</rdf:li>
+</rdf:Bag>
+</pdfaExtension:schemas>
+</rdf:Description>
+`
+	xmpFooter = `</rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+)
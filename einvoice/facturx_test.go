@@ -0,0 +1,340 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/unidoc/unipdf/v3/core"
+)
+
+func TestDocument_WriteCII(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		want    []string
+		notWant []string
+	}{
+		{"minimum", "minimum",
+			[]string{`<ram:ID>urn:factur-x.eu:1p0:minimum</ram:ID>`, `<ram:DuePayableAmount>952.00</ram:DuePayableAmount>`},
+			[]string{`<ram:IncludedSupplyChainTradeLineItem>`},
+		},
+		{"basic", "BASIC",
+			[]string{`urn:factur-x.eu:1p0:basic`, `<ram:IncludedSupplyChainTradeLineItem>`, `<ram:BilledQuantity unitCode="HUR">10</ram:BilledQuantity>`},
+			nil,
+		},
+		{"en16931", "EN 16931",
+			[]string{`<ram:ID>urn:cen.eu:en16931:2017</ram:ID>`, `<ram:IBANID>DE89370400440532013000</ram:IBANID>`},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testDocument().WriteCII(&buf, tt.profile); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("WriteCII() output is missing %s", want)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("WriteCII() output unexpectedly contains %s", s)
+				}
+			}
+		})
+	}
+	if err := testDocument().WriteCII(&bytes.Buffer{}, "extended"); err == nil {
+		t.Error("WriteCII() expected error for unknown profile")
+	}
+}
+
+func TestDocument_WriteFacturX(t *testing.T) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.Cell(40, 10, "Invoice 42")
+	var src bytes.Buffer
+	if err := pdf.Output(&src); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := testDocument().WriteFacturX(&buf, src.Bytes(), ProfileBasic); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	if !binaryComment(out) {
+		t.Error("WriteFacturX() must add the binary comment")
+	}
+	parser, err := core.NewParser(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("WriteFacturX() output is not a PDF: %v", err)
+	}
+	resolve := func(obj core.PdfObject) core.PdfObject {
+		obj, err := parser.Resolve(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+	catalog, ok := core.GetDict(resolve(parser.GetTrailer().Get("Root")))
+	if !ok {
+		t.Fatal("WriteFacturX() catalog is not found")
+	}
+	if catalog.Get("Pages") == nil {
+		t.Error("WriteFacturX() catalog lost the page tree")
+	}
+	if catalog.Get("OutputIntents") == nil {
+		t.Error("WriteFacturX() catalog has no output intent")
+	}
+	af, _ := core.GetArray(resolve(catalog.Get("AF")))
+	if af == nil || af.Len() != 1 {
+		t.Fatalf("WriteFacturX() catalog AF = %v, want the invoice xml", catalog.Get("AF"))
+	}
+	spec, _ := core.GetDict(resolve(af.Get(0)))
+	if rel, _ := core.GetNameVal(spec.Get("AFRelationship")); rel != "Data" {
+		t.Errorf("AFRelationship = %q, want Data", rel)
+	}
+	names, _ := core.GetDict(resolve(catalog.Get("Names")))
+	files, _ := core.GetDict(resolve(names.Get("EmbeddedFiles")))
+	arr, _ := core.GetArray(resolve(files.Get("Names")))
+	if name, _ := core.GetStringVal(arr.Get(0)); name != FacturXFilename {
+		t.Errorf("embedded file name = %q, want %q", name, FacturXFilename)
+	}
+	ef, _ := core.GetDict(resolve(spec.Get("EF")))
+	stream, ok := core.GetStream(resolve(ef.Get("F")))
+	if !ok {
+		t.Fatal("WriteFacturX() embedded file is not a stream")
+	}
+	if subtype, _ := core.GetNameVal(stream.Get("Subtype")); subtype != "text/xml" {
+		t.Errorf("embedded file subtype = %q, want text/xml", subtype)
+	}
+	xml, err := core.DecodeStream(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(xml, []byte("urn:factur-x.eu:1p0:basic")) {
+		t.Error("WriteFacturX() embedded file is not the invoice xml")
+	}
+	metadata, ok := core.GetStream(resolve(catalog.Get("Metadata")))
+	if !ok {
+		t.Fatal("WriteFacturX() metadata is not found")
+	}
+	for _, want := range []string{"<fx:ConformanceLevel>BASIC</fx:ConformanceLevel>", "<pdf:Producer>FPDF 1.7</pdf:Producer>"} {
+		if !bytes.Contains(metadata.Stream, []byte(want)) {
+			t.Errorf("WriteFacturX() metadata is missing %s", want)
+		}
+	}
+	// the standard font is not embedded, the output is not PDF/A.
+	if bytes.Contains(metadata.Stream, []byte("pdfaid")) {
+		t.Error("WriteFacturX() metadata must not claim PDF/A conformance")
+	}
+}
+
+// minimalPDF returns the single page PDF with the font object and the
+// optional binary comment.
+func minimalPDF(font string, comment bool) []byte {
+	objs := []string{
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources <</Font <</F1 4 0 R>>>> /Contents 5 0 R>>",
+		font,
+		"<</Length 35>>\nstream\nBT /F1 12 Tf 72 720 Td (Hi) Tj ET\nendstream",
+		"<</Type /FontDescriptor /FontName /Go /Flags 32 /FontBBox [0 0 1000 1000] /ItalicAngle 0 /Ascent 800 /Descent -200 /CapHeight 700 /StemV 80 /FontFile2 7 0 R>>",
+		"<</Length 4>>\nstream\n\x00\x01\x00\x00\nendstream",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	if comment {
+		buf.WriteString("%\xe2\xe3\xcf\xd3\n")
+	}
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<</Size %d /Root 1 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+// revised appends the incremental update, that refers to the same catalog,
+// to the pdf.
+func revised(pdf []byte) []byte {
+	prev := bytes.LastIndex(pdf, []byte("xref\n"))
+	catalog := bytes.Index(pdf, []byte("1 0 obj"))
+	return append(pdf, fmt.Sprintf("xref\n0 2\n0000000000 65535 f \n%010d 00000 n \ntrailer\n<</Size 8 /Root 1 0 R /Prev %d>>\nstartxref\n%d\n%%%%EOF\n", catalog, prev, len(pdf))...)
+}
+
+func Test_update_pdfa(t *testing.T) {
+	const (
+		embedded = "<</Type /Font /Subtype /TrueType /BaseFont /Go /FirstChar 32 /LastChar 32 /Widths [500] /FontDescriptor 6 0 R>>"
+		standard = "<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>"
+	)
+	tests := []struct {
+		name string
+		pdf  []byte
+		want bool
+	}{
+		{"embedded font", minimalPDF(embedded, true), true},
+		{"standard font", minimalPDF(standard, true), false},
+		{"binary comment is added", minimalPDF(embedded, false), true},
+		{"updated, no binary comment", revised(minimalPDF(embedded, false)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := newUpdate(tt.pdf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := u.pdfa(); got != tt.want {
+				t.Errorf("update.pdfa() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocument_WriteFacturX_update(t *testing.T) {
+	const font = "<</Type /Font /Subtype /TrueType /BaseFont /Go /FirstChar 32 /LastChar 32 /Widths [500] /FontDescriptor 6 0 R>>"
+	tests := []struct {
+		name        string
+		comment     bool
+		incremental bool
+	}{
+		{"binary comment", true, true},
+		{"no binary comment", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := minimalPDF(font, tt.comment)
+			var buf bytes.Buffer
+			if err := testDocument().WriteFacturX(&buf, src, ProfileBasic); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.Bytes()
+			if got := bytes.HasPrefix(out, src); got != tt.incremental {
+				t.Errorf("output starts with the original pdf = %v, want %v", got, tt.incremental)
+			}
+			u, err := newUpdate(out)
+			if err != nil {
+				t.Fatalf("WriteFacturX() output is not a PDF: %v", err)
+			}
+			if got := u.trailer.Get("Prev") != nil; got != tt.incremental {
+				t.Errorf("trailer /Prev = %v, want %v", got, tt.incremental)
+			}
+			if !u.pdfa() {
+				t.Error("WriteFacturX() output does not conform to PDF/A")
+			}
+			if !bytes.Contains(out, []byte("<pdfaid:part>3</pdfaid:part>")) {
+				t.Error("WriteFacturX() output has no PDF/A identification")
+			}
+		})
+	}
+}
+
+func Test_binaryComment(t *testing.T) {
+	tests := []struct {
+		name string
+		pdf  string
+		want bool
+	}{
+		{"binary comment", "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj", true},
+		{"crlf", "%PDF-1.7\r\n%\xe2\xe3\xcf\xd3\r\n", true},
+		{"no comment", "%PDF-1.3\n3 0 obj", false},
+		{"text comment", "%PDF-1.3\n%abcd\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := binaryComment([]byte(tt.pdf)); got != tt.want {
+				t.Errorf("binaryComment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_srgbICC(t *testing.T) {
+	if size := binary.BigEndian.Uint32(srgbICC); int(size) != len(srgbICC) {
+		t.Errorf("profile size = %d, want %d", size, len(srgbICC))
+	}
+	if magic := string(srgbICC[36:40]); magic != "acsp" {
+		t.Errorf("profile signature = %q, want acsp", magic)
+	}
+	n := int(binary.BigEndian.Uint32(srgbICC[128:]))
+	for i := 0; i < n; i++ {
+		entry := srgbICC[132+12*i:]
+		off, size := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if int(off+size) > len(srgbICC) {
+			t.Errorf("tag %s is out of the profile", entry[:4])
+		}
+	}
+}
+
+func TestDocument_xmp(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    *Info
+		pdfa    bool
+		want    []string
+		notWant []string
+	}{
+		{"default",
+			nil,
+			false,
+			[]string{
+				`<rdf:li xml:lang="x-default">Invoice 42</rdf:li>`,
+				`<rdf:li>Seller</rdf:li>`,
+			},
+			[]string{"<xmp:CreateDate>", "<pdf:Keywords>", "<xmp:CreatorTool>", "pdfaid", "pdfaExtension"},
+		},
+		{"document information",
+			&Info{
+				Title:    "RECHNUNG 42",
+				Author:   "ACME Consulting",
+				Subject:  "RECHNUNG 42, Client & Co",
+				Keywords: "42, Client & Co",
+				Creator:  "sheet2inv",
+				Created:  time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+			},
+			true,
+			[]string{
+				"<pdfaid:part>3</pdfaid:part>",
+				"<pdfaSchema:prefix>fx</pdfaSchema:prefix>",
+				`<rdf:li xml:lang="x-default">RECHNUNG 42</rdf:li>`,
+				`<rdf:li xml:lang="x-default">RECHNUNG 42, Client &amp; Co</rdf:li>`,
+				"<xmp:CreateDate>2020-04-01T00:00:00Z</xmp:CreateDate>",
+				"<xmp:CreatorTool>sheet2inv</xmp:CreatorTool>",
+				"<pdf:Keywords>42, Client &amp; Co</pdf:Keywords>",
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDocument()
+			d.Info = tt.info
+			got := string(d.xmp(ProfileBasic, pdfInfo{Modified: time.Now()}, tt.pdfa))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("xmp() is missing %s", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("xmp() must not contain %s", notWant)
+				}
+			}
+		})
+	}
+}
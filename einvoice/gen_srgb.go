@@ -0,0 +1,141 @@
+//go:build ignore
+// +build ignore
+
+// This program generates srgb.go, the sRGB IEC 61966-2.1 ICC profile for the
+// PDF/A output intent.  Run it with "go generate".
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+)
+
+// trcSize is the number of the tone reproduction curve samples.
+const trcSize = 1024
+
+func main() {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_srgb.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package einvoice\n\n")
+	buf.WriteString("// srgbICC is the ICC v2 display profile of the sRGB IEC 61966-2.1 colour\n")
+	buf.WriteString("// space, with the D50 adapted primaries and the sampled sRGB tone curve.\n")
+	buf.WriteString("var srgbICC = []byte(\"")
+	for i, b := range profile() {
+		if i > 0 && i%32 == 0 {
+			buf.WriteString("\" +\n\t\"")
+		}
+		fmt.Fprintf(&buf, "\\x%02x", b)
+	}
+	buf.WriteString("\")\n")
+	if err := ioutil.WriteFile("srgb.go", buf.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type tag struct {
+	sig  string
+	data []byte
+}
+
+// profile returns the ICC profile data.
+func profile() []byte {
+	trc := curve()
+	tags := []tag{
+		{"desc", desc("sRGB IEC61966-2.1")},
+		{"cprt", text("No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"bkpt", xyz(0, 0, 0)},
+		{"rXYZ", xyz(0.4360747, 0.2225045, 0.0139322)},
+		{"gXYZ", xyz(0.3850649, 0.7168786, 0.0971045)},
+		{"bXYZ", xyz(0.1430804, 0.0606169, 0.7141733)},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	const hdrSz = 128
+	table := u32(uint32(len(tags)))
+	offset := hdrSz + 4 + 12*len(tags)
+	var data []byte
+	offsets := make(map[string]int) // shared tag data is stored once
+	for _, t := range tags {
+		for len(t.data)%4 != 0 {
+			t.data = append(t.data, 0)
+		}
+		off, ok := offsets[string(t.data)]
+		if !ok {
+			off = offset + len(data)
+			offsets[string(t.data)] = off
+			data = append(data, t.data...)
+		}
+		table = append(table, t.sig...)
+		table = append(table, u32(uint32(off))...)
+		table = append(table, u32(uint32(len(t.data)))...)
+	}
+
+	hdr := make([]byte, hdrSz)
+	binary.BigEndian.PutUint32(hdr[0:], uint32(hdrSz+len(table)+len(data)))
+	binary.BigEndian.PutUint32(hdr[8:], 0x02100000) // version 2.1
+	copy(hdr[12:], "mntr")
+	copy(hdr[16:], "RGB ")
+	copy(hdr[20:], "XYZ ")
+	for i, v := range []uint16{2020, 1, 1, 0, 0, 0} { // creation date and time
+		binary.BigEndian.PutUint16(hdr[24+2*i:], v)
+	}
+	copy(hdr[36:], "acsp")
+	copy(hdr[68:], xyz(0.9642, 1.0, 0.8249)[8:]) // D50 illuminant
+
+	out := append(hdr, table...)
+	return append(out, data...)
+}
+
+// curve returns the sRGB tone reproduction curve.
+func curve() []byte {
+	b := []byte("curv\x00\x00\x00\x00")
+	b = append(b, u32(trcSize)...)
+	for i := 0; i < trcSize; i++ {
+		v := float64(i) / (trcSize - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		b = append(b, byte(0), byte(0))
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(math.Round(v*0xffff)))
+	}
+	return b
+}
+
+func xyz(x, y, z float64) []byte {
+	b := []byte("XYZ \x00\x00\x00\x00")
+	for _, v := range []float64{x, y, z} {
+		b = append(b, u32(uint32(int32(math.Round(v*65536))))...)
+	}
+	return b
+}
+
+// desc returns the ICC v2 textDescriptionType with the ASCII description
+// only.
+func desc(s string) []byte {
+	b := []byte("desc\x00\x00\x00\x00")
+	b = append(b, u32(uint32(len(s)+1))...)
+	b = append(b, s...)
+	b = append(b, 0)
+	return append(b, make([]byte, 4+4+2+1+67)...)
+}
+
+func text(s string) []byte {
+	b := []byte("text\x00\x00\x00\x00")
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
@@ -0,0 +1,86 @@
+// Code generated by gen_srgb.go; DO NOT EDIT.
+
+package einvoice
+
+// srgbICC is the ICC v2 display profile of the sRGB IEC 61966-2.1 colour
+// space, with the D50 adapted primaries and the sampled sRGB tone curve.
+var srgbICC = []byte("\x00\x00\x09\xfc\x00\x00\x00\x00\x02\x10\x00\x00\x6d\x6e\x74\x72\x52\x47\x42\x20\x58\x59\x5a\x20\x07\xe4\x00\x01\x00\x01\x00\x00" +
+	"\x00\x00\x00\x00\x61\x63\x73\x70\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\xf6\xd6\x00\x01\x00\x00\x00\x00\xd3\x2d\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x0a\x64\x65\x73\x63\x00\x00\x00\xfc\x00\x00\x00\x6c\x63\x70\x72\x74\x00\x00\x01\x68\x00\x00\x00\x24\x77\x74\x70\x74" +
+	"\x00\x00\x01\x8c\x00\x00\x00\x14\x62\x6b\x70\x74\x00\x00\x01\xa0\x00\x00\x00\x14\x72\x58\x59\x5a\x00\x00\x01\xb4\x00\x00\x00\x14" +
+	"\x67\x58\x59\x5a\x00\x00\x01\xc8\x00\x00\x00\x14\x62\x58\x59\x5a\x00\x00\x01\xdc\x00\x00\x00\x14\x72\x54\x52\x43\x00\x00\x01\xf0" +
+	"\x00\x00\x08\x0c\x67\x54\x52\x43\x00\x00\x01\xf0\x00\x00\x08\x0c\x62\x54\x52\x43\x00\x00\x01\xf0\x00\x00\x08\x0c\x64\x65\x73\x63" +
+	"\x00\x00\x00\x00\x00\x00\x00\x12\x73\x52\x47\x42\x20\x49\x45\x43\x36\x31\x39\x36\x36\x2d\x32\x2e\x31\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\x00\x00\x74\x65\x78\x74\x00\x00\x00\x00\x4e\x6f\x20\x63\x6f\x70\x79\x72\x69\x67\x68\x74\x2c\x20\x75\x73" +
+	"\x65\x20\x66\x72\x65\x65\x6c\x79\x00\x00\x00\x00\x58\x59\x5a\x20\x00\x00\x00\x00\x00\x00\xf6\xd6\x00\x01\x00\x00\x00\x00\xd3\x2d" +
+	"\x58\x59\x5a\x20\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x58\x59\x5a\x20\x00\x00\x00\x00\x00\x00\x6f\xa3" +
+	"\x00\x00\x38\xf6\x00\x00\x03\x91\x58\x59\x5a\x20\x00\x00\x00\x00\x00\x00\x62\x94\x00\x00\xb7\x85\x00\x00\x18\xdc\x58\x59\x5a\x20" +
+	"\x00\x00\x00\x00\x00\x00\x24\xa1\x00\x00\x0f\x85\x00\x00\xb6\xd4\x63\x75\x72\x76\x00\x00\x00\x00\x00\x00\x04\x00\x00\x00\x00\x05" +
+	"\x00\x0a\x00\x0f\x00\x14\x00\x19\x00\x1e\x00\x23\x00\x28\x00\x2d\x00\x32\x00\x37\x00\x3b\x00\x40\x00\x45\x00\x4a\x00\x4f\x00\x54" +
+	"\x00\x59\x00\x5e\x00\x63\x00\x68\x00\x6d\x00\x72\x00\x77\x00\x7c\x00\x81\x00\x86\x00\x8b\x00\x90\x00\x95\x00\x9a\x00\x9f\x00\xa4" +
+	"\x00\xa9\x00\xae\x00\xb2\x00\xb7\x00\xbc\x00\xc1\x00\xc6\x00\xcb\x00\xd0\x00\xd5\x00\xdb\x00\xe0\x00\xe5\x00\xeb\x00\xf0\x00\xf6" +
+	"\x00\xfb\x01\x01\x01\x07\x01\x0d\x01\x13\x01\x19\x01\x1f\x01\x25\x01\x2b\x01\x32\x01\x38\x01\x3e\x01\x45\x01\x4c\x01\x52\x01\x59" +
+	"\x01\x60\x01\x67\x01\x6e\x01\x75\x01\x7c\x01\x83\x01\x8b\x01\x92\x01\x9a\x01\xa1\x01\xa9\x01\xb1\x01\xb9\x01\xc1\x01\xc9\x01\xd1" +
+	"\x01\xd9\x01\xe1\x01\xe9\x01\xf2\x01\xfa\x02\x03\x02\x0c\x02\x14\x02\x1d\x02\x26\x02\x2f\x02\x38\x02\x41\x02\x4b\x02\x54\x02\x5d" +
+	"\x02\x67\x02\x71\x02\x7a\x02\x84\x02\x8e\x02\x98\x02\xa2\x02\xac\x02\xb6\x02\xc1\x02\xcb\x02\xd5\x02\xe0\x02\xeb\x02\xf5\x03\x00" +
+	"\x03\x0b\x03\x16\x03\x21\x03\x2d\x03\x38\x03\x43\x03\x4f\x03\x5a\x03\x66\x03\x72\x03\x7e\x03\x8a\x03\x96\x03\xa2\x03\xae\x03\xba" +
+	"\x03\xc7\x03\xd3\x03\xe0\x03\xec\x03\xf9\x04\x06\x04\x13\x04\x20\x04\x2d\x04\x3b\x04\x48\x04\x55\x04\x63\x04\x71\x04\x7e\x04\x8c" +
+	"\x04\x9a\x04\xa8\x04\xb6\x04\xc4\x04\xd3\x04\xe1\x04\xf0\x04\xfe\x05\x0d\x05\x1c\x05\x2b\x05\x3a\x05\x49\x05\x58\x05\x67\x05\x77" +
+	"\x05\x86\x05\x96\x05\xa6\x05\xb5\x05\xc5\x05\xd5\x05\xe5\x05\xf6\x06\x06\x06\x16\x06\x27\x06\x37\x06\x48\x06\x59\x06\x6a\x06\x7b" +
+	"\x06\x8c\x06\x9d\x06\xaf\x06\xc0\x06\xd1\x06\xe3\x06\xf5\x07\x07\x07\x19\x07\x2b\x07\x3d\x07\x4f\x07\x61\x07\x74\x07\x86\x07\x99" +
+	"\x07\xac\x07\xbf\x07\xd2\x07\xe5\x07\xf8\x08\x0b\x08\x1f\x08\x32\x08\x46\x08\x5a\x08\x6e\x08\x82\x08\x96\x08\xaa\x08\xbe\x08\xd2" +
+	"\x08\xe7\x08\xfb\x09\x10\x09\x25\x09\x3a\x09\x4f\x09\x64\x09\x79\x09\x8f\x09\xa4\x09\xba\x09\xcf\x09\xe5\x09\xfb\x0a\x11\x0a\x27" +
+	"\x0a\x3d\x0a\x54\x0a\x6a\x0a\x81\x0a\x98\x0a\xae\x0a\xc5\x0a\xdc\x0a\xf3\x0b\x0b\x0b\x22\x0b\x39\x0b\x51\x0b\x69\x0b\x80\x0b\x98" +
+	"\x0b\xb0\x0b\xc8\x0b\xe1\x0b\xf9\x0c\x12\x0c\x2a\x0c\x43\x0c\x5c\x0c\x75\x0c\x8e\x0c\xa7\x0c\xc0\x0c\xd9\x0c\xf3\x0d\x0d\x0d\x26" +
+	"\x0d\x40\x0d\x5a\x0d\x74\x0d\x8e\x0d\xa9\x0d\xc3\x0d\xde\x0d\xf8\x0e\x13\x0e\x2e\x0e\x49\x0e\x64\x0e\x7f\x0e\x9b\x0e\xb6\x0e\xd2" +
+	"\x0e\xee\x0f\x09\x0f\x25\x0f\x41\x0f\x5e\x0f\x7a\x0f\x96\x0f\xb3\x0f\xcf\x0f\xec\x10\x09\x10\x26\x10\x43\x10\x61\x10\x7e\x10\x9b" +
+	"\x10\xb9\x10\xd7\x10\xf5\x11\x13\x11\x31\x11\x4f\x11\x6d\x11\x8c\x11\xaa\x11\xc9\x11\xe8\x12\x07\x12\x26\x12\x45\x12\x64\x12\x84" +
+	"\x12\xa3\x12\xc3\x12\xe3\x13\x03\x13\x23\x13\x43\x13\x63\x13\x83\x13\xa4\x13\xc5\x13\xe5\x14\x06\x14\x27\x14\x49\x14\x6a\x14\x8b" +
+	"\x14\xad\x14\xce\x14\xf0\x15\x12\x15\x34\x15\x56\x15\x78\x15\x9b\x15\xbd\x15\xe0\x16\x03\x16\x26\x16\x49\x16\x6c\x16\x8f\x16\xb2" +
+	"\x16\xd6\x16\xfa\x17\x1d\x17\x41\x17\x65\x17\x89\x17\xae\x17\xd2\x17\xf7\x18\x1b\x18\x40\x18\x65\x18\x8a\x18\xaf\x18\xd5\x18\xfa" +
+	"\x19\x20\x19\x45\x19\x6b\x19\x91\x19\xb7\x19\xdd\x1a\x04\x1a\x2a\x1a\x51\x1a\x77\x1a\x9e\x1a\xc5\x1a\xec\x1b\x14\x1b\x3b\x1b\x63" +
+	"\x1b\x8a\x1b\xb2\x1b\xda\x1c\x02\x1c\x2a\x1c\x52\x1c\x7b\x1c\xa3\x1c\xcc\x1c\xf5\x1d\x1e\x1d\x47\x1d\x70\x1d\x99\x1d\xc3\x1d\xec" +
+	"\x1e\x16\x1e\x40\x1e\x6a\x1e\x94\x1e\xbe\x1e\xe9\x1f\x13\x1f\x3e\x1f\x69\x1f\x94\x1f\xbf\x1f\xea\x20\x15\x20\x41\x20\x6c\x20\x98" +
+	"\x20\xc4\x20\xf0\x21\x1c\x21\x48\x21\x75\x21\xa1\x21\xce\x21\xfb\x22\x27\x22\x55\x22\x82\x22\xaf\x22\xdd\x23\x0a\x23\x38\x23\x66" +
+	"\x23\x94\x23\xc2\x23\xf0\x24\x1f\x24\x4d\x24\x7c\x24\xab\x24\xda\x25\x09\x25\x38\x25\x68\x25\x97\x25\xc7\x25\xf7\x26\x27\x26\x57" +
+	"\x26\x87\x26\xb7\x26\xe8\x27\x18\x27\x49\x27\x7a\x27\xab\x27\xdc\x28\x0d\x28\x3f\x28\x71\x28\xa2\x28\xd4\x29\x06\x29\x38\x29\x6b" +
+	"\x29\x9d\x29\xd0\x2a\x02\x2a\x35\x2a\x68\x2a\x9b\x2a\xcf\x2b\x02\x2b\x36\x2b\x69\x2b\x9d\x2b\xd1\x2c\x05\x2c\x39\x2c\x6e\x2c\xa2" +
+	"\x2c\xd7\x2d\x0c\x2d\x41\x2d\x76\x2d\xab\x2d\xe1\x2e\x16\x2e\x4c\x2e\x82\x2e\xb7\x2e\xee\x2f\x24\x2f\x5a\x2f\x91\x2f\xc7\x2f\xfe" +
+	"\x30\x35\x30\x6c\x30\xa4\x30\xdb\x31\x12\x31\x4a\x31\x82\x31\xba\x31\xf2\x32\x2a\x32\x63\x32\x9b\x32\xd4\x33\x0d\x33\x46\x33\x7f" +
+	"\x33\xb8\x33\xf1\x34\x2b\x34\x65\x34\x9e\x34\xd8\x35\x13\x35\x4d\x35\x87\x35\xc2\x35\xfd\x36\x37\x36\x72\x36\xae\x36\xe9\x37\x24" +
+	"\x37\x60\x37\x9c\x37\xd7\x38\x14\x38\x50\x38\x8c\x38\xc8\x39\x05\x39\x42\x39\x7f\x39\xbc\x39\xf9\x3a\x36\x3a\x74\x3a\xb2\x3a\xef" +
+	"\x3b\x2d\x3b\x6b\x3b\xaa\x3b\xe8\x3c\x27\x3c\x65\x3c\xa4\x3c\xe3\x3d\x22\x3d\x61\x3d\xa1\x3d\xe0\x3e\x20\x3e\x60\x3e\xa0\x3e\xe0" +
+	"\x3f\x21\x3f\x61\x3f\xa2\x3f\xe2\x40\x23\x40\x64\x40\xa6\x40\xe7\x41\x29\x41\x6a\x41\xac\x41\xee\x42\x30\x42\x72\x42\xb5\x42\xf7" +
+	"\x43\x3a\x43\x7d\x43\xc0\x44\x03\x44\x47\x44\x8a\x44\xce\x45\x12\x45\x55\x45\x9a\x45\xde\x46\x22\x46\x67\x46\xab\x46\xf0\x47\x35" +
+	"\x47\x7b\x47\xc0\x48\x05\x48\x4b\x48\x91\x48\xd7\x49\x1d\x49\x63\x49\xa9\x49\xf0\x4a\x37\x4a\x7d\x4a\xc4\x4b\x0c\x4b\x53\x4b\x9a" +
+	"\x4b\xe2\x4c\x2a\x4c\x72\x4c\xba\x4d\x02\x4d\x4a\x4d\x93\x4d\xdc\x4e\x25\x4e\x6e\x4e\xb7\x4f\x00\x4f\x49\x4f\x93\x4f\xdd\x50\x27" +
+	"\x50\x71\x50\xbb\x51\x06\x51\x50\x51\x9b\x51\xe6\x52\x31\x52\x7c\x52\xc7\x53\x13\x53\x5f\x53\xaa\x53\xf6\x54\x42\x54\x8f\x54\xdb" +
+	"\x55\x28\x55\x75\x55\xc2\x56\x0f\x56\x5c\x56\xa9\x56\xf7\x57\x44\x57\x92\x57\xe0\x58\x2f\x58\x7d\x58\xcb\x59\x1a\x59\x69\x59\xb8" +
+	"\x5a\x07\x5a\x56\x5a\xa6\x5a\xf5\x5b\x45\x5b\x95\x5b\xe5\x5c\x35\x5c\x86\x5c\xd6\x5d\x27\x5d\x78\x5d\xc9\x5e\x1a\x5e\x6c\x5e\xbd" +
+	"\x5f\x0f\x5f\x61\x5f\xb3\x60\x05\x60\x57\x60\xaa\x60\xfc\x61\x4f\x61\xa2\x61\xf5\x62\x49\x62\x9c\x62\xf0\x63\x43\x63\x97\x63\xeb" +
+	"\x64\x40\x64\x94\x64\xe9\x65\x3d\x65\x92\x65\xe7\x66\x3d\x66\x92\x66\xe8\x67\x3d\x67\x93\x67\xe9\x68\x3f\x68\x96\x68\xec\x69\x43" +
+	"\x69\x9a\x69\xf1\x6a\x48\x6a\x9f\x6a\xf7\x6b\x4f\x6b\xa7\x6b\xff\x6c\x57\x6c\xaf\x6d\x08\x6d\x60\x6d\xb9\x6e\x12\x6e\x6b\x6e\xc4" +
+	"\x6f\x1e\x6f\x78\x6f\xd1\x70\x2b\x70\x86\x70\xe0\x71\x3a\x71\x95\x71\xf0\x72\x4b\x72\xa6\x73\x01\x73\x5d\x73\xb8\x74\x14\x74\x70" +
+	"\x74\xcc\x75\x28\x75\x85\x75\xe1\x76\x3e\x76\x9b\x76\xf8\x77\x56\x77\xb3\x78\x11\x78\x6e\x78\xcc\x79\x2a\x79\x89\x79\xe7\x7a\x46" +
+	"\x7a\xa5\x7b\x04\x7b\x63\x7b\xc2\x7c\x21\x7c\x81\x7c\xe1\x7d\x41\x7d\xa1\x7e\x01\x7e\x62\x7e\xc2\x7f\x23\x7f\x84\x7f\xe5\x80\x47" +
+	"\x80\xa8\x81\x0a\x81\x6b\x81\xcd\x82\x30\x82\x92\x82\xf4\x83\x57\x83\xba\x84\x1d\x84\x80\x84\xe3\x85\x47\x85\xab\x86\x0e\x86\x72" +
+	"\x86\xd7\x87\x3b\x87\x9f\x88\x04\x88\x69\x88\xce\x89\x33\x89\x99\x89\xfe\x8a\x64\x8a\xca\x8b\x30\x8b\x96\x8b\xfc\x8c\x63\x8c\xca" +
+	"\x8d\x31\x8d\x98\x8d\xff\x8e\x66\x8e\xce\x8f\x36\x8f\x9e\x90\x06\x90\x6e\x90\xd6\x91\x3f\x91\xa8\x92\x11\x92\x7a\x92\xe3\x93\x4d" +
+	"\x93\xb6\x94\x20\x94\x8a\x94\xf4\x95\x5f\x95\xc9\x96\x34\x96\x9f\x97\x0a\x97\x75\x97\xe0\x98\x4c\x98\xb8\x99\x24\x99\x90\x99\xfc" +
+	"\x9a\x68\x9a\xd5\x9b\x42\x9b\xaf\x9c\x1c\x9c\x89\x9c\xf7\x9d\x64\x9d\xd2\x9e\x40\x9e\xae\x9f\x1d\x9f\x8b\x9f\xfa\xa0\x69\xa0\xd8" +
+	"\xa1\x47\xa1\xb6\xa2\x26\xa2\x96\xa3\x06\xa3\x76\xa3\xe6\xa4\x56\xa4\xc7\xa5\x38\xa5\xa9\xa6\x1a\xa6\x8b\xa6\xfd\xa7\x6e\xa7\xe0" +
+	"\xa8\x52\xa8\xc4\xa9\x37\xa9\xa9\xaa\x1c\xaa\x8f\xab\x02\xab\x75\xab\xe9\xac\x5c\xac\xd0\xad\x44\xad\xb8\xae\x2d\xae\xa1\xaf\x16" +
+	"\xaf\x8b\xb0\x00\xb0\x75\xb0\xea\xb1\x60\xb1\xd6\xb2\x4b\xb2\xc2\xb3\x38\xb3\xae\xb4\x25\xb4\x9c\xb5\x13\xb5\x8a\xb6\x01\xb6\x79" +
+	"\xb6\xf0\xb7\x68\xb7\xe0\xb8\x59\xb8\xd1\xb9\x4a\xb9\xc2\xba\x3b\xba\xb5\xbb\x2e\xbb\xa7\xbc\x21\xbc\x9b\xbd\x15\xbd\x8f\xbe\x0a" +
+	"\xbe\x84\xbe\xff\xbf\x7a\xbf\xf5\xc0\x70\xc0\xec\xc1\x67\xc1\xe3\xc2\x5f\xc2\xdb\xc3\x58\xc3\xd4\xc4\x51\xc4\xce\xc5\x4b\xc5\xc8" +
+	"\xc6\x46\xc6\xc3\xc7\x41\xc7\xbf\xc8\x3d\xc8\xbc\xc9\x3a\xc9\xb9\xca\x38\xca\xb7\xcb\x36\xcb\xb6\xcc\x35\xcc\xb5\xcd\x35\xcd\xb5" +
+	"\xce\x36\xce\xb6\xcf\x37\xcf\xb8\xd0\x39\xd0\xba\xd1\x3c\xd1\xbe\xd2\x3f\xd2\xc1\xd3\x44\xd3\xc6\xd4\x49\xd4\xcb\xd5\x4e\xd5\xd1" +
+	"\xd6\x55\xd6\xd8\xd7\x5c\xd7\xe0\xd8\x64\xd8\xe8\xd9\x6c\xd9\xf1\xda\x76\xda\xfb\xdb\x80\xdc\x05\xdc\x8a\xdd\x10\xdd\x96\xde\x1c" +
+	"\xde\xa2\xdf\x29\xdf\xaf\xe0\x36\xe0\xbd\xe1\x44\xe1\xcc\xe2\x53\xe2\xdb\xe3\x63\xe3\xeb\xe4\x73\xe4\xfc\xe5\x84\xe6\x0d\xe6\x96" +
+	"\xe7\x1f\xe7\xa9\xe8\x32\xe8\xbc\xe9\x46\xe9\xd0\xea\x5b\xea\xe5\xeb\x70\xeb\xfb\xec\x86\xed\x11\xed\x9c\xee\x28\xee\xb4\xef\x40" +
+	"\xef\xcc\xf0\x58\xf0\xe5\xf1\x72\xf1\xff\xf2\x8c\xf3\x19\xf3\xa7\xf4\x34\xf4\xc2\xf5\x50\xf5\xde\xf6\x6d\xf6\xfb\xf7\x8a\xf8\x19" +
+	"\xf8\xa8\xf9\x38\xf9\xc7\xfa\x57\xfa\xe7\xfb\x77\xfc\x07\xfc\x98\xfd\x29\xfd\xba\xfe\x4b\xfe\xdc\xff\x6d\xff\xff")
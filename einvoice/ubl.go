@@ -0,0 +1,319 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/shopspring/decimal"
+)
+
+// Peppol BIS Billing 3.0 identifiers
+const (
+	peppolCustomization = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	peppolProfile       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+
+	ublNS = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	cacNS = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	cbcNS = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	invoiceTypeCommercial = "380" // UNCL1001 commercial invoice
+	paymentCreditTransfer = "30"  // UNCL4461 credit transfer
+	endpointEmail         = "EM"  // electronic address scheme: email
+	taxSchemeVAT          = "VAT"
+	taxSchemeOther        = "TAX" // tax scheme of the seller tax registration identifier
+)
+
+type ublInvoice struct {
+	XMLName xml.Name `xml:"Invoice"`
+	NS      string   `xml:"xmlns,attr"`
+	CAC     string   `xml:"xmlns:cac,attr"`
+	CBC     string   `xml:"xmlns:cbc,attr"`
+
+	CustomizationID      string      `xml:"cbc:CustomizationID"`
+	ProfileID            string      `xml:"cbc:ProfileID"`
+	ID                   string      `xml:"cbc:ID"`
+	IssueDate            string      `xml:"cbc:IssueDate"`
+	DueDate              string      `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode      string      `xml:"cbc:InvoiceTypeCode"`
+	Note                 string      `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string      `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference       string      `xml:"cbc:BuyerReference,omitempty"`
+	InvoicePeriod        *ublPeriod  `xml:"cac:InvoicePeriod,omitempty"`
+	OrderReference       *ublID      `xml:"cac:OrderReference>cbc:ID,omitempty"`
+	ContractReference    *ublID      `xml:"cac:ContractDocumentReference>cbc:ID,omitempty"`
+	Supplier             ublSupplier `xml:"cac:AccountingSupplierParty"`
+	Customer             ublSupplier `xml:"cac:AccountingCustomerParty"`
+
+	PaymentMeans    ublPaymentMeans      `xml:"cac:PaymentMeans"`
+	AllowanceCharge []ublAllowanceCharge `xml:"cac:AllowanceCharge"`
+	TaxTotal        ublTaxTotal          `xml:"cac:TaxTotal"`
+	MonetaryTotal   ublMonetaryTotal     `xml:"cac:LegalMonetaryTotal"`
+	Lines           []ublLine            `xml:"cac:InvoiceLine"`
+}
+
+type ublAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublID struct {
+	Scheme string `xml:"schemeID,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type ublPeriod struct {
+	StartDate string `xml:"cbc:StartDate,omitempty"`
+	EndDate   string `xml:"cbc:EndDate,omitempty"`
+}
+
+type ublSupplier struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublParty struct {
+	EndpointID  *ublID         `xml:"cbc:EndpointID,omitempty"`
+	Name        string         `xml:"cac:PartyName>cbc:Name"`
+	Address     ublAddress     `xml:"cac:PostalAddress"`
+	TaxSchemes  []ublPartyTax  `xml:"cac:PartyTaxScheme"`
+	LegalEntity ublLegalEntity `xml:"cac:PartyLegalEntity"`
+	Contact     *ublContact    `xml:"cac:Contact,omitempty"`
+}
+
+type ublAddress struct {
+	Street     string `xml:"cbc:StreetName,omitempty"`
+	Additional string `xml:"cbc:AdditionalStreetName,omitempty"`
+	City       string `xml:"cbc:CityName,omitempty"`
+	Postcode   string `xml:"cbc:PostalZone,omitempty"`
+	Country    string `xml:"cac:Country>cbc:IdentificationCode"`
+}
+
+type ublPartyTax struct {
+	CompanyID string `xml:"cbc:CompanyID"`
+	TaxScheme string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublContact struct {
+	Telephone string `xml:"cbc:Telephone,omitempty"`
+	Email     string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublPaymentMeans struct {
+	Code      string          `xml:"cbc:PaymentMeansCode"`
+	PaymentID string          `xml:"cbc:PaymentID,omitempty"`
+	Account   *ublFinancialAc `xml:"cac:PayeeFinancialAccount,omitempty"`
+}
+
+type ublFinancialAc struct {
+	ID     string `xml:"cbc:ID"`
+	Name   string `xml:"cbc:Name,omitempty"`
+	Branch *ublID `xml:"cac:FinancialInstitutionBranch>cbc:ID,omitempty"`
+}
+
+type ublTaxCategory struct {
+	ID              string `xml:"cbc:ID"`
+	Percent         string `xml:"cbc:Percent,omitempty"`
+	ExemptionReason string `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme       string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublAllowanceCharge struct {
+	ChargeIndicator bool           `xml:"cbc:ChargeIndicator"`
+	Reason          string         `xml:"cbc:AllowanceChargeReason,omitempty"`
+	Amount          ublAmount      `xml:"cbc:Amount"`
+	TaxCategory     ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount ublAmount      `xml:"cbc:TaxAmount"`
+	Subtotal  ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtension  ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusive   ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusive   ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotal *ublAmount `xml:"cbc:AllowanceTotalAmount,omitempty"`
+	ChargeTotal    *ublAmount `xml:"cbc:ChargeTotalAmount,omitempty"`
+	Payable        ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublLine struct {
+	ID            string    `xml:"cbc:ID"`
+	Quantity      ublQty    `xml:"cbc:InvoicedQuantity"`
+	LineExtension ublAmount `xml:"cbc:LineExtensionAmount"`
+	Item          ublItem   `xml:"cac:Item"`
+	Price         ublAmount `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type ublQty struct {
+	Unit  string `xml:"unitCode,attr"`
+	Value string `xml:",chardata"`
+}
+
+type ublItem struct {
+	Name        string         `xml:"cbc:Name"`
+	TaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+// WriteUBL validates the document and writes it to w as UBL 2.1 invoice,
+// following Peppol BIS Billing 3.0.
+func (d *Document) WriteUBL(w io.Writer) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d.ubl()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func (d *Document) amount(v decimal.Decimal) ublAmount {
+	return ublAmount{Currency: d.Currency, Value: amount(v)}
+}
+
+func (d *Document) taxCategory() ublTaxCategory {
+	c := ublTaxCategory{ID: d.TaxCategory, TaxScheme: taxSchemeVAT}
+	if d.TaxCategory != TaxNotSubject {
+		c.Percent = d.TaxPercent.String()
+	}
+	return c
+}
+
+func (d *Document) ubl() *ublInvoice {
+	inv := ublInvoice{
+		NS:  ublNS,
+		CAC: cacNS,
+		CBC: cbcNS,
+
+		CustomizationID:      peppolCustomization,
+		ProfileID:            peppolProfile,
+		ID:                   d.ID,
+		IssueDate:            date(d.IssueDate),
+		DueDate:              date(d.DueDate),
+		InvoiceTypeCode:      invoiceTypeCommercial,
+		Note:                 d.Note,
+		DocumentCurrencyCode: d.Currency,
+		BuyerReference:       d.BuyerReference,
+		OrderReference:       optUBLID(d.OrderReference),
+		ContractReference:    optUBLID(d.ContractReference),
+		Supplier:             ublSupplier{d.Seller.ubl()},
+		Customer:             ublSupplier{d.Buyer.ubl()},
+		PaymentMeans: ublPaymentMeans{
+			Code:      paymentCreditTransfer,
+			PaymentID: d.Payment.Reference,
+			Account: &ublFinancialAc{
+				ID:     d.Payment.Account,
+				Name:   d.Payment.AccountName,
+				Branch: optUBLID(d.Payment.Bank),
+			},
+		},
+	}
+	if !d.PeriodStart.IsZero() || !d.PeriodEnd.IsZero() {
+		inv.InvoicePeriod = &ublPeriod{StartDate: date(d.PeriodStart), EndDate: date(d.PeriodEnd)}
+	}
+
+	taxCat := d.taxCategory()
+	for _, a := range d.Adjustments {
+		inv.AllowanceCharge = append(inv.AllowanceCharge, ublAllowanceCharge{
+			ChargeIndicator: !a.Amount.IsNegative(),
+			Reason:          a.Reason,
+			Amount:          d.amount(a.Amount.Abs()),
+			TaxCategory:     taxCat,
+		})
+	}
+
+	subtotalCat := taxCat
+	switch d.TaxCategory {
+	case TaxExempt:
+		subtotalCat.ExemptionReason = "Exempt from tax"
+	case TaxNotSubject:
+		subtotalCat.ExemptionReason = "Not subject to VAT"
+	}
+	inv.TaxTotal = ublTaxTotal{
+		TaxAmount: d.amount(d.Tax()),
+		Subtotal: ublTaxSubtotal{
+			TaxableAmount: d.amount(d.TaxExclusive()),
+			TaxAmount:     d.amount(d.Tax()),
+			TaxCategory:   subtotalCat,
+		},
+	}
+
+	allowance, charge := d.allowances()
+	inv.MonetaryTotal = ublMonetaryTotal{
+		LineExtension: d.amount(d.LineTotal()),
+		TaxExclusive:  d.amount(d.TaxExclusive()),
+		TaxInclusive:  d.amount(d.TaxInclusive()),
+		Payable:       d.amount(d.TaxInclusive()),
+	}
+	if !allowance.IsZero() {
+		a := d.amount(allowance)
+		inv.MonetaryTotal.AllowanceTotal = &a
+	}
+	if !charge.IsZero() {
+		c := d.amount(charge)
+		inv.MonetaryTotal.ChargeTotal = &c
+	}
+
+	for _, l := range d.Lines {
+		inv.Lines = append(inv.Lines, ublLine{
+			ID:            l.ID,
+			Quantity:      ublQty{Unit: unitHour, Value: l.Quantity.Round(4).String()},
+			LineExtension: d.amount(l.Net),
+			Item:          ublItem{Name: l.Name, TaxCategory: taxCat},
+			Price:         ublAmount{Currency: d.Currency, Value: l.Price.String()},
+		})
+	}
+	return &inv
+}
+
+// optUBLID returns the identifier, or nil, if the value is empty, so that
+// the element is omitted.
+func optUBLID(value string) *ublID {
+	if value == "" {
+		return nil
+	}
+	return &ublID{Value: value}
+}
+
+func (p *Party) ubl() ublParty {
+	party := ublParty{
+		Name: p.Name,
+		Address: ublAddress{
+			Street:     p.Street,
+			Additional: p.Additional,
+			City:       p.City,
+			Postcode:   p.Postcode,
+			Country:    p.CountryCode,
+		},
+		LegalEntity: ublLegalEntity{RegistrationName: p.Name, CompanyID: p.LegalID},
+	}
+	if p.Email != "" {
+		party.EndpointID = &ublID{Scheme: endpointEmail, Value: p.Email}
+	}
+	if p.VATID != "" {
+		party.TaxSchemes = append(party.TaxSchemes, ublPartyTax{CompanyID: p.VATID, TaxScheme: taxSchemeVAT})
+	}
+	if p.TaxID != "" {
+		party.TaxSchemes = append(party.TaxSchemes, ublPartyTax{CompanyID: p.TaxID, TaxScheme: taxSchemeOther})
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ublContact{Telephone: p.Phone, Email: p.Email}
+	}
+	return party
+}
@@ -0,0 +1,108 @@
+package einvoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func testDocument() *Document {
+	return &Document{
+		ID:             "42",
+		IssueDate:      time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:        time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC),
+		Currency:       "EUR",
+		BuyerReference: "PO-1",
+		Seller:         Party{Name: "Seller", CountryCode: "DE", VATID: "DE123456789", Email: "seller@example.com"},
+		Buyer:          Party{Name: "Buyer", CountryCode: "FR", Email: "buyer@example.com"},
+		Lines: []Line{
+			{ID: "1", Name: "Consulting", Quantity: decimal.New(10, 0), Price: decimal.New(100, 0), Net: decimal.New(1000, 0)},
+		},
+		Adjustments: []Adjustment{{Reason: "Retainer", Amount: decimal.New(-200, 0)}},
+		TaxCategory: TaxStandard,
+		TaxPercent:  decimal.New(19, 0),
+		Payment:     Payment{Account: "DE89370400440532013000", Bank: "COBADEFFXXX"},
+	}
+}
+
+func TestDocument_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(d *Document)
+		wantErr string
+	}{
+		{"valid", func(d *Document) {}, ""},
+		{"missing seller VAT", func(d *Document) { d.Seller.VATID = "" }, "seller VAT identifier (BT-31)"},
+		{"missing currency", func(d *Document) { d.Currency = "" }, "currency code (BT-5)"},
+		{"no lines", func(d *Document) { d.Lines = nil }, "at least one line"},
+		{"not subject with VAT ID", func(d *Document) { d.TaxCategory = TaxNotSubject }, "must not be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testDocument()
+			tt.modify(d)
+			err := d.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDocument_WriteUBL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDocument().WriteUBL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<cbc:CustomizationID>` + peppolCustomization,
+		`<cbc:EndpointID schemeID="EM">seller@example.com</cbc:EndpointID>`,
+		`<cbc:CompanyID>DE123456789</cbc:CompanyID>`,
+		`<cbc:ChargeIndicator>false</cbc:ChargeIndicator>`,
+		`<cbc:TaxAmount currencyID="EUR">152.00</cbc:TaxAmount>`,
+		`<cbc:PayableAmount currencyID="EUR">952.00</cbc:PayableAmount>`,
+		`<cbc:InvoicedQuantity unitCode="HUR">10</cbc:InvoicedQuantity>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteUBL() output is missing %s", want)
+		}
+	}
+	// optional references must be omitted altogether
+	if strings.Contains(out, "<cac:OrderReference>") {
+		t.Error("WriteUBL() output contains empty order reference")
+	}
+}
+
+func TestDocument_WriteUBL_references(t *testing.T) {
+	d := testDocument()
+	d.OrderReference = "PO-1"
+	d.ContractReference = "SOW-7"
+	d.Seller.VATID = ""
+	d.Seller.TaxID = "12/345/67890"
+	d.Seller.LegalID = "HRB 12345"
+	var buf bytes.Buffer
+	if err := d.WriteUBL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"<cac:OrderReference>\n    <cbc:ID>PO-1</cbc:ID>",
+		"<cac:ContractDocumentReference>\n    <cbc:ID>SOW-7</cbc:ID>",
+		"<cbc:CompanyID>12/345/67890</cbc:CompanyID>\n        <cac:TaxScheme>\n          <cbc:ID>TAX</cbc:ID>",
+		"<cbc:CompanyID>HRB 12345</cbc:CompanyID>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteUBL() output is missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package forms
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// timeFmt is the time format of the timesheet appendix.
+const timeFmt = "15:04"
+
+// appendix table columns: date, start, end, description, hours
+var (
+	appendixKeys  = []string{LabelColDate, LabelColStart, LabelColEnd, LabelColDescription, LabelColHours}
+	appendixColPc = []float64{0.14, 0.09, 0.09, 0.56, 0.12}
+)
+
+// AppendixRow is the row of the timesheet appendix.
+type AppendixRow struct {
+	Date        time.Time
+	Start, End  time.Time // not printed, if zero
+	Description string
+	Hours       decimal.Decimal
+	DayTotal    bool // the row is the day subtotal
+}
+
+// AppendixGroup is the group of the timesheet appendix rows, i.e. all rows of
+// the issue.
+type AppendixGroup struct {
+	Title string
+	Rows  []AppendixRow
+	Hours decimal.Decimal // group subtotal
+}
+
+// AddAppendixGroup adds the group of rows to the timesheet appendix, that is
+// printed after the invoice.
+func (c *Content) AddAppendixGroup(title string, rows []AppendixRow, hours decimal.Decimal) *Content {
+	c.appendix = append(c.appendix, AppendixGroup{Title: title, Rows: rows, Hours: hours})
+	return c
+}
+
+// timesheet prints the timesheet appendix on the new page.
+func (f *InvoiceForm) timesheet() {
+	if len(f.appendix) == 0 {
+		return
+	}
+	const tabCellH = defCellHeight
+
+	f.pdf.AddPage()
+	f.setFont(f.s.Title.font())
+	f.pdf.SetTextColor(f.s.Title.fg())
+	f.cell(f.w, 7, f.loc.Label(LabelTimesheet), "0", 1, "LM", false, 0, "")
+	f.pdf.Ln(tabCellH / 2)
+	f.tableHeader(appendixColPc, appendixKeys, tabCellH)
+
+	var total decimal.Decimal
+	for _, g := range f.appendix {
+		f.appendixBreak(tabCellH * 2)
+		f.setFont(f.s.TableRowOdd.FamilyStr, "B", f.s.TableRowOdd.Size)
+		f.pdf.SetX(f.m.Left)
+		f.cell(f.w, tabCellH, g.Title, "B", 1, "L", false, 0, "")
+		for _, r := range g.Rows {
+			f.appendixRow(r, tabCellH)
+		}
+		f.appendixBreak(tabCellH)
+		f.appendixTotal(f.loc.Label(LabelIssueTotal), "B", g.Hours, tabCellH)
+		total = total.Add(g.Hours)
+	}
+	f.appendixBreak(tabCellH + 2)
+	f.appendixTotal(f.loc.Label(LabelTotalHours), "B", total, tabCellH+2)
+}
+
+// appendixRow prints the appendix row.
+func (f *InvoiceForm) appendixRow(r AppendixRow, tabCellH float64) {
+	if r.DayTotal {
+		f.appendixBreak(tabCellH)
+		f.appendixTotal(f.loc.Label(LabelDayTotal), "I", r.Hours, tabCellH)
+		return
+	}
+	f.setFont(f.s.TableRowOdd.font())
+	w := func(i int) float64 { return f.w * appendixColPc[i] }
+	f.appendixBreak(f.rowHeight(r.Description, w(3), tabCellH))
+
+	y := f.pdf.GetY()
+	f.pdf.SetX(f.m.Left + w(0) + w(1) + w(2))
+	f.pdf.MultiCell(w(3), tabCellH, f.text(r.Description), "B", "L", false)
+	Δy := f.pdf.GetY() - y
+	f.pdf.SetXY(f.m.Left, y)
+	f.cell(w(0), Δy, f.loc.Date(r.Date), "B", 0, "L", false, 0, "")
+	f.cell(w(1), Δy, clock(r.Start), "B", 0, "C", false, 0, "")
+	f.cell(w(2), Δy, clock(r.End), "B", 0, "C", false, 0, "")
+	f.pdf.SetX(f.pdf.GetX() + w(3))
+	f.cell(w(4), Δy, f.loc.Number(r.Hours), "B", 1, "R", false, 0, "")
+}
+
+// appendixTotal prints the total row with the label in the font style.
+func (f *InvoiceForm) appendixTotal(label, style string, hours decimal.Decimal, ch float64) {
+	f.setFont(f.s.TableRowOdd.FamilyStr, style, f.s.TableRowOdd.Size)
+	f.pdf.SetX(f.m.Left)
+	f.cell(f.w*(1-appendixColPc[4]), ch, label, "B", 0, "R", false, 0, "")
+	f.cell(f.w*appendixColPc[4], ch, f.loc.Number(hours), "B", 1, "R", false, 0, "")
+}
+
+// appendixBreak starts the new page with the table header, if the block of
+// height h doesn't fit on the page.
+func (f *InvoiceForm) appendixBreak(h float64) {
+	if f.pdf.GetY()+h <= f.pageBottom() {
+		return
+	}
+	family, style := f.family, f.style
+	size, _ := f.pdf.GetFontSize()
+	f.pdf.AddPage()
+	f.tableHeader(appendixColPc, appendixKeys, defCellHeight)
+	f.setFont(family, style, size)
+}
+
+// clock returns the time of the day, or empty string, if t is zero.
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeFmt)
+}
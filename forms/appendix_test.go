@@ -0,0 +1,62 @@
+package forms
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInvoiceForm_timesheet(t *testing.T) {
+	core := defStyle
+	for _, font := range []*Font{&core.Title, &core.Heading, &core.Body, &core.Address, &core.TableHead, &core.TableRowOdd, &core.TableRowEven} {
+		font.FamilyStr = helvetica
+	}
+	day := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	var rows []AppendixRow
+	for i := 0; i < 60; i++ {
+		start := day.Add(time.Duration(i/3*24+9+i%3) * time.Hour)
+		rows = append(rows, AppendixRow{Date: start, Start: start, End: start.Add(time.Hour), Description: "work", Hours: decimal.New(1, 0)})
+		if i%3 == 2 {
+			rows = append(rows, AppendixRow{Date: start, Hours: decimal.New(3, 0), DayTotal: true})
+		}
+	}
+
+	tests := []struct {
+		name      string
+		groups    int
+		wantPages int
+		want      []string
+	}{
+		{"no appendix", 0, 1, nil},
+		{"appendix", 1, 4, []string{"(TIMESHEET)", "(ACME-1: work)", "(09:00)", "(Day total)", "(Total hours)", "(60.00)", "(Page 4 of 4)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewInvoice("42", PgA4, nil, &core)
+			f.Fpdf().SetCompression(false)
+			f.AddEntry("work", "60.00", "100.00", "6000.00").SetTotal("", "6000.00")
+			for i := 0; i < tt.groups; i++ {
+				f.AddAppendixGroup("ACME-1: work", rows, decimal.New(60, 0))
+			}
+			var buf bytes.Buffer
+			if err := f.Write(&buf, &InvoiceFields{Date: time.Now()}); err != nil {
+				t.Fatalf("InvoiceForm.Write() error = %v", err)
+			}
+			if got := f.Fpdf().PageCount(); got != tt.wantPages {
+				t.Errorf("InvoiceForm.Write() pages = %d, want %d", got, tt.wantPages)
+			}
+			// the header is repeated on every appendix page
+			if got := strings.Count(buf.String(), "(DATE)"); got != tt.wantPages-1 {
+				t.Errorf("InvoiceForm.Write() appendix headers = %d, want %d", got, tt.wantPages-1)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("InvoiceForm.Write() output does not contain %q", s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package forms
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Content is the invoice content: line items, totals and payment account
+// details.  It is shared by all invoice renderers.
+type Content struct {
+	invoiceID string
+
+	// page elements
+	account   [][keyValueSz]string
+	entries   [][entrySz]string
+	subTotals [][totalSz]string
+	total     [totalSz]string
+
+	// amount payable, for payment codes
+	payable  decimal.Decimal
+	currency string
+
+	appendix []AppendixGroup // timesheet appendix
+}
+
+func newContent(invoiceID string) Content {
+	if invoiceID == "" {
+		invoiceID = time.Now().Format(defInvoiceNoFmt)
+	}
+	return Content{
+		invoiceID: invoiceID,
+		account:   make([][keyValueSz]string, 0),
+		entries:   make([][entrySz]string, 0),
+		subTotals: make([][totalSz]string, 0),
+	}
+}
+
+// Contents returns the content.
+func (c *Content) Contents() *Content {
+	return c
+}
+
+// InvoiceID returns the invoice number.
+func (c *Content) InvoiceID() string {
+	return c.invoiceID
+}
+
+// Payable returns the amount payable and its currency code.
+func (c *Content) Payable() (decimal.Decimal, string) {
+	return c.payable, c.currency
+}
+
+// AddEntry adds an entry to the Invoice item list.
+func (c *Content) AddEntry(description, qty, price, total string) *Content {
+	c.entries = append(c.entries, [entrySz]string{description, qty, price, total})
+	return c
+}
+
+// AddSubTotal adds a total to the invoice form
+func (c *Content) AddSubTotal(name, value string) *Content {
+	c.subTotals = append(c.subTotals, [totalSz]string{name, value})
+	return c
+}
+
+// SetTotal sets the total line name and value.
+func (c *Content) SetTotal(name, value string) *Content {
+	if name == "" {
+		name = "BALANCE DUE"
+	}
+	c.total = [totalSz]string{name, value}
+	return c
+}
+
+// AddAccountDetail adds the account information row.
+func (c *Content) AddAccountDetail(name, value string) *Content {
+	c.account = append(c.account, [keyValueSz]string{name + " ", value})
+	return c
+}
+
+// SetPayable sets the amount payable and its ISO 4217 currency code, that are
+// encoded in the payment codes.
+func (c *Content) SetPayable(amount decimal.Decimal, currency string) *Content {
+	c.payable = amount
+	c.currency = currency
+	return c
+}
@@ -0,0 +1,214 @@
+package forms
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// GoFont is the family name of the bundled Go fonts (BSD licensed), that
+// cover Latin, Greek and Cyrillic scripts.
+const GoFont = "Go"
+
+// font styles, as understood by gofpdf.
+const (
+	styleRegular    = ""
+	styleBold       = "B"
+	styleItalic     = "I"
+	styleBoldItalic = "BI"
+)
+
+// TTF is the TrueType font family.  Font files are registered with the PDF
+// as UTF-8 fonts with the Family name, that can be used in the Style fonts.
+// Missing bold and italic faces fall back to the regular face.
+type TTF struct {
+	Family     string
+	Regular    string // font file paths
+	Bold       string `yaml:",omitempty"`
+	Italic     string `yaml:",omitempty"`
+	BoldItalic string `yaml:"bold_italic,omitempty"`
+}
+
+// fontFace is the registered font family.
+type fontFace struct {
+	core  bool                  // core font, supports cp1252 only
+	faces map[string]*sfnt.Font // parsed TTF faces by style
+}
+
+// coreFonts are gofpdf core font families.
+var coreFonts = map[string]bool{helvetica: true, "arial": true, times: true, courier: true}
+
+// registerFonts registers the TTF fonts of the style and the bundled font
+// with the PDF.  Errors are set on the PDF.
+func (f *InvoiceForm) registerFonts() {
+	f.fonts = make(map[string]*fontFace)
+	for family := range coreFonts {
+		f.fonts[strings.ToLower(family)] = &fontFace{core: true}
+	}
+	bundled := TTF{Family: GoFont}
+	f.addFont(bundled, map[string][]byte{
+		styleRegular:    goregular.TTF,
+		styleBold:       gobold.TTF,
+		styleItalic:     goitalic.TTF,
+		styleBoldItalic: gobolditalic.TTF,
+	})
+	for _, ttf := range f.s.Fonts {
+		files := map[string]string{
+			styleRegular:    ttf.Regular,
+			styleBold:       ttf.Bold,
+			styleItalic:     ttf.Italic,
+			styleBoldItalic: ttf.BoldItalic,
+		}
+		data := make(map[string][]byte, len(files))
+		for style, filename := range files {
+			if filename == "" {
+				continue
+			}
+			b, err := ioutil.ReadFile(filename)
+			if err != nil {
+				f.pdf.SetErrorf("font %s: %s", ttf.Family, err)
+				return
+			}
+			data[style] = b
+		}
+		if data[styleRegular] == nil {
+			f.pdf.SetErrorf("font %s: regular font file is not set", ttf.Family)
+			return
+		}
+		f.addFont(ttf, data)
+	}
+}
+
+// addFont parses and registers the font faces, missing styles fall back to
+// the regular or bold face.
+func (f *InvoiceForm) addFont(ttf TTF, data map[string][]byte) {
+	fallback := map[string][]string{
+		styleBold:       {styleRegular},
+		styleItalic:     {styleRegular},
+		styleBoldItalic: {styleBold, styleRegular},
+	}
+	face := fontFace{faces: make(map[string]*sfnt.Font)}
+	for _, style := range []string{styleRegular, styleBold, styleItalic, styleBoldItalic} {
+		b := data[style]
+		for _, fb := range fallback[style] {
+			if b != nil {
+				break
+			}
+			b = data[fb]
+		}
+		font, err := sfnt.Parse(b)
+		if err != nil {
+			f.pdf.SetErrorf("font %s: %s", ttf.Family, err)
+			return
+		}
+		face.faces[style] = font
+		f.pdf.AddUTF8FontFromBytes(ttf.Family, style, b)
+	}
+	f.fonts[strings.ToLower(ttf.Family)] = &face
+}
+
+// family returns the font family, or GoFont, if the family is a core font
+// and the fonts must be embedded.
+func (s *Style) family(name string) string {
+	if !s.EmbedFonts {
+		return name
+	}
+	for core := range coreFonts {
+		if strings.EqualFold(core, name) {
+			return GoFont
+		}
+	}
+	return name
+}
+
+// setFont sets the font, and keeps track of the current font family and
+// style.  Empty family keeps the current family.
+func (f *InvoiceForm) setFont(family, style string, size float64) {
+	if family != "" {
+		family = f.s.family(family)
+		f.family = strings.ToLower(family)
+	}
+	f.style = strings.ToUpper(strings.Replace(style, "U", "", -1))
+	f.pdf.SetFont(family, style, size)
+}
+
+// text returns the string, encoded for the current font.  If the font
+// can't display any of the characters, the error is set on the PDF.
+func (f *InvoiceForm) text(s string) string {
+	face := f.fonts[f.family]
+	if face == nil {
+		return s // unknown font, gofpdf reports it.
+	}
+	if r, ok := face.missing(s, f.style); ok {
+		f.pdf.SetErrorf("font %s cannot display %q in %q, use a font that supports it", f.family, r, s)
+		return s
+	}
+	return f.encode(s)
+}
+
+// encode converts the string to cp1252 for the core fonts.
+func (f *InvoiceForm) encode(s string) string {
+	if face := f.fonts[f.family]; face != nil && face.core {
+		return f.cp1252(s)
+	}
+	return s
+}
+
+// width returns the width of the string in the current font.
+func (f *InvoiceForm) width(s string) float64 {
+	return f.pdf.GetStringWidth(f.encode(s))
+}
+
+// lines returns the number of lines of the string, printed with MultiCell
+// of width w in the current font.
+func (f *InvoiceForm) lines(s string, w float64) int {
+	if f.pdf.Err() {
+		return 1 // no current font to measure with
+	}
+	var n int
+	if face := f.fonts[f.family]; face != nil && face.core {
+		n = len(f.pdf.SplitLines([]byte(f.encode(s)), w))
+	} else {
+		n = len(f.pdf.SplitText(s, w))
+	}
+	if n == 0 {
+		n = 1 // MultiCell prints the empty line
+	}
+	return n
+}
+
+// missing returns the first character, that the font face can't display.
+func (ff *fontFace) missing(s string, style string) (rune, bool) {
+	var buf sfnt.Buffer
+	font := ff.faces[style]
+	if !ff.core && font == nil {
+		font = ff.faces[styleRegular]
+	}
+	for _, r := range s {
+		if r < ' ' {
+			continue // line breaks and tabs
+		}
+		if ff.core {
+			if !isCP1252(r) {
+				return r, true
+			}
+			continue
+		}
+		if idx, err := font.GlyphIndex(&buf, r); err != nil || idx == 0 {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// cp1252 characters in the 0x80-0x9f range.
+const cp1252Specials = "€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ"
+
+func isCP1252(r rune) bool {
+	return r < 0x80 || (0xa0 <= r && r <= 0xff) || strings.ContainsRune(cp1252Specials, r)
+}
@@ -0,0 +1,60 @@
+package forms
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInvoiceForm_fonts(t *testing.T) {
+	core := defStyle
+	core.Body = Font{helvetica, "", 10, cBlack, cDefault}
+	core.Address = core.Body
+
+	missing := defStyle
+	missing.Fonts = []TTF{{Family: "Missing", Regular: "does-not-exist.ttf"}}
+
+	tests := []struct {
+		name    string
+		style   *Style
+		client  string
+		wantErr string
+	}{
+		{"cyrillic, bundled font", nil, "ООО «Ромашка»", ""},
+		{"polish, bundled font", nil, "Zakład Łódź", ""},
+		{"cjk, bundled font", nil, "株式会社", "cannot display '株'"},
+		{"latin-1, core font", &core, "Müller GmbH", ""},
+		{"cyrillic, core font", &core, "ООО «Ромашка»", "cannot display 'О'"},
+		{"missing font file", &missing, "ACME", "does-not-exist.ttf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewInvoice("42", PgA4, nil, tt.style)
+			f.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+			var buf bytes.Buffer
+			err := f.Write(&buf, &InvoiceFields{BillTo: Address{Name: tt.client}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("InvoiceForm.Write() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("InvoiceForm.Write() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_isCP1252(t *testing.T) {
+	for _, r := range "Aé€ŸÿŒ" {
+		if !isCP1252(r) {
+			t.Errorf("isCP1252(%q) = false, want true", r)
+		}
+	}
+	for _, r := range "ŁЖ株\u0081" {
+		if isCP1252(r) {
+			t.Errorf("isCP1252(%q) = true, want false", r)
+		}
+	}
+}
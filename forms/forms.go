@@ -1,6 +1,7 @@
 package forms
 
 import (
+	"fmt"
 	"image/color"
 )
 
@@ -44,9 +45,48 @@ type Address struct {
 	Town         string
 	Postcode     string
 	Country      string
+	CountryCode  string `yaml:"country_code,omitempty"` // ISO 3166-1 alpha-2 code, i.e. "DE"
 
 	Phone string
 	Email string
+
+	VATID        string  `yaml:"vat_id,omitempty"`       // VAT identification number
+	TaxIDs       []TaxID `yaml:"tax_ids,omitempty"`      // other tax identifiers, i.e. GST, ABN or TIN
+	Registration string  `yaml:"registration,omitempty"` // company registration number
+}
+
+// TaxID is a tax identifier of the scheme, i.e. "GST", "ABN".
+type TaxID struct {
+	Scheme string
+	Value  string
+}
+
+// TaxIDList returns all tax identifiers of the address, starting with the
+// VAT identification number, if set.
+func (a Address) TaxIDList() []TaxID {
+	var ids []TaxID
+	if a.VATID != "" {
+		ids = append(ids, TaxID{Scheme: "VAT", Value: a.VATID})
+	}
+	for _, id := range a.TaxIDs {
+		if id.Value != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// legalLines returns tax identifier and registration number lines, as
+// printed on the invoice.
+func (a Address) legalLines(loc *Locale) []string {
+	var lines []string
+	for _, id := range a.TaxIDList() {
+		lines = append(lines, fmt.Sprintf("%s: %s", id.Scheme, id.Value))
+	}
+	if a.Registration != "" {
+		lines = append(lines, loc.Label(LabelRegNo)+": "+a.Registration)
+	}
+	return lines
 }
 
 // Style is a form style
@@ -63,6 +103,19 @@ type Style struct {
 	AccentColor color.RGBA
 
 	LineSpacing float64
+
+	Fonts      []TTF // TrueType fonts to register, the bundled GoFont is always available
+	EmbedFonts bool  // core fonts are replaced with GoFont, so that all fonts are embedded
+
+	Columns []float64 // table column widths, fractions of the text area width
+	Logo    Box       // logo position and size
+}
+
+// Box is the position and size in mm.  Zero width or height is calculated
+// from the image aspect ratio.
+type Box struct {
+	X, Y          float64 `yaml:",omitempty"`
+	Width, Height float64 `yaml:",omitempty"`
 }
 
 // Font represents a font
@@ -81,14 +134,14 @@ type Margins struct {
 
 var defMargins = Margins{25.7, 25.7, 25.7, 25.7}
 var defStyle = Style{
-	Title:   Font{helvetica, "", 16, cLightGray, cDefault},
-	Heading: Font{helvetica, "", 10, cTeal, cDefault},
-	Body:    Font{helvetica, "", 10, cBlack, cDefault},
-	Address: Font{helvetica, "", 10, cBlack, cDefault},
-
-	TableHead:    Font{helvetica, "", 10, cWhite, cTeal},
-	TableRowOdd:  Font{helvetica, "", 9, cBlack, cDefault},
-	TableRowEven: Font{helvetica, "", 9, cBlack, cDefault},
+	Title:   Font{GoFont, "", 16, cLightGray, cDefault},
+	Heading: Font{GoFont, "", 10, cTeal, cDefault},
+	Body:    Font{GoFont, "", 10, cBlack, cDefault},
+	Address: Font{GoFont, "", 10, cBlack, cDefault},
+
+	TableHead:    Font{GoFont, "", 10, cWhite, cTeal},
+	TableRowOdd:  Font{GoFont, "", 9, cBlack, cDefault},
+	TableRowEven: Font{GoFont, "", 9, cBlack, cDefault},
 
 	AccentColor: cTeal,
 
@@ -2,6 +2,7 @@ package forms
 
 import (
 	"image/color"
+	"reflect"
 	"testing"
 )
 
@@ -33,3 +34,27 @@ func Test_toRGB(t *testing.T) {
 		})
 	}
 }
+
+func TestAddress_legalLines(t *testing.T) {
+	tests := []struct {
+		name string
+		addr Address
+		want []string
+	}{
+		{"empty", Address{}, nil},
+		{"vat", Address{VATID: "DE123456789"}, []string{"VAT: DE123456789"}},
+		{"all", Address{
+			VATID:        "DE123456789",
+			TaxIDs:       []TaxID{{"ABN", "51 824 753 556"}, {"TIN", ""}},
+			Registration: "HRB 12345",
+		}, []string{"VAT: DE123456789", "ABN: 51 824 753 556", "Reg. No.: HRB 12345"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, _ := NewLocale(DefaultLanguage, "")
+			if got := tt.addr.legalLines(loc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Address.legalLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package forms
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// HTMLForm renders the invoice as HTML using html/template.
+type HTMLForm struct {
+	Content
+
+	tmpl *template.Template
+}
+
+// HTMLData is the data passed to the HTML template.
+type HTMLData struct {
+	ID     string
+	Fields *InvoiceFields
+
+	Date        string
+	Due         string
+	PeriodStart string
+	PeriodEnd   string
+
+	Labels    map[string]string // localized labels, keyed by label key, i.e. "bill_to"
+	Columns   []string
+	Entries   []HTMLEntry
+	SubTotals []KeyValue
+	Total     KeyValue
+	Account   []KeyValue
+	Remarks   []string // remarks lines
+	Stamp     *Stamp   // status stamp, nil if none
+	Meta      *Metadata
+}
+
+// HTMLEntry is the invoice line item.
+type HTMLEntry struct {
+	No          int
+	Description string
+	Qty         string
+	Price       string
+	Total       string
+}
+
+// KeyValue is the name and value pair.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+var htmlFuncs = template.FuncMap{
+	"address": func(addr Address) []string { return addressLines(addr, nil) },
+	"join":    strings.Join,
+}
+
+// NewHTML creates the HTML invoice form.  If no template files are given,
+// the built-in default template is used.  The template named "invoice" is
+// executed, or, if there's none, the first template.
+func NewHTML(invoiceID string, templateFiles ...string) (*HTMLForm, error) {
+	var (
+		tmpl *template.Template
+		err  error
+	)
+	if len(templateFiles) == 0 {
+		tmpl, err = template.New("invoice").Funcs(htmlFuncs).Parse(defHTMLTemplate)
+	} else {
+		tmpl, err = template.New(filepath.Base(templateFiles[0])).Funcs(htmlFuncs).ParseFiles(templateFiles...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t := tmpl.Lookup("invoice"); t != nil {
+		tmpl = t
+	}
+	return &HTMLForm{Content: newContent(invoiceID), tmpl: tmpl}, nil
+}
+
+// Write renders the invoice and writes it to w.
+func (f *HTMLForm) Write(w io.Writer, fields *InvoiceFields) error {
+	loc, err := NewLocale(fields.Language, fields.SecondLanguage)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	f.tmpl.Funcs(template.FuncMap{
+		"address": func(addr Address) []string { return addressLines(addr, loc) },
+	})
+	d := f.data(fields, loc)
+	d.Meta = f.metadata(fields, loc)
+	if d.Stamp, err = fields.stamp(loc); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	if err := f.tmpl.Execute(w, d); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	return nil
+}
+
+// data returns the template data in the same order as the PDF form.
+func (f *HTMLForm) data(fields *InvoiceFields, loc *Locale) *HTMLData {
+	d := HTMLData{
+		ID:          f.invoiceID,
+		Fields:      fields,
+		Date:        loc.Date(fields.Date),
+		Due:         loc.Date(fields.Due),
+		PeriodStart: loc.Date(fields.PeriodStart),
+		PeriodEnd:   loc.Date(fields.PeriodEnd),
+		Labels:      loc.labels(),
+		Total:       KeyValue{f.total[0], f.total[1]},
+	}
+	for _, key := range columnKeys {
+		d.Columns = append(d.Columns, loc.Label(key))
+	}
+	for i, e := range f.entries {
+		d.Entries = append(d.Entries, HTMLEntry{No: i + 1, Description: e[0], Qty: e[1], Price: e[2], Total: e[3]})
+	}
+	for _, st := range f.subTotals {
+		d.SubTotals = append(d.SubTotals, KeyValue{st[0], st[1]})
+	}
+	for _, a := range f.account {
+		d.Account = append(d.Account, KeyValue{strings.TrimSpace(a[0]), a[1]})
+	}
+	if fields.Remarks != "" {
+		d.Remarks = strings.Split(fields.Remarks, "\n")
+	}
+	return &d
+}
+
+// addressLines returns address lines, following the name and organisation,
+// as printed on the invoice.  If loc is nil, default language is used.
+func addressLines(addr Address, loc *Locale) []string {
+	if loc == nil {
+		loc, _ = NewLocale(DefaultLanguage, "")
+	}
+	var lines []string
+	for _, l := range []string{
+		nvljoin([]string{addr.Floor, addr.Street}, delim),
+		nvljoin([]string{addr.Suburb, addr.Town, addr.Postcode}, delim),
+		addr.Phone,
+		addr.Email,
+	} {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return append(lines, addr.legalLines(loc)...)
+}
+
+const defHTMLTemplate = `<!DOCTYPE html>
+<html{{with .Fields.Language}} lang="{{.}}"{{end}}>
+<head>
+<meta charset="utf-8">
+<title>{{.Labels.invoice}} {{.ID}}</title>
+{{with .Meta}}{{with .Author}}<meta name="author" content="{{.}}">
+{{end}}<meta name="description" content="{{.Subject}}">
+<meta name="keywords" content="{{join .Keywords ", "}}">
+{{end}}<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 10pt; color: #000; border-left: 6px solid #497b9e; padding: 0 2em; max-width: 52em; }
+h1 { color: #bfbfbf; font-weight: normal; font-size: 16pt; }
+h5 { color: #497b9e; font-size: 10pt; font-weight: normal; border-bottom: 1px solid #000; display: inline-block; min-width: 20em; margin: 1em 0 0.5em; }
+.row { display: flex; justify-content: space-between; }
+.address p { margin: 0.2em 0; }
+table { border-collapse: collapse; width: 100%; margin-top: 1em; }
+th { background: #497b9e; color: #fff; font-weight: normal; padding: 0.4em; }
+td { border-bottom: 1px solid #000; padding: 0.4em; vertical-align: top; }
+td.num { text-align: right; }
+tr.total td { font-weight: bold; font-size: 11pt; }
+.stamp { font-weight: bold; pointer-events: none; }
+.stamp.watermark { position: fixed; top: 40%; left: 0; right: 0; text-align: center; font-size: 96pt; opacity: 0.25; transform: rotate(-35deg); }
+.stamp.corner { position: absolute; top: 1em; left: 50%; border: 3px solid; border-radius: 6px; padding: 0 0.2em; font-size: 28pt; opacity: 0.5; transform: rotate(-15deg); }
+</style>
+</head>
+<body>
+{{with .Stamp}}<div class="stamp {{.Kind}}" style="color: {{.Color}}">{{.Text}}</div>
+{{end}}<h1>{{.Labels.invoice}}</h1>
+<div class="row">
+<div class="address">
+{{template "address" .Fields.Address}}</div>
+<table style="width: auto">
+<tr><td>{{.Labels.no}}</td><td><b>{{.ID}}</b></td></tr>
+<tr><td>{{.Labels.date}}</td><td><b>{{.Date}}</b></td></tr>
+<tr><td>{{.Labels.due}}</td><td><b>{{.Due}}</b></td></tr>
+{{with .Fields.PONumber}}<tr><td>{{$.Labels.po_number}}</td><td><b>{{.}}</b></td></tr>
+{{end}}{{with .Fields.ContractID}}<tr><td>{{$.Labels.contract}}</td><td><b>{{.}}</b></td></tr>
+{{end}}</table>
+</div>
+<div class="row">
+<div class="address">
+<h5>{{.Labels.bill_to}}</h5>
+{{template "address" .Fields.BillTo}}</div>
+<div>
+<h5>{{.Labels.payment_details}}</h5>
+{{range .Account}}<p>{{.Key}} <b>{{.Value}}</b></p>
+{{end}}</div>
+</div>
+<p>{{.Labels.time_period}} <b>{{.PeriodStart}} - {{.PeriodEnd}}</b></p>
+<table>
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Entries}}<tr><td class="num">{{.No}}</td><td>{{.Description}}</td><td class="num">{{.Qty}}</td><td class="num">{{.Price}}</td><td class="num">{{.Total}}</td></tr>
+{{end}}</tbody>
+<tfoot>
+{{range .SubTotals}}<tr><td colspan="2"></td><td colspan="2"><b>{{.Key}}</b></td><td class="num">{{.Value}}</td></tr>
+{{end}}<tr class="total"><td colspan="2"></td><td colspan="2">{{.Total.Key}}</td><td class="num">{{.Total.Value}}</td></tr>
+</tfoot>
+</table>
+{{if .Remarks}}<p><b>{{.Labels.remarks}}</b></p>
+{{range .Remarks}}<p>{{.}}</p>
+{{end}}{{end}}</body>
+</html>
+{{define "address"}}{{with .Name}}<p><b>{{.}}</b></p>
+{{end}}{{with .Organisation}}<p><b>{{.}}</b></p>
+{{end}}{{range address .}}<p>{{.}}</p>
+{{end}}{{end}}`
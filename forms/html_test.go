@@ -0,0 +1,58 @@
+package forms
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLForm_Write(t *testing.T) {
+	f, err := NewHTML("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.AddEntry("first <b>", "1.00", "100.00", "100.00").
+		AddEntry("second", "2.00", "100.00", "200.00").
+		AddSubTotal("SUBTOTAL", "300.00").
+		SetTotal("", "$ 300.00")
+	f.AddAccountDetail("Bank", "ACME Bank")
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf, &InvoiceFields{Date: time.Now(), BillTo: Address{Name: "Client"}, Remarks: "line1\nline2"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"first &lt;b&gt;", "Client", "ACME Bank", "BALANCE DUE", "$ 300.00", "<p>line2</p>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTMLForm.Write() output is missing %q", want)
+		}
+	}
+	if strings.Index(out, "first") > strings.Index(out, "second") {
+		t.Errorf("HTMLForm.Write() entries are out of order")
+	}
+}
+
+func TestHTMLForm_Write_bilingual(t *testing.T) {
+	f, err := NewHTML("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetTotal("", "1,00")
+	var buf bytes.Buffer
+	fields := InvoiceFields{
+		Date:           time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+		Language:       "de",
+		SecondLanguage: "en",
+		Address:        Address{Registration: "HRB 1"},
+	}
+	if err := f.Write(&buf, &fields); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`<html lang="de">`, "RECHNUNG / INVOICE", "31.03.2020", "BESCHREIBUNG / DESCRIPTION", "Handelsreg.-Nr. / Reg. No.: HRB 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTMLForm.Write() output is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,576 @@
+package forms
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultLanguage is the language of the invoice, if none is set.
+const DefaultLanguage = "en"
+
+// bilingualSep separates the labels of the two languages.
+const bilingualSep = " / "
+
+// Label keys.
+const (
+	LabelInvoice        = "invoice"
+	LabelBillTo         = "bill_to"
+	LabelPaymentDetails = "payment_details"
+	LabelTimePeriod     = "time_period"
+	LabelRemarks        = "remarks"
+	LabelNo             = "no"
+	LabelDate           = "date"
+	LabelDue            = "due"
+	LabelPONumber       = "po_number"
+	LabelContract       = "contract"
+	LabelColNo          = "col_no"
+	LabelColDescription = "col_description"
+	LabelColQty         = "col_qty"
+	LabelColPrice       = "col_price"
+	LabelColTotal       = "col_total"
+	LabelSubtotal       = "subtotal"
+	LabelTax            = "tax"
+	LabelShipping       = "shipping"
+	LabelBalanceDue     = "balance_due"
+	LabelBank           = "bank"
+	LabelAccountNo      = "account_no"
+	LabelIBAN           = "iban"
+	LabelBIC            = "bic"
+	LabelRegNo          = "reg_no"
+	LabelScanToPay      = "scan_to_pay"
+	LabelCapped         = "capped"
+	LabelPage           = "page"    // page footer format, i.e. "Page %d of %s"
+	LabelCarried        = "carried" // carried forward subtotal at the bottom of the page
+	LabelBrought        = "brought" // brought forward subtotal at the top of the next page
+
+	// timesheet appendix labels
+	LabelTimesheet  = "timesheet"
+	LabelColDate    = "col_date"
+	LabelColStart   = "col_start"
+	LabelColEnd     = "col_end"
+	LabelColHours   = "col_hours"
+	LabelDayTotal   = "day_total"
+	LabelIssueTotal = "issue_total"
+	LabelTotalHours = "total_hours"
+
+	// status stamp labels
+	LabelDraft = "draft"
+	LabelPaid  = "paid"
+	LabelVoid  = "void"
+
+	// single line invoice description
+	LabelServices = "services"
+
+	// retainer adjustments and remark, format strings
+	LabelRetainer       = "retainer"        // retainer credit, i.e. "RETAINER (%s)"
+	LabelOverage        = "overage"         // overage charge, i.e. "OVERAGE (%s)"
+	LabelRetainerRemark = "retainer_remark" // used and remaining retainer balance
+	labelHours          = "hours"           // hours quantity, i.e. "%s hrs"
+
+	// Swiss QR-bill labels, the standard allows only de, fr, it and en.
+	labelQRReceipt        = "qr_receipt"
+	labelQRPaymentPart    = "qr_payment_part"
+	labelQRAccount        = "qr_account"
+	labelQRReference      = "qr_reference"
+	labelQRAdditional     = "qr_additional"
+	labelQRPayableBy      = "qr_payable_by"
+	labelQRPayableByBlank = "qr_payable_by_blank" // debtor unknown, filled in by hand
+	labelQRCurrency       = "qr_currency"
+	labelQRAmount         = "qr_amount"
+	labelQRAcceptance     = "qr_acceptance"
+)
+
+// catalog is the translation catalog of one language.
+type catalog struct {
+	labels    map[string]string
+	dateFmt   string
+	decimal   string // decimal separator
+	thousands string // thousands separator
+
+	symbolFirst bool // currency symbol precedes the amount
+}
+
+var catalogs = map[string]*catalog{
+	"en": {
+		dateFmt: dateFmt, decimal: ".", symbolFirst: true,
+		labels: map[string]string{
+			LabelInvoice:        "INVOICE",
+			LabelBillTo:         "BILL TO",
+			LabelPaymentDetails: "PAYMENT ACCOUNT DETAILS",
+			LabelTimePeriod:     "Time period:",
+			LabelRemarks:        "Remarks/Payment instructions",
+			LabelNo:             "No.:",
+			LabelDate:           "Date:",
+			LabelDue:            "Due:",
+			LabelPONumber:       "PO No.:",
+			LabelContract:       "Contract:",
+			LabelColNo:          "#",
+			LabelColDescription: "DESCRIPTION",
+			LabelColQty:         "QTY (hrs)",
+			LabelColPrice:       "UNIT PRICE",
+			LabelColTotal:       "TOTAL",
+			LabelSubtotal:       "SUBTOTAL",
+			LabelTax:            "TAX",
+			LabelShipping:       "SHIPPING",
+			LabelBalanceDue:     "BALANCE DUE",
+			LabelBank:           "Bank",
+			LabelAccountNo:      "Account No.",
+			LabelIBAN:           "IBAN",
+			LabelBIC:            "BIC",
+			LabelRegNo:          "Reg. No.",
+			LabelScanToPay:      "Scan to pay",
+			LabelCapped:         "capped",
+			LabelTimesheet:      "TIMESHEET",
+			LabelColDate:        "DATE",
+			LabelColStart:       "START",
+			LabelColEnd:         "END",
+			LabelColHours:       "HOURS",
+			LabelDayTotal:       "Day total",
+			LabelIssueTotal:     "Total",
+			LabelTotalHours:     "Total hours",
+			LabelPage:           "Page %d of %s",
+			LabelCarried:        "Carried forward",
+			LabelBrought:        "Brought forward",
+			LabelDraft:          "DRAFT",
+			LabelPaid:           "PAID",
+			LabelVoid:           "VOID",
+			LabelServices:       "Consulting services",
+			LabelRetainer:       "RETAINER (%s)",
+			LabelOverage:        "OVERAGE (%s)",
+			LabelRetainerRemark: "Retainer: %s used this period / %s remaining.",
+			labelHours:          "%s hrs",
+
+			labelQRReceipt:        "Receipt",
+			labelQRPaymentPart:    "Payment part",
+			labelQRAccount:        "Account / Payable to",
+			labelQRReference:      "Reference",
+			labelQRAdditional:     "Additional information",
+			labelQRPayableBy:      "Payable by",
+			labelQRPayableByBlank: "Payable by (name/address)",
+			labelQRCurrency:       "Currency",
+			labelQRAmount:         "Amount",
+			labelQRAcceptance:     "Acceptance point",
+		},
+	},
+	"de": {
+		dateFmt: "02.01.2006", decimal: ",", thousands: ".",
+		labels: map[string]string{
+			LabelInvoice:        "RECHNUNG",
+			LabelBillTo:         "RECHNUNG AN",
+			LabelPaymentDetails: "BANKVERBINDUNG",
+			LabelTimePeriod:     "Leistungszeitraum:",
+			LabelRemarks:        "Bemerkungen/Zahlungshinweise",
+			LabelNo:             "Nr.:",
+			LabelDate:           "Datum:",
+			LabelDue:            "Fällig:",
+			LabelPONumber:       "Bestell-Nr.:",
+			LabelContract:       "Vertrag:",
+			LabelColNo:          "#",
+			LabelColDescription: "BESCHREIBUNG",
+			LabelColQty:         "MENGE (Std.)",
+			LabelColPrice:       "EINZELPREIS",
+			LabelColTotal:       "GESAMT",
+			LabelSubtotal:       "ZWISCHENSUMME",
+			LabelTax:            "MWST.",
+			LabelShipping:       "VERSAND",
+			LabelBalanceDue:     "ZAHLBETRAG",
+			LabelBank:           "Bank",
+			LabelAccountNo:      "Konto-Nr.",
+			LabelIBAN:           "IBAN",
+			LabelBIC:            "BIC",
+			LabelRegNo:          "Handelsreg.-Nr.",
+			LabelScanToPay:      "Zum Bezahlen scannen",
+			LabelCapped:         "gedeckelt",
+			LabelTimesheet:      "STUNDENNACHWEIS",
+			LabelColDate:        "DATUM",
+			LabelColStart:       "BEGINN",
+			LabelColEnd:         "ENDE",
+			LabelColHours:       "STUNDEN",
+			LabelDayTotal:       "Tagessumme",
+			LabelIssueTotal:     "Summe",
+			LabelTotalHours:     "Gesamtstunden",
+			LabelPage:           "Seite %d von %s",
+			LabelCarried:        "Übertrag",
+			LabelBrought:        "Übertrag",
+			LabelDraft:          "ENTWURF",
+			LabelPaid:           "BEZAHLT",
+			LabelVoid:           "STORNIERT",
+			LabelServices:       "Beratungsleistungen",
+			LabelRetainer:       "VORAUSZAHLUNG (%s)",
+			LabelOverage:        "MEHRAUFWAND (%s)",
+			LabelRetainerRemark: "Vorauszahlung: %s in diesem Zeitraum verbraucht / %s verbleibend.",
+			labelHours:          "%s Std.",
+
+			labelQRReceipt:        "Empfangsschein",
+			labelQRPaymentPart:    "Zahlteil",
+			labelQRAccount:        "Konto / Zahlbar an",
+			labelQRReference:      "Referenz",
+			labelQRAdditional:     "Zusätzliche Informationen",
+			labelQRPayableBy:      "Zahlbar durch",
+			labelQRPayableByBlank: "Zahlbar durch (Name/Adresse)",
+			labelQRCurrency:       "Währung",
+			labelQRAmount:         "Betrag",
+			labelQRAcceptance:     "Annahmestelle",
+		},
+	},
+	"fr": {
+		dateFmt: "02/01/2006", decimal: ",", thousands: " ",
+		labels: map[string]string{
+			LabelInvoice:        "FACTURE",
+			LabelBillTo:         "FACTURER À",
+			LabelPaymentDetails: "COORDONNÉES BANCAIRES",
+			LabelTimePeriod:     "Période :",
+			LabelRemarks:        "Remarques/Instructions de paiement",
+			LabelNo:             "N° :",
+			LabelDate:           "Date :",
+			LabelDue:            "Échéance :",
+			LabelPONumber:       "N° de commande :",
+			LabelContract:       "Contrat :",
+			LabelColNo:          "#",
+			LabelColDescription: "DÉSIGNATION",
+			LabelColQty:         "QTÉ (h)",
+			LabelColPrice:       "PRIX UNITAIRE",
+			LabelColTotal:       "TOTAL",
+			LabelSubtotal:       "SOUS-TOTAL",
+			LabelTax:            "TVA",
+			LabelShipping:       "LIVRAISON",
+			LabelBalanceDue:     "NET À PAYER",
+			LabelBank:           "Banque",
+			LabelAccountNo:      "N° de compte",
+			LabelIBAN:           "IBAN",
+			LabelBIC:            "BIC",
+			LabelRegNo:          "N° d'immatriculation",
+			LabelScanToPay:      "Scanner pour payer",
+			LabelCapped:         "plafonné",
+			LabelTimesheet:      "RELEVÉ D'HEURES",
+			LabelColDate:        "DATE",
+			LabelColStart:       "DÉBUT",
+			LabelColEnd:         "FIN",
+			LabelColHours:       "HEURES",
+			LabelDayTotal:       "Total du jour",
+			LabelIssueTotal:     "Total",
+			LabelTotalHours:     "Total des heures",
+			LabelPage:           "Page %d sur %s",
+			LabelCarried:        "À reporter",
+			LabelBrought:        "Report",
+			LabelDraft:          "BROUILLON",
+			LabelPaid:           "PAYÉE",
+			LabelVoid:           "ANNULÉE",
+			LabelServices:       "Prestations de conseil",
+			LabelRetainer:       "PROVISION (%s)",
+			LabelOverage:        "DÉPASSEMENT (%s)",
+			LabelRetainerRemark: "Provision : %s utilisé sur la période / %s restant.",
+			labelHours:          "%s h",
+
+			labelQRReceipt:        "Récépissé",
+			labelQRPaymentPart:    "Section paiement",
+			labelQRAccount:        "Compte / Payable à",
+			labelQRReference:      "Référence",
+			labelQRAdditional:     "Informations supplémentaires",
+			labelQRPayableBy:      "Payable par",
+			labelQRPayableByBlank: "Payable par (nom/adresse)",
+			labelQRCurrency:       "Monnaie",
+			labelQRAmount:         "Montant",
+			labelQRAcceptance:     "Point de dépôt",
+		},
+	},
+	"es": {
+		dateFmt: "02/01/2006", decimal: ",", thousands: ".",
+		labels: map[string]string{
+			LabelInvoice:        "FACTURA",
+			LabelBillTo:         "FACTURAR A",
+			LabelPaymentDetails: "DATOS BANCARIOS",
+			LabelTimePeriod:     "Período:",
+			LabelRemarks:        "Observaciones/Instrucciones de pago",
+			LabelNo:             "N.º:",
+			LabelDate:           "Fecha:",
+			LabelDue:            "Vencimiento:",
+			LabelPONumber:       "N.º de pedido:",
+			LabelContract:       "Contrato:",
+			LabelColNo:          "#",
+			LabelColDescription: "DESCRIPCIÓN",
+			LabelColQty:         "CANT. (h)",
+			LabelColPrice:       "PRECIO UNITARIO",
+			LabelColTotal:       "TOTAL",
+			LabelSubtotal:       "SUBTOTAL",
+			LabelTax:            "IMPUESTOS",
+			LabelShipping:       "ENVÍO",
+			LabelBalanceDue:     "TOTAL A PAGAR",
+			LabelBank:           "Banco",
+			LabelAccountNo:      "N.º de cuenta",
+			LabelIBAN:           "IBAN",
+			LabelBIC:            "BIC",
+			LabelRegNo:          "N.º de registro",
+			LabelScanToPay:      "Escanear para pagar",
+			LabelCapped:         "con tope",
+			LabelTimesheet:      "PARTE DE HORAS",
+			LabelColDate:        "FECHA",
+			LabelColStart:       "INICIO",
+			LabelColEnd:         "FIN",
+			LabelColHours:       "HORAS",
+			LabelDayTotal:       "Total del día",
+			LabelIssueTotal:     "Total",
+			LabelTotalHours:     "Total de horas",
+			LabelPage:           "Página %d de %s",
+			LabelCarried:        "Suma y sigue",
+			LabelBrought:        "Suma anterior",
+			LabelDraft:          "BORRADOR",
+			LabelPaid:           "PAGADA",
+			LabelVoid:           "ANULADA",
+			LabelServices:       "Servicios de consultoría",
+			LabelRetainer:       "ANTICIPO (%s)",
+			LabelOverage:        "EXCEDENTE (%s)",
+			LabelRetainerRemark: "Anticipo: %s utilizado en el periodo / %s restante.",
+			labelHours:          "%s h",
+		},
+	},
+	"ru": {
+		dateFmt: "02.01.2006", decimal: ",", thousands: " ",
+		labels: map[string]string{
+			LabelInvoice:        "СЧЁТ",
+			LabelBillTo:         "ПЛАТЕЛЬЩИК",
+			LabelPaymentDetails: "ПЛАТЁЖНЫЕ РЕКВИЗИТЫ",
+			LabelTimePeriod:     "Период:",
+			LabelRemarks:        "Примечания/Порядок оплаты",
+			LabelNo:             "№:",
+			LabelDate:           "Дата:",
+			LabelDue:            "Оплатить до:",
+			LabelPONumber:       "Заказ №:",
+			LabelContract:       "Договор:",
+			LabelColNo:          "№",
+			LabelColDescription: "ОПИСАНИЕ",
+			LabelColQty:         "КОЛ-ВО (ч)",
+			LabelColPrice:       "ЦЕНА",
+			LabelColTotal:       "СУММА",
+			LabelSubtotal:       "ИТОГО",
+			LabelTax:            "НАЛОГ",
+			LabelShipping:       "ДОСТАВКА",
+			LabelBalanceDue:     "К ОПЛАТЕ",
+			LabelBank:           "Банк",
+			LabelAccountNo:      "Счёт №",
+			LabelIBAN:           "IBAN",
+			LabelBIC:            "БИК",
+			LabelRegNo:          "Рег. №",
+			LabelScanToPay:      "Отсканируйте для оплаты",
+			LabelCapped:         "ограничено",
+			LabelTimesheet:      "ТАБЕЛЬ",
+			LabelColDate:        "ДАТА",
+			LabelColStart:       "НАЧАЛО",
+			LabelColEnd:         "КОНЕЦ",
+			LabelColHours:       "ЧАСЫ",
+			LabelDayTotal:       "Итого за день",
+			LabelIssueTotal:     "Итого",
+			LabelTotalHours:     "Всего часов",
+			LabelPage:           "Страница %d из %s",
+			LabelCarried:        "Перенос",
+			LabelBrought:        "Перенесено",
+			LabelDraft:          "ЧЕРНОВИК",
+			LabelPaid:           "ОПЛАЧЕН",
+			LabelVoid:           "АННУЛИРОВАН",
+			LabelServices:       "Консультационные услуги",
+			LabelRetainer:       "АВАНС (%s)",
+			LabelOverage:        "ПЕРЕРАСХОД (%s)",
+			LabelRetainerRemark: "Аванс: использовано %s за период / остаток %s.",
+			labelHours:          "%s ч",
+		},
+	},
+}
+
+// Locale provides the invoice labels, and date and number formatting in the
+// invoice language.  Bilingual locale has the second language labels
+// following the primary language labels.
+type Locale struct {
+	primary   *catalog
+	secondary *catalog
+}
+
+// Languages returns the supported languages.
+func Languages() []string {
+	return []string{"de", "en", "es", "fr", "ru"}
+}
+
+// NewLocale returns the locale for the language, and the optional second
+// language for bilingual invoices.  Empty language is the default language.
+func NewLocale(lang, second string) (*Locale, error) {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	var l Locale
+	var err error
+	if l.primary, err = lookupCatalog(lang); err != nil {
+		return nil, err
+	}
+	if second != "" && !strings.EqualFold(second, lang) {
+		if l.secondary, err = lookupCatalog(second); err != nil {
+			return nil, err
+		}
+	}
+	return &l, nil
+}
+
+func lookupCatalog(lang string) (*catalog, error) {
+	c, ok := catalogs[strings.ToLower(lang)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language: %q, supported: %s", lang, strings.Join(Languages(), ", "))
+	}
+	return c, nil
+}
+
+// Label returns the label, if the locale is bilingual, labels of both
+// languages are joined.
+func (l *Locale) Label(key string) string {
+	primary, secondary := l.Labels(key)
+	if secondary == "" {
+		return primary
+	}
+	return primary + bilingualSep + secondary
+}
+
+// page returns the page footer text of page n of total pages.
+func (l *Locale) page(n int, total string) string {
+	return l.Format(LabelPage, n, total)
+}
+
+// Format formats the arguments with the format string label of the key, in
+// both languages of the bilingual locale.
+func (l *Locale) Format(key string, a ...interface{}) string {
+	primary, secondary := l.Labels(key)
+	s := fmt.Sprintf(primary, a...)
+	if secondary != "" {
+		s += bilingualSep + fmt.Sprintf(secondary, a...)
+	}
+	return s
+}
+
+// parseNumber parses the number formatted with Number.  Any prefix before
+// the number, i.e. a currency symbol, is ignored.  Numbers in other formats
+// are rejected, as separators would be misread.
+func (l *Locale) parseNumber(s string) (decimal.Decimal, error) {
+	num := strings.TrimLeftFunc(s, func(r rune) bool {
+		return r != '-' && (r < '0' || r > '9')
+	})
+	plain := num
+	if sep := l.primary.thousands; sep != "" {
+		plain = strings.Replace(plain, sep, "", -1)
+	}
+	plain = strings.Replace(plain, l.primary.decimal, ".", 1)
+	d, err := decimal.NewFromString(plain)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if l.Number(d) != num {
+		return decimal.Zero, fmt.Errorf("number %q is not in the locale format", s)
+	}
+	return d, nil
+}
+
+// Labels returns the label in the primary language and in the second
+// language, or empty string, if the locale is not bilingual, or the label is
+// the same in both languages.
+func (l *Locale) Labels(key string) (primary, secondary string) {
+	primary = l.primary.label(key)
+	if l.secondary != nil {
+		if s := l.secondary.label(key); s != primary {
+			secondary = s
+		}
+	}
+	return primary, secondary
+}
+
+// label returns the label, falling back to the default language.
+func (c *catalog) label(key string) string {
+	if s, ok := c.labels[key]; ok {
+		return s
+	}
+	if s, ok := catalogs[DefaultLanguage].labels[key]; ok {
+		return s
+	}
+	return key
+}
+
+// Date formats the date in the primary language format.
+func (l *Locale) Date(t time.Time) string {
+	return t.Format(l.primary.dateFmt)
+}
+
+// Number formats the number rounded to 2 decimal places, with the primary
+// language decimal and thousands separators.
+func (l *Locale) Number(d decimal.Decimal) string {
+	s := d.StringFixedBank(2)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s[:len(s)-3], s[len(s)-2:]
+	if sep := l.primary.thousands; sep != "" {
+		var buf strings.Builder
+		for i, r := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				buf.WriteString(sep)
+			}
+			buf.WriteRune(r)
+		}
+		intPart = buf.String()
+	}
+	return sign + intPart + l.primary.decimal + frac
+}
+
+// currencySymbols are the symbols of the common currencies, other currencies
+// are shown with the ISO 4217 code.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// Amount formats the amount with the currency symbol or code.  The symbol
+// precedes the amount in english, and follows it in other languages.
+func (l *Locale) Amount(d decimal.Decimal, currency string) string {
+	sym, ok := currencySymbols[strings.ToUpper(currency)]
+	if !ok {
+		sym = strings.ToUpper(currency)
+	}
+	if l.primary.symbolFirst {
+		return sym + " " + l.Number(d)
+	}
+	return l.Number(d) + " " + sym
+}
+
+// Hours formats the hours quantity in the primary language, i.e. "2.50 hrs".
+func (l *Locale) Hours(d decimal.Decimal) string {
+	return fmt.Sprintf(l.primary.label(labelHours), l.Number(d))
+}
+
+// columnKeys are the table column label keys.
+var columnKeys = []string{LabelColNo, LabelColDescription, LabelColQty, LabelColPrice, LabelColTotal}
+
+// columns returns the column headers of the table with the keys in the
+// primary and the second language, secondary is nil, if the locale is not
+// bilingual.
+func (l *Locale) columns(keys []string) (primary, secondary []string) {
+	primary = make([]string, len(keys))
+	if l.secondary != nil {
+		secondary = make([]string, len(keys))
+	}
+	for i, key := range keys {
+		if secondary != nil {
+			primary[i], secondary[i] = l.Labels(key)
+		} else {
+			primary[i], _ = l.Labels(key)
+		}
+	}
+	return primary, secondary
+}
+
+// labels returns all labels, keyed by the label key.
+func (l *Locale) labels() map[string]string {
+	m := make(map[string]string, len(catalogs[DefaultLanguage].labels))
+	for key := range catalogs[DefaultLanguage].labels {
+		m[key] = l.Label(key)
+	}
+	return m
+}
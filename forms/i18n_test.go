@@ -0,0 +1,138 @@
+package forms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNewLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		second  string
+		wantErr bool
+	}{
+		{"default", "", "", false},
+		{"german", "DE", "", false},
+		{"bilingual", "de", "en", false},
+		{"unsupported", "xx", "", true},
+		{"unsupported second", "en", "xx", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLocale(tt.lang, tt.second); (err != nil) != tt.wantErr {
+				t.Errorf("NewLocale() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocale_Label(t *testing.T) {
+	tests := []struct {
+		name   string
+		lang   string
+		second string
+		key    string
+		want   string
+	}{
+		{"english", "en", "", LabelBillTo, "BILL TO"},
+		{"french", "fr", "", LabelInvoice, "FACTURE"},
+		{"bilingual", "de", "en", LabelInvoice, "RECHNUNG / INVOICE"},
+		{"bilingual same", "de", "en", LabelIBAN, "IBAN"},
+		{"same language", "en", "EN", LabelInvoice, "INVOICE"},
+		{"fallback", "es", "", labelQRReceipt, "Receipt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewLocale(tt.lang, tt.second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.Label(tt.key); got != tt.want {
+				t.Errorf("Locale.Label() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_Number(t *testing.T) {
+	tests := []struct {
+		lang string
+		val  string
+		want string
+	}{
+		{"en", "1234567.891", "1234567.89"},
+		{"de", "1234567.891", "1.234.567,89"},
+		{"fr", "-1234.5", "-1 234,50"},
+		{"ru", "123", "123,00"},
+		{"es", "0.125", "0,12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			l, _ := NewLocale(tt.lang, "")
+			if got := l.Number(decimal.RequireFromString(tt.val)); got != tt.want {
+				t.Errorf("Locale.Number() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_parseNumber(t *testing.T) {
+	tests := []struct {
+		lang    string
+		val     string
+		want    string
+		wantErr bool
+	}{
+		{"en", "1234567.89", "1234567.89", false},
+		{"en", "$ 1234.50", "1234.5", false},
+		{"de", "1.234.567,89", "1234567.89", false},
+		{"de", "-12,00", "-12", false},
+		{"de", "1000.00", "", true},
+		{"en", "n/a", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang+" "+tt.val, func(t *testing.T) {
+			l, _ := NewLocale(tt.lang, "")
+			got, err := l.parseNumber(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Locale.parseNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("Locale.parseNumber() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_Date(t *testing.T) {
+	d := time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	for lang, want := range map[string]string{"en": "31/03/2020", "de": "31.03.2020", "ru": "31.03.2020"} {
+		l, _ := NewLocale(lang, "")
+		if got := l.Date(d); got != want {
+			t.Errorf("%s: Locale.Date() = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestLocale_Amount(t *testing.T) {
+	tests := []struct {
+		lang     string
+		currency string
+		want     string
+	}{
+		{"en", "USD", "$ 1234.50"},
+		{"de", "EUR", "1.234,50 €"},
+		{"fr", "chf", "1 234,50 CHF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			l, _ := NewLocale(tt.lang, "")
+			if got := l.Amount(decimal.RequireFromString("1234.5"), tt.currency); got != tt.want {
+				t.Errorf("Locale.Amount() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
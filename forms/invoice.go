@@ -3,10 +3,14 @@ package forms
 import (
 	"fmt"
 	"image/color"
+	"io"
+	"math"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
 )
 
 // measurements
@@ -24,6 +28,9 @@ const (
 
 	// image
 	imgW = 18.00 //mm
+
+	// total number of pages alias
+	nbPages = "{nb}"
 )
 
 //dimensions
@@ -33,9 +40,8 @@ const (
 	keyValueSz = 2 // key: value pair array size.
 )
 
-// table columns
+// table column widths
 var (
-	tabCol   = []string{"#", "DESCRIPTION", "QTY (hrs)", "UNIT PRICE", "TOTAL"}
 	tabColPc = []float64{0.05, 0.55, 0.15, 0.15, 0.10}
 )
 
@@ -47,25 +53,49 @@ type InvoiceFields struct {
 	PeriodStart time.Time `yaml:"period_start"`
 	PeriodEnd   time.Time `yaml:"period_end"`
 
-	Bank    string
-	Account string
+	Bank    string `yaml:",omitempty"`
+	Account string `yaml:",omitempty"`
+	IBAN    string `yaml:"iban,omitempty"` // international bank account number, used in payment codes
+	BIC     string `yaml:"bic,omitempty"`  // bank identifier code
+
+	PaymentCode string `yaml:"payment_code,omitempty"` // payment code to print: "epc" (GiroCode) or "swissqr" (Swiss QR-bill)
+
+	PONumber   string `yaml:"po_number,omitempty"`   // client purchase order number
+	ContractID string `yaml:"contract_id,omitempty"` // contract or statement of work reference
 
-	Address Address
+	Language       string `yaml:"language,omitempty"`        // invoice language, i.e. "de", default is "en"
+	SecondLanguage string `yaml:"second_language,omitempty"` // second language of the bilingual invoice
+
+	Address Address `yaml:",omitempty"`
 	BillTo  Address `yaml:"bill_to"`
 
-	Image string
+	Image     string `yaml:",omitempty"`           // logo file or data URI, i.e. "data:image/svg+xml;base64,..."
+	ImageData string `yaml:"image_data,omitempty"` // base64 encoded logo image, PNG, JPEG, GIF or SVG
+	Logo      *Logo  `yaml:"-"`                    // logo image, overrides Image and ImageData
 
 	Remarks string // note at the end of the invoice
 
+	Status string `yaml:"status,omitempty"` // invoice status: "draft", "paid" or "void", printed as the stamp
+	Stamp  *Stamp `yaml:"stamp,omitempty"`  // stamp text, colour and kind, default is set by the status
+
+	Keywords []string `yaml:"keywords,omitempty"` // custom keywords of the PDF document information
+
 }
 
 // InvoiceForm is the form
 type InvoiceForm struct {
-	pdf       *gofpdf.Fpdf
-	invoiceID string
+	Content
 
-	m Margins
-	s Style
+	pdf *gofpdf.Fpdf
+
+	m   Margins
+	s   Style
+	loc *Locale
+
+	fonts         map[string]*fontFace // registered font families
+	family, style string               // current font family and style
+	cp1252        func(string) string  // core font text translator
+	slip          bool                 // payment slip is drawn on the current page
 
 	//
 	// +-----------+
@@ -81,19 +111,27 @@ type InvoiceForm struct {
 
 	maxX, maxY float64 // page max X, max Y
 	w, h       float64 // text area width, height
-
-	// page elements
-	account   [][keyValueSz]string
-	entries   [][entrySz]string
-	subTotals [][totalSz]string
-	total     [totalSz]string
 }
 
 // NewInvoice creates an invoice form.
 func NewInvoice(invoiceID string, sizeStr string, m *Margins, s *Style) *InvoiceForm {
-	if invoiceID == "" {
-		invoiceID = time.Now().Format(defInvoiceNoFmt)
+	return newInvoice(invoiceID, sizeStr, orientPortrait, m, s)
+}
+
+// NewInvoiceTheme creates an invoice form with the theme.  Nil theme selects
+// the default theme.
+func NewInvoiceTheme(invoiceID string, t *Theme) (*InvoiceForm, error) {
+	if t == nil {
+		t = &Theme{}
+	}
+	s, m, err := t.resolve()
+	if err != nil {
+		return nil, err
 	}
+	return newInvoice(invoiceID, t.PageSize, t.orientation(), m, s), nil
+}
+
+func newInvoice(invoiceID string, sizeStr string, orientation string, m *Margins, s *Style) *InvoiceForm {
 	if sizeStr == "" {
 		sizeStr = PgA4
 	}
@@ -103,18 +141,19 @@ func NewInvoice(invoiceID string, sizeStr string, m *Margins, s *Style) *Invoice
 	if s == nil {
 		s = &defStyle
 	}
-	pdf := gofpdf.New("P", "mm", sizeStr, "")
+	pdf := gofpdf.New(orientation, "mm", sizeStr, "")
 	pdf.SetMargins(m.Left, m.Top, m.Right)
 	pdf.SetAutoPageBreak(true, m.Bottom)
-	pdf.AddPage()
+	pdf.AliasNbPages(nbPages)
 
 	maxX, maxY := pdf.GetPageSize()
 
 	inv := &InvoiceForm{
-		pdf:       pdf,
-		invoiceID: invoiceID,
-		m:         *m, // margins
-		s:         *s, // style
+		Content: newContent(invoiceID),
+
+		pdf: pdf,
+		m:   *m, // margins
+		s:   *s, // style
 
 		// dimensions
 		maxX: maxX,
@@ -122,18 +161,51 @@ func NewInvoice(invoiceID string, sizeStr string, m *Margins, s *Style) *Invoice
 		w:    maxX - (m.Left + m.Right),
 		h:    maxY - (m.Bottom + m.Top),
 
-		// page elements
-		account:   make([][keyValueSz]string, 0),
-		entries:   make([][entrySz]string, 0),
-		subTotals: make([][totalSz]string, 0),
+		cp1252: pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+	if len(inv.s.Columns) != len(tabColPc) {
+		inv.s.Columns = tabColPc
+	}
+	if inv.s.Logo.Width == 0 && inv.s.Logo.Height == 0 {
+		inv.s.Logo.Width = imgW
 	}
+	inv.registerFonts()
 
-	inv.line(inv.s.AccentColor, lThick, 0, 0, inv.maxY, 0)
-	inv.line(inv.s.AccentColor, lThick, 0, inv.maxY, inv.maxX, inv.maxY)
+	pdf.SetHeaderFuncMode(inv.decorate, true)
+	pdf.SetFooterFunc(inv.footer)
+	pdf.AddPage()
 
 	return inv
 }
 
+// decorate draws the page decorations, it is called on each new page.
+func (f *InvoiceForm) decorate() {
+	f.slip = false
+	f.line(f.s.AccentColor, lThick, 0, 0, f.maxY, 0)
+	f.line(f.s.AccentColor, lThick, 0, f.maxY, f.maxX, f.maxY)
+}
+
+// footer prints the page number in the bottom margin, it is called at the
+// end of each page.
+func (f *InvoiceForm) footer() {
+	if f.loc == nil || f.slip {
+		// not started writing, or the payment slip occupies the bottom of the page
+		return
+	}
+	family, style := f.family, f.style
+	defer func() { f.family, f.style = family, style }() // gofpdf restores the font
+
+	f.setFont(f.s.Body.FamilyStr, "", f.s.Body.Size-2)
+	f.pdf.SetTextColor(f.s.Body.fg())
+	f.pdf.SetXY(f.m.Left, f.maxY-(f.m.Bottom+defCellHeight)/2)
+	f.cell(f.w, defCellHeight, f.loc.page(f.pdf.PageNo(), nbPages), "", 0, "C", false, 0, "")
+}
+
+// pageBottom returns the Y of the page break.
+func (f *InvoiceForm) pageBottom() float64 {
+	return f.maxY - f.m.Bottom
+}
+
 // Fpdf returns underlying Fpdf instance.
 func (f *InvoiceForm) Fpdf() *gofpdf.Fpdf {
 	return f.pdf
@@ -154,12 +226,12 @@ func (f *InvoiceForm) line(col color.RGBA, width, x0, y0, x1, y1 float64) {
 
 func (f *InvoiceForm) cell(cw, ch float64, str, borderStr string, ln int, alignStr string, fill bool, link int, linkStr string) {
 	if cw == 0 {
-		cw = f.pdf.GetStringWidth(str)
+		cw = f.width(str)
 	}
 	if ch == 0 {
 		_, ch = f.pdf.GetFontSize()
 	}
-	f.pdf.CellFormat(cw, ch, str, borderStr, ln, alignStr, fill, link, linkStr)
+	f.pdf.CellFormat(cw, ch, f.text(str), borderStr, ln, alignStr, fill, link, linkStr)
 }
 
 // writeAt returns the writer at X with cell width of cw x ch
@@ -178,12 +250,12 @@ func (f *InvoiceForm) heading5(x, y, lineSz float64, str string) {
 	var cy = f.pdf.PointConvert(pt)
 	oldColor := fromRGB(f.pdf.GetTextColor())
 	f.pdf.SetXY(x, y)
-	f.pdf.SetFont(f.s.Heading.font())
+	f.setFont(f.s.Heading.font())
 	f.pdf.SetTextColor(f.s.Heading.fg())
 	f.cell(0, cy, str, "0", 0, "L", false, 0, "")
 	f.pdf.Ln(-1)
 	if lineSz == 0.0 {
-		lineSz = f.pdf.GetStringWidth(str)
+		lineSz = f.width(str)
 	}
 	f.line(cBlack, lThin, x, f.pdf.GetY()-offset, x+lineSz, f.pdf.GetY()-offset)
 	f.pdf.Ln(cy + lThin)
@@ -194,7 +266,7 @@ func (f *InvoiceForm) printAddrAt(x, y float64, addr Address) {
 	var writeln = f.writeAt(x, 0.0, f.pdf.PointConvert(f.s.Address.Size)*f.s.LineSpacing, "", 1, "L", false, 0, "")
 
 	f.pdf.SetXY(x, y)
-	f.pdf.SetFont(f.s.Address.FamilyStr, "B", f.s.Address.Size)
+	f.setFont(f.s.Address.FamilyStr, "B", f.s.Address.Size)
 	f.pdf.SetTextColor(toRGB(f.s.Address.Foreground))
 	if addr.Name != "" {
 		writeln(addr.Name)
@@ -202,12 +274,15 @@ func (f *InvoiceForm) printAddrAt(x, y float64, addr Address) {
 	if addr.Organisation != "" {
 		writeln(addr.Organisation)
 	}
-	f.pdf.SetFont("", "", 0)
+	f.setFont("", "", 0)
 	writeln(nvljoin([]string{addr.Floor, addr.Street}, delim))
 	writeln(strings.Join([]string{addr.Suburb, addr.Town, addr.Postcode}, delim))
 	f.pdf.Ln(f.pdf.PointConvert(f.s.Address.Size+1) / 2)
 	writeln(addr.Phone)
 	writeln(addr.Email)
+	for _, line := range addr.legalLines(f.loc) {
+		writeln(line)
+	}
 }
 
 // keyValuesAt prints formatted key values val at x,y.
@@ -218,7 +293,7 @@ func (f *InvoiceForm) keyValuesAt(x, y float64, val [][keyValueSz]string, attr [
 	for row := range val {
 		for col := range val[row] {
 			curSz := colsSz[col]
-			if newSz := f.pdf.GetStringWidth(val[row][col]); newSz > curSz {
+			if newSz := f.width(val[row][col]); newSz > curSz {
 				colsSz[col] = newSz
 			}
 		}
@@ -227,7 +302,7 @@ func (f *InvoiceForm) keyValuesAt(x, y float64, val [][keyValueSz]string, attr [
 	for row := range val {
 		f.pdf.SetX(x)
 		for col, str := range val[row] {
-			f.pdf.SetFont("", attr[col], 0)
+			f.setFont("", attr[col], 0)
 			f.cell(colsSz[col], cellH, str, "", 0, align[col], false, 0, "")
 		}
 		f.pdf.Ln(-1)
@@ -236,26 +311,55 @@ func (f *InvoiceForm) keyValuesAt(x, y float64, val [][keyValueSz]string, attr [
 
 func (f *InvoiceForm) title(x, y float64) (lastX, lastY float64) {
 	f.pdf.MoveTo(x, y)
-	f.pdf.SetFont(f.s.Title.font())
+	f.setFont(f.s.Title.font())
 	f.pdf.SetTextColor(f.s.Title.fg())
 	// CellFormat(width, height, text, border, position after, align, fill, link, linkStr)
-	f.cell(190, 7, "INVOICE", "0", 0, "LM", false, 0, "")
+	f.cell(190, 7, f.loc.Label(LabelInvoice), "0", 0, "LM", false, 0, "")
 	f.pdf.Ln(-1)
 	// f.line(cBlack, lThin, x, f.pdf.GetY(), f.w+x, f.pdf.GetY())
 	return f.pdf.GetXY()
 }
 
-// Generate generates a PDF file
+// Generate generates a PDF file.
 func (f *InvoiceForm) Generate(filename string, fields *InvoiceFields) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := f.Write(out, fields); err != nil {
+		out.Close()
+		os.Remove(filename)
+		return err
+	}
+	return out.Close()
+}
+
+// Write generates the PDF and writes it to w.
+func (f *InvoiceForm) Write(w io.Writer, fields *InvoiceFields) error {
 	val := *fields
 
+	loc, err := NewLocale(val.Language, val.SecondLanguage)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	f.loc = loc
+	stamp, err := val.stamp(loc)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	logo, err := val.logo()
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	f.setMetadata(f.metadata(&val, loc))
+
 	// HEADER
 	f.title(f.m.Left, f.m.Top)
 
-	f.imageAt(f.maxX-f.m.Right-imgW, f.m.Top, imgW, 0, val.Image)
+	f.logo(logo)
 
 	// invoice details
-	f.invoiceData(f.maxX-f.m.Right-(f.w*0.18), f.m.Top+addressOffsetY+defCellHeight, f.invoiceID, val.Date, val.Due)
+	f.invoiceData(f.maxX-f.m.Right-(f.w*0.18), f.m.Top+addressOffsetY+defCellHeight, f.invoiceID, &val)
 
 	// Address
 	x, y := f.address(f.m.Left, f.m.Top+addressOffsetY, val.Address)
@@ -268,29 +372,64 @@ func (f *InvoiceForm) Generate(filename string, fields *InvoiceFields) error {
 	x, y = f.timePeriod(f.m.Left, y+defCellHeight, val.PeriodStart, val.PeriodEnd)
 
 	// Table
-	_, tabY := f.table(x, y)
+	_, tabY := f.table(x, y, f.remarksHeight(val.Remarks))
+	totY := f.pdf.GetY()
 
 	// Remarks
-	f.remarks(f.m.Left, tabY, f.w*(tabColPc[1]), val.Remarks)
+	_, remY := f.remarks(f.m.Left, tabY, f.w*(f.s.Columns[1]), val.Remarks)
+
+	// Payment code
+	if _, err := f.paymentCode(math.Max(totY, remY), &val); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+
+	// Timesheet appendix
+	f.timesheet()
 
-	return f.pdf.OutputFileAndClose(filename)
+	// Status stamp
+	f.stamp(stamp)
+
+	if err := f.pdf.Error(); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	return f.pdf.Output(w)
 }
 
-func (f *InvoiceForm) imageAt(x, y, w, h float64, filename string) (lastX, lastY float64) {
-	f.pdf.ImageOptions(filename, x, y, w, h, false, gofpdf.ImageOptions{}, 0, "")
-	return f.pdf.GetXY()
+// logoX returns the X position of the logo of width w, by default the logo
+// is aligned to the right margin.
+func (f *InvoiceForm) logoX(w float64) float64 {
+	if f.s.Logo.X != 0 {
+		return f.s.Logo.X
+	}
+	return f.maxX - f.m.Right - w
+}
+
+// logoY returns the logo Y position, by default the logo is aligned to the
+// top margin.
+func (f *InvoiceForm) logoY() float64 {
+	if f.s.Logo.Y != 0 {
+		return f.s.Logo.Y
+	}
+	return f.m.Top
 }
 
-func (f *InvoiceForm) invoiceData(x, y float64, id string, date, due time.Time) (lastX, lastY float64) {
+func (f *InvoiceForm) invoiceData(x, y float64, id string, val *InvoiceFields) (lastX, lastY float64) {
 	f.pdf.SetTextColor(f.s.Body.fg())
-	f.pdf.SetFont(f.s.Body.font())
+	f.setFont(f.s.Body.font())
+	data := [][keyValueSz]string{
+		{f.loc.Label(LabelNo), id},
+		{f.loc.Label(LabelDate), f.loc.Date(val.Date)},
+		{"", ""},
+		{f.loc.Label(LabelDue) + " ", f.loc.Date(val.Due)},
+	}
+	if val.PONumber != "" {
+		data = append(data, [keyValueSz]string{f.loc.Label(LabelPONumber) + " ", val.PONumber})
+	}
+	if val.ContractID != "" {
+		data = append(data, [keyValueSz]string{f.loc.Label(LabelContract) + " ", val.ContractID})
+	}
 	f.keyValuesAt(x, y,
-		[][keyValueSz]string{
-			{"No.:", id},
-			{"Date:", date.Format(dateFmt)},
-			{"", ""},
-			{"Due: ", due.Format(dateFmt)},
-		},
+		data,
 		[keyValueSz]string{"", "B"}, [keyValueSz]string{"R", "L"},
 	)
 	return f.pdf.GetXY()
@@ -303,13 +442,13 @@ func (f *InvoiceForm) address(x, y float64, addr Address) (lastX, lastY float64)
 }
 
 func (f *InvoiceForm) billToAddress(x, y float64, addr Address) (lastX, lastY float64) {
-	f.heading5(x, y, 60.0, "BILL TO")
+	f.heading5(x, y, 60.0, f.loc.Label(LabelBillTo))
 	f.printAddrAt(x, f.pdf.GetY(), addr)
 	return f.pdf.GetXY()
 }
 
 func (f *InvoiceForm) accountDetails(x, y float64) (lastX, lastY float64) {
-	f.heading5(x, y, 60.0, "PAYMENT ACCOUNT DETAILS")
+	f.heading5(x, y, 60.0, f.loc.Label(LabelPaymentDetails))
 	f.keyValuesAt(x, f.pdf.GetY(),
 		f.account,
 		[keyValueSz]string{"", "B"}, [keyValueSz]string{"L", "L"},
@@ -318,51 +457,60 @@ func (f *InvoiceForm) accountDetails(x, y float64) (lastX, lastY float64) {
 }
 
 func (f *InvoiceForm) timePeriod(x, y float64, periodStart, periodEnd time.Time) (lastX, lastY float64) {
-	f.pdf.SetFont(f.s.Body.font())
+	f.setFont(f.s.Body.font())
 	period := fmt.Sprintf("%s - %s",
-		periodStart.Format(dateFmt),
-		periodEnd.Format(dateFmt),
+		f.loc.Date(periodStart),
+		f.loc.Date(periodEnd),
 	)
 	f.keyValuesAt(x, y,
 		[][keyValueSz]string{
-			{"Time period: ", period},
+			{f.loc.Label(LabelTimePeriod) + " ", period},
 		},
 		[keyValueSz]string{"", "B"}, [keyValueSz]string{"L", "L"})
 	f.pdf.Ln(0.5)
 	return f.pdf.GetXY()
 }
 
-func (f *InvoiceForm) table(x, y float64) (lastX, lastY float64) {
+// table prints the invoice lines and totals.  If the lines don't fit on the
+// page, the table continues on the next page with the header row and the
+// carried forward subtotal.  The totals are kept on the same page with the
+// last line and the block of keepH height, that is printed next to them.
+func (f *InvoiceForm) table(x, y float64, keepH float64) (lastX, lastY float64) {
 
 	const tabCellH = defCellHeight
 
 	f.pdf.SetXY(x, y)
-	fillColor := fromRGB(f.pdf.GetFillColor())
-	f.pdf.SetFont(f.s.Heading.font())
-	f.pdf.SetFillColor(f.s.TableHead.bg())
-	f.pdf.SetTextColor(f.s.TableHead.fg())
-	for i := range tabCol {
-		f.pdf.CellFormat(f.w*tabColPc[i], tabCellH, tabCol[i], "TB", 0, "C", true, 0, "")
-	}
-	f.pdf.SetFillColor(toRGB(fillColor))
-	f.pdf.Ln(-1)
-	f.pdf.SetFont(f.s.TableRowOdd.font())
-	f.pdf.SetTextColor(f.s.TableRowOdd.fg())
-	idx := 1
-	for _, entry := range f.entries {
-		y := f.pdf.GetY()
-		f.pdf.SetX(f.m.Left + f.w*tabColPc[0])
-		f.pdf.MultiCell(f.w*tabColPc[1], tabCellH, entry[0], "B", "L", false)
-		Δy := f.pdf.GetY() - y
-		f.pdf.SetXY(f.m.Left+f.w*(tabColPc[0]+tabColPc[1]), y) // reset line
-		// numbers
-		for col := 1; col < len(entry); col++ {
-			f.pdf.CellFormat(f.w*tabColPc[col+1], Δy, entry[col], "B", 0, "R", false, 0, "")
+	f.tableHeader(f.s.Columns, columnKeys, tabCellH)
+	top := f.pdf.GetY()
+
+	// running total of the lines, it is not printed, if the line totals
+	// can't be parsed.
+	carried, carry := decimal.Zero, true
+	keepH = math.Max(keepH, f.totalsHeight(tabCellH))
+	for i, entry := range f.entries {
+		need := f.rowHeight(entry[0], f.w*f.s.Columns[1], tabCellH)
+		if i == len(f.entries)-1 {
+			need += keepH
+		}
+		if carry {
+			need += tabCellH
+		}
+		if f.pdf.GetY()+need > f.pageBottom() && f.pdf.GetY() > top {
+			if carry {
+				f.forwardRow(LabelCarried, carried, tabCellH)
+			}
+			f.pdf.AddPage()
+			f.tableHeader(f.s.Columns, columnKeys, tabCellH)
+			top = f.pdf.GetY()
+			if carry {
+				f.forwardRow(LabelBrought, carried, tabCellH)
+			}
+		}
+		f.row(i+1, entry, tabCellH)
+		if carry {
+			amount, err := f.loc.parseNumber(entry[3])
+			carried, carry = carried.Add(amount), err == nil
 		}
-		// item #
-		f.pdf.SetX(f.m.Left)
-		f.pdf.CellFormat(f.w*tabColPc[0], Δy, fmt.Sprintf("%d", idx), "B", 1, "R", false, 0, "")
-		idx++
 	}
 
 	lastX, lastY = f.pdf.GetXY()
@@ -370,11 +518,11 @@ func (f *InvoiceForm) table(x, y float64) (lastX, lastY float64) {
 	// Totals
 	// totalLine: ch - cell height
 	var totalLine = func(ch float64, sz float64, name, value string) {
-		f.pdf.SetX(f.m.Left + f.w*(tabColPc[0]+tabColPc[1]))
-		f.pdf.SetFont(f.s.TableRowOdd.FamilyStr, "B", sz)
-		f.cell(f.w*(tabColPc[2]+tabColPc[3]), ch, name, "B", 0, "L", false, 0, "")
-		f.pdf.SetFont("", "", 0)
-		f.cell(f.w*tabColPc[4], ch, value, "B", 0, "R", false, 0, "")
+		f.pdf.SetX(f.m.Left + f.w*(f.s.Columns[0]+f.s.Columns[1]))
+		f.setFont(f.s.TableRowOdd.FamilyStr, "B", sz)
+		f.cell(f.w*(f.s.Columns[2]+f.s.Columns[3]), ch, name, "B", 0, "L", false, 0, "")
+		f.setFont("", "", 0)
+		f.cell(f.w*f.s.Columns[4], ch, value, "B", 0, "R", false, 0, "")
 		f.pdf.Ln(-1)
 	}
 	for _, subtotal := range f.subTotals {
@@ -385,43 +533,93 @@ func (f *InvoiceForm) table(x, y float64) (lastX, lastY float64) {
 	return lastX, lastY
 }
 
-func (f *InvoiceForm) remarks(x, y, cw float64, remark string) (lastX, lastY float64) {
-	const remCh = defCellHeight
-	f.pdf.SetXY(x, y)
+// tableHeader prints the header row of the table with the column widths and
+// label keys at the current position, and sets the table row font.
+func (f *InvoiceForm) tableHeader(widths []float64, keys []string, tabCellH float64) {
+	f.pdf.SetX(f.m.Left)
+	fillColor := fromRGB(f.pdf.GetFillColor())
+	f.setFont(f.s.Heading.font())
+	f.pdf.SetFillColor(f.s.TableHead.bg())
+	f.pdf.SetTextColor(f.s.TableHead.fg())
+	cols, second := f.loc.columns(keys)
+	border := "TB"
+	if second != nil {
+		border = "T"
+	}
+	for i := range cols {
+		f.pdf.CellFormat(f.w*widths[i], tabCellH, f.text(cols[i]), border, 0, "C", true, 0, "")
+	}
 	f.pdf.Ln(-1)
-	f.pdf.SetFont(f.s.Body.font())
-	f.pdf.SetFont("", "B", 0)
-	f.cell(cw, remCh, "Remarks/Payment instructions", "0", 1, "L", false, 0, "")
-	f.pdf.SetFont("", "", 0) // cancel bold
-	f.pdf.MultiCell(f.w*(tabColPc[1]), remCh, remark, "", "", false)
-	return f.pdf.GetXY()
+	if second != nil {
+		// bilingual header, second language labels are below
+		f.pdf.SetFontSize(f.s.Heading.Size - 2)
+		for i := range second {
+			f.pdf.CellFormat(f.w*widths[i], tabCellH*0.75, f.text(second[i]), "B", 0, "C", true, 0, "")
+		}
+		f.pdf.Ln(-1)
+	}
+	f.pdf.SetFillColor(toRGB(fillColor))
+	f.setFont(f.s.TableRowOdd.font())
+	f.pdf.SetTextColor(f.s.TableRowOdd.fg())
+}
+
+// row prints the table row of the entry number idx.
+func (f *InvoiceForm) row(idx int, entry [entrySz]string, tabCellH float64) {
+	y := f.pdf.GetY()
+	f.pdf.SetX(f.m.Left + f.w*f.s.Columns[0])
+	f.pdf.MultiCell(f.w*f.s.Columns[1], tabCellH, f.text(entry[0]), "B", "L", false)
+	Δy := f.pdf.GetY() - y
+	f.pdf.SetXY(f.m.Left+f.w*(f.s.Columns[0]+f.s.Columns[1]), y) // reset line
+	// numbers
+	for col := 1; col < len(entry); col++ {
+		f.pdf.CellFormat(f.w*f.s.Columns[col+1], Δy, f.text(entry[col]), "B", 0, "R", false, 0, "")
+	}
+	// item #
+	f.pdf.SetX(f.m.Left)
+	f.pdf.CellFormat(f.w*f.s.Columns[0], Δy, fmt.Sprintf("%d", idx), "B", 1, "R", false, 0, "")
 }
 
-// AddEntry adds an entry to the Invoice item list.
-func (f *InvoiceForm) AddEntry(description, qty, price, total string) *InvoiceForm {
-	f.entries = append(f.entries, [entrySz]string{description, qty, price, total})
-	return f
+// forwardRow prints the carried or brought forward subtotal row.
+func (f *InvoiceForm) forwardRow(key string, amount decimal.Decimal, tabCellH float64) {
+	f.pdf.SetX(f.m.Left + f.w*(f.s.Columns[0]+f.s.Columns[1]))
+	f.setFont(f.s.TableRowOdd.FamilyStr, "I", f.s.TableRowOdd.Size)
+	f.cell(f.w*(f.s.Columns[2]+f.s.Columns[3]), tabCellH, f.loc.Label(key), "B", 0, "L", false, 0, "")
+	f.cell(f.w*f.s.Columns[4], tabCellH, f.loc.Number(amount), "B", 1, "R", false, 0, "")
+	f.setFont(f.s.TableRowOdd.font())
 }
 
-// AddSubTotal adds a total to the invoice form
-func (f *InvoiceForm) AddSubTotal(name, value string) *InvoiceForm {
-	f.subTotals = append(f.subTotals, [totalSz]string{name, value})
-	return f
+// rowHeight returns the height of the row with the description of width w
+// in the current font.
+func (f *InvoiceForm) rowHeight(description string, w, tabCellH float64) float64 {
+	return float64(f.lines(description, w)) * tabCellH
 }
 
-// SetTotal sets the total line name and value.
-func (f *InvoiceForm) SetTotal(name, value string) *InvoiceForm {
-	if name == "" {
-		name = "BALANCE DUE"
-	}
-	f.total = [totalSz]string{name, value}
-	return f
+// totalsHeight returns the height of the totals block.
+func (f *InvoiceForm) totalsHeight(tabCellH float64) float64 {
+	return float64(len(f.subTotals))*tabCellH + tabCellH + 2
+}
+
+// remarksHeight returns the height of the remarks block.
+func (f *InvoiceForm) remarksHeight(remark string) float64 {
+	const remCh = defCellHeight
+	family, style := f.family, f.style
+	size, _ := f.pdf.GetFontSize()
+	f.setFont(f.s.Body.font())
+	h := float64(2+f.lines(remark, f.w*f.s.Columns[1])) * remCh
+	f.setFont(family, style, size)
+	return h
 }
 
-// AddAccountDetail adds the account information row.
-func (f *InvoiceForm) AddAccountDetail(name, value string) *InvoiceForm {
-	f.account = append(f.account, [keyValueSz]string{name + " ", value})
-	return f
+func (f *InvoiceForm) remarks(x, y, cw float64, remark string) (lastX, lastY float64) {
+	const remCh = defCellHeight
+	f.pdf.SetXY(x, y)
+	f.pdf.Ln(-1)
+	f.setFont(f.s.Body.font())
+	f.setFont("", "B", 0)
+	f.cell(cw, remCh, f.loc.Label(LabelRemarks), "0", 1, "L", false, 0, "")
+	f.setFont("", "", 0) // cancel bold
+	f.pdf.MultiCell(f.w*(f.s.Columns[1]), remCh, f.text(remark), "", "", false)
+	return f.pdf.GetXY()
 }
 
 func nvljoin(ss []string, delim string) string {
@@ -0,0 +1,96 @@
+package forms
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvoiceForm_Write(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  InvoiceFields
+		wantErr bool
+	}{
+		{"ok", InvoiceFields{Date: time.Now(), Remarks: "thanks"}, false},
+		{"missing image", InvoiceFields{Image: "does-not-exist.png"}, true},
+		{"bilingual", InvoiceFields{Date: time.Now(), Language: "de", SecondLanguage: "en"}, false},
+		{"unsupported language", InvoiceFields{Language: "xx"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewInvoice("42", PgA4, nil, nil)
+			f.AddEntry("work", "1.00", "100.00", "100.00").
+				AddSubTotal("SUBTOTAL", "100.00").
+				SetTotal("", "100.00")
+			var buf bytes.Buffer
+			err := f.Write(&buf, &tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InvoiceForm.Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+				t.Errorf("InvoiceForm.Write() output is not a PDF")
+			}
+		})
+	}
+}
+
+func TestInvoiceForm_table_pages(t *testing.T) {
+	core := defStyle
+	for _, font := range []*Font{&core.Title, &core.Heading, &core.Body, &core.Address, &core.TableHead, &core.TableRowOdd, &core.TableRowEven} {
+		font.FamilyStr = helvetica
+	}
+	tests := []struct {
+		name      string
+		entries   int
+		wantPages int
+		want      []string
+		notWant   []string
+	}{
+		{"single page", 3, 1, []string{"(Page 1 of 1)"}, []string{"Carried forward"}},
+		{"multiple pages", 80, 3, []string{
+			"(Page 1 of 3)", "(Page 3 of 3)",
+			"(Carried forward)", "(Brought forward)", "(2400.00)",
+		}, []string{"{nb}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewInvoice("42", PgA4, nil, &core)
+			f.Fpdf().SetCompression(false)
+			for i := 0; i < tt.entries; i++ {
+				f.AddEntry("work", "1.00", "100.00", "100.00")
+			}
+			f.AddSubTotal("SUBTOTAL", "100.00").SetTotal("", "100.00")
+			var buf bytes.Buffer
+			if err := f.Write(&buf, &InvoiceFields{Date: time.Now(), Remarks: "thanks"}); err != nil {
+				t.Fatalf("InvoiceForm.Write() error = %v", err)
+			}
+			if got := f.Fpdf().PageCount(); got != tt.wantPages {
+				t.Errorf("InvoiceForm.Write() pages = %d, want %d", got, tt.wantPages)
+			}
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("InvoiceForm.Write() output does not contain %q", s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("InvoiceForm.Write() output contains %q", s)
+				}
+			}
+			// totals and remarks are on the last page with the last line
+			prev := fmt.Sprintf("(Page %d of %d)", tt.wantPages-1, tt.wantPages)
+			if i := strings.Index(out, prev); i > 0 {
+				last := out[i:]
+				for _, s := range []string{"(BALANCE DUE)", "(thanks)", fmt.Sprintf("(%d)", tt.entries)} {
+					if !strings.Contains(last, s) {
+						t.Errorf("last page does not contain %q", s)
+					}
+				}
+			}
+		})
+	}
+}
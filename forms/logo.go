@@ -0,0 +1,251 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // logo formats
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Logo image formats.
+const (
+	ImagePNG  = "png"
+	ImageJPEG = "jpeg"
+	ImageGIF  = "gif"
+	ImageSVG  = "svg"
+)
+
+// svgRasterDPI is the resolution of the rasterised SVG logo.
+const svgRasterDPI = 300
+
+// Logo is the decoded logo image.
+type Logo struct {
+	Format        string  // image format, one of Image* constants
+	Width, Height float64 // intrinsic size, px
+
+	data []byte
+	svg  *svgImage
+}
+
+// NewLogo decodes the logo image: PNG, JPEG, GIF or SVG.  Data can also be
+// base64 encoded.
+func NewLogo(data []byte) (*Logo, error) {
+	if len(data) == 0 {
+		return nil, errors.New("logo: no image data")
+	}
+	if isSVG(data) {
+		img, err := parseSVG(data)
+		if err != nil {
+			return nil, fmt.Errorf("logo: %s", err)
+		}
+		return &Logo{Format: ImageSVG, Width: img.width, Height: img.height, data: data, svg: img}, nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		decoded, decErr := decodeBase64(data)
+		if decErr != nil {
+			return nil, fmt.Errorf("logo: unsupported image, must be PNG, JPEG, GIF or SVG: %s", err)
+		}
+		return NewLogo(decoded)
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return nil, fmt.Errorf("logo: empty %s image", format)
+	}
+	return &Logo{Format: format, Width: float64(cfg.Width), Height: float64(cfg.Height), data: data}, nil
+}
+
+// decodeBase64 decodes the base64 data, line breaks and spaces are ignored.
+func decodeBase64(data []byte) ([]byte, error) {
+	s := strings.Join(strings.Fields(string(data)), "")
+	if s == "" {
+		return nil, errors.New("empty data")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return decoded, nil
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// LoadLogo loads the logo from the file, or from the data URI, i.e.
+// "data:image/png;base64,iVBOR...".
+func LoadLogo(source string) (*Logo, error) {
+	if strings.HasPrefix(source, "data:") {
+		i := strings.IndexByte(source, ',')
+		if i < 0 {
+			return nil, errors.New("logo: invalid data URI")
+		}
+		if !strings.HasSuffix(source[:i], ";base64") {
+			return NewLogo([]byte(source[i+1:]))
+		}
+		data, err := decodeBase64([]byte(source[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("logo: invalid data URI: %s", err)
+		}
+		return NewLogo(data)
+	}
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("logo: %s", err)
+	}
+	logo, err := NewLogo(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", source, err)
+	}
+	return logo, nil
+}
+
+// logo returns the logo of the invoice fields: the logo, the image data,
+// or the image file, whichever is set first.  It returns nil, if there's no
+// logo.
+func (v *InvoiceFields) logo() (*Logo, error) {
+	switch {
+	case v.Logo != nil:
+		return v.Logo, nil
+	case v.ImageData != "":
+		data, err := decodeBase64([]byte(v.ImageData))
+		if err != nil {
+			return nil, fmt.Errorf("logo: invalid image data: %s", err)
+		}
+		return NewLogo(data)
+	case v.Image != "":
+		return LoadLogo(v.Image)
+	}
+	return nil, nil
+}
+
+// ValidateLogo checks that the logo of the fields, if any, can be loaded.
+func (v *InvoiceFields) ValidateLogo() error {
+	_, err := v.logo()
+	return err
+}
+
+// fit returns the logo size that fits the box of size w × h, keeping the
+// aspect ratio.  Zero width or height is not limited.
+func (l *Logo) fit(w, h float64) (float64, float64) {
+	ratio := l.Width / l.Height
+	switch {
+	case w == 0 && h == 0:
+		return l.Width * 25.4 / 96, l.Height * 25.4 / 96 // px at 96 dpi, in mm
+	case h == 0 || (w != 0 && w/h < ratio):
+		return w, w / ratio
+	default:
+		return h * ratio, h
+	}
+}
+
+// raster returns the logo as the raster image data, SVG is rasterised to
+// fit the size in mm.
+func (l *Logo) raster(w, h float64) ([]byte, error) {
+	if l.svg == nil {
+		return l.data, nil
+	}
+	px := func(mm float64) int {
+		return int(math.Max(1, math.Round(mm/25.4*svgRasterDPI)))
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, l.svg.rasterize(px(w), px(h))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// logo prints the logo fitted in the logo box of the style, keeping the
+// aspect ratio.  SVG logos are drawn as vectors.
+func (f *InvoiceForm) logo(l *Logo) {
+	if l == nil {
+		return
+	}
+	w, h := l.fit(f.s.Logo.Width, f.s.Logo.Height)
+	x, y := f.logoX(w), f.logoY()
+	if l.svg != nil {
+		f.svg(l.svg, x, y, w, h)
+		return
+	}
+	name := fmt.Sprintf("logo-%p", l)
+	opts := gofpdf.ImageOptions{ImageType: l.Format}
+	if f.pdf.GetImageInfo(name) == nil {
+		f.pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(l.data))
+	}
+	f.pdf.ImageOptions(name, x, y, w, h, false, opts, 0, "")
+}
+
+// svg draws the SVG image in the box of size w × h at x, y.
+func (f *InvoiceForm) svg(img *svgImage, x, y, w, h float64) {
+	scale, dx, dy := img.viewport(w, h)
+	pt := func(p svgPoint) (float64, float64) {
+		return x + dx + p.x*scale, y + dy + p.y*scale
+	}
+	fr, fg, fb := f.pdf.GetFillColor()
+	dr, dg, db := f.pdf.GetDrawColor()
+	lw := f.pdf.GetLineWidth()
+	orig, blend := f.pdf.GetAlpha()
+	alpha := orig
+
+	path := func(sh *svgShape, style string, a uint8) {
+		if opacity := float64(a) / 0xff; opacity != alpha {
+			f.pdf.SetAlpha(opacity, blend)
+			alpha = opacity
+		}
+		for _, seg := range sh.path {
+			switch seg.cmd {
+			case 'M':
+				f.pdf.MoveTo(pt(seg.pts[0]))
+			case 'L':
+				f.pdf.LineTo(pt(seg.pts[0]))
+			case 'C':
+				x1, y1 := pt(seg.pts[0])
+				x2, y2 := pt(seg.pts[1])
+				x3, y3 := pt(seg.pts[2])
+				f.pdf.CurveBezierCubicTo(x1, y1, x2, y2, x3, y3)
+			case 'Z':
+				f.pdf.ClosePath()
+			}
+		}
+		f.pdf.DrawPath(style)
+	}
+	for i := range img.shapes {
+		sh := &img.shapes[i]
+		var fill, stroke string
+		if sh.fill != nil {
+			f.pdf.SetFillColor(int(sh.fill.R), int(sh.fill.G), int(sh.fill.B))
+			fill = "F"
+			if sh.evenOdd {
+				fill = "F*"
+			}
+		}
+		if sh.stroke != nil && sh.strokeWidth > 0 {
+			f.pdf.SetDrawColor(int(sh.stroke.R), int(sh.stroke.G), int(sh.stroke.B))
+			f.pdf.SetLineWidth(sh.strokeWidth * scale)
+			stroke = "D"
+		}
+		switch {
+		case fill != "" && stroke != "" && sh.fill.A == sh.stroke.A:
+			path(sh, "FD"+strings.TrimPrefix(fill, "F"), sh.fill.A)
+		default:
+			// the alpha applies to both fill and stroke, translucent
+			// ones are drawn separately.
+			if fill != "" {
+				path(sh, fill, sh.fill.A)
+			}
+			if stroke != "" {
+				path(sh, stroke, sh.stroke.A)
+			}
+		}
+	}
+
+	if alpha != orig {
+		f.pdf.SetAlpha(orig, blend)
+	}
+	f.pdf.SetFillColor(fr, fg, fb)
+	f.pdf.SetDrawColor(dr, dg, db)
+	f.pdf.SetLineWidth(lw)
+}
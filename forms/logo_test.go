@@ -0,0 +1,205 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSVG = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="40mm" height="20mm" viewBox="0 0 200 100">
+<title>ACME</title>
+<rect x="5" y="5" width="190" height="90" rx="10" fill="#243761"/>
+<g transform="translate(100 50)" fill="none" stroke="white" stroke-width="4">
+<circle r="30"/>
+<path d="M-20,0 a20 20 0 1 0 40 0z"/>
+</g>
+</svg>`
+
+// testImage returns the 4 × 2 px image encoded in the format.
+func testImage(t *testing.T, format string) []byte {
+	t.Helper()
+	img := image.NewPaletted(image.Rect(0, 0, 4, 2), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case ImagePNG:
+		err = png.Encode(&buf, img)
+	case ImageJPEG:
+		err = jpeg.Encode(&buf, img, nil)
+	case ImageGIF:
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNewLogo(t *testing.T) {
+	pngData := testImage(t, ImagePNG)
+	tests := []struct {
+		name       string
+		data       []byte
+		wantFormat string
+		wantW      float64
+		wantH      float64
+		wantErr    string
+	}{
+		{"png", pngData, ImagePNG, 4, 2, ""},
+		{"jpeg", testImage(t, ImageJPEG), ImageJPEG, 4, 2, ""},
+		{"gif", testImage(t, ImageGIF), ImageGIF, 4, 2, ""},
+		{"svg", []byte(testSVG), ImageSVG, 40 * 96 / 25.4, 20 * 96 / 25.4, ""},
+		{"svg viewBox only", []byte(`<svg viewBox="0 0 30 10"><path d="M0 0h30v10z"/></svg>`), ImageSVG, 30, 10, ""},
+		{"base64", []byte(base64.StdEncoding.EncodeToString(pngData)), ImagePNG, 4, 2, ""},
+		{"empty", nil, "", 0, 0, "no image data"},
+		{"not an image", []byte("hello, world"), "", 0, 0, "unsupported image"},
+		{"svg without size", []byte(`<svg><path d="M0 0h30v10z"/></svg>`), "", 0, 0, "size is not set"},
+		{"invalid svg path", []byte(`<svg width="10" height="10"><path d="M0 0 L1"/></svg>`), "", 0, 0, "requires 2 arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLogo(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewLogo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewLogo() unexpected error = %v", err)
+			}
+			if got.Format != tt.wantFormat || !almostEqual(got.Width, tt.wantW) || !almostEqual(got.Height, tt.wantH) {
+				t.Errorf("NewLogo() = %s %.2f×%.2f, want %s %.2f×%.2f", got.Format, got.Width, got.Height, tt.wantFormat, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestLoadLogo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pngData := testImage(t, ImagePNG)
+	file := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(file, pngData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "logo.txt")
+	if err := ioutil.WriteFile(bad, []byte("not a logo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		source     string
+		wantFormat string
+		wantErr    string
+	}{
+		{"file", file, ImagePNG, ""},
+		{"data uri", "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData), ImagePNG, ""},
+		{"plain data uri", "data:image/svg+xml," + testSVG, ImageSVG, ""},
+		{"missing file", filepath.Join(dir, "missing.png"), "", "no such file"},
+		{"not an image", bad, "", "logo.txt: logo: unsupported image"},
+		{"invalid data uri", "data:image/png;base64", "", "invalid data URI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadLogo(tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadLogo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadLogo() unexpected error = %v", err)
+			}
+			if got.Format != tt.wantFormat {
+				t.Errorf("LoadLogo() format = %s, want %s", got.Format, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestLogo_fit(t *testing.T) {
+	logo := &Logo{Width: 200, Height: 100}
+	tests := []struct {
+		name         string
+		w, h         float64
+		wantW, wantH float64
+	}{
+		{"width", 18, 0, 18, 9},
+		{"height", 0, 10, 20, 10},
+		{"wide box", 40, 10, 20, 10},
+		{"tall box", 18, 40, 18, 9},
+		{"no box", 0, 0, 200 * 25.4 / 96, 100 * 25.4 / 96},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := logo.fit(tt.w, tt.h)
+			if !almostEqual(w, tt.wantW) || !almostEqual(h, tt.wantH) {
+				t.Errorf("Logo.fit() = %.2f×%.2f, want %.2f×%.2f", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestRenderer_Write_logo(t *testing.T) {
+	logo, err := NewLogo(testImage(t, ImageGIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		fields  InvoiceFields
+		wantErr string
+	}{
+		{"png data", InvoiceFields{ImageData: base64.StdEncoding.EncodeToString(testImage(t, ImagePNG))}, ""},
+		{"jpeg data uri", InvoiceFields{Image: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(testImage(t, ImageJPEG))}, ""},
+		{"gif logo", InvoiceFields{Logo: logo}, ""},
+		{"svg", InvoiceFields{ImageData: base64.StdEncoding.EncodeToString([]byte(testSVG))}, ""},
+		{"translucent svg", InvoiceFields{ImageData: base64.StdEncoding.EncodeToString([]byte(`<svg width="10" height="10"><rect width="5" height="5" fill="red" stroke="blue" opacity=".5" stroke-opacity=".5"/></svg>`))}, ""},
+		{"unsupported svg", InvoiceFields{ImageData: base64.StdEncoding.EncodeToString([]byte(`<svg width="10" height="10"><text>ACME</text></svg>`))}, "<text> elements are not supported"},
+		{"missing file", InvoiceFields{Image: "does-not-exist.png"}, "does-not-exist.png"},
+		{"invalid data", InvoiceFields{ImageData: "!!!"}, "invalid image data"},
+	}
+	for _, name := range Renderers() {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				r, err := NewRenderer(name, "42", &Theme{Logo: &Box{Width: 30, Height: 10}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+				var buf bytes.Buffer
+				err = r.Write(&buf, &tt.fields)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Write() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Write() unexpected error = %v", err)
+				}
+				if !bytes.Contains(buf.Bytes(), []byte("/Subtype /Image")) && !bytes.Contains(buf.Bytes(), []byte("/Subtype/Image")) && !strings.HasSuffix(tt.name, "svg") {
+					t.Error("Write() output has no logo image")
+				}
+			})
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-6 && d > -1e-6
+}
@@ -0,0 +1,153 @@
+package forms
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+// metaCreator is the creator application of the PDF document information.
+const metaCreator = "sheet2inv"
+
+// Metadata is the PDF document information of the invoice.
+//
+// Tagged PDF structure is not produced: neither gofpdf nor the unipdf
+// creator support the structure tree, so the document information is the
+// only accessibility data available to screen readers.
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+	Creator  string    // application that created the document
+	Created  time.Time // creation date, zero is the time the PDF is generated
+}
+
+// Metadata returns the document information of the invoice: the invoice
+// number as the title, the seller as the author, the client, period and
+// total as the subject, and the invoice number, client and the custom
+// keywords of the fields as the keywords.  The content total must be set.
+func (c *Content) Metadata(fields *InvoiceFields) (*Metadata, error) {
+	loc, err := NewLocale(fields.Language, fields.SecondLanguage)
+	if err != nil {
+		return nil, err
+	}
+	return c.metadata(fields, loc), nil
+}
+
+func (c *Content) metadata(v *InvoiceFields, loc *Locale) *Metadata {
+	label, _ := loc.Labels(LabelInvoice)
+	title := strings.TrimSpace(label + " " + c.invoiceID)
+	seller, client := orgName(v.Address), orgName(v.BillTo)
+
+	subject := []string{title}
+	if seller != "" {
+		subject = append(subject, seller)
+	}
+	if client != "" {
+		subject = append(subject, metaLabel(loc, LabelBillTo)+": "+client)
+	}
+	if !v.PeriodStart.IsZero() || !v.PeriodEnd.IsZero() {
+		subject = append(subject, metaLabel(loc, LabelTimePeriod)+": "+loc.Date(v.PeriodStart)+" - "+loc.Date(v.PeriodEnd))
+	}
+	if c.total[1] != "" {
+		subject = append(subject, metaLabel(loc, LabelBalanceDue)+": "+c.total[1])
+	}
+
+	keywords := []string{c.invoiceID}
+	if client != "" {
+		keywords = append(keywords, client)
+	}
+	if v.PONumber != "" {
+		keywords = append(keywords, v.PONumber)
+	}
+	for _, kw := range v.Keywords {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			keywords = append(keywords, kw)
+		}
+	}
+
+	return &Metadata{
+		Title:    title,
+		Author:   seller,
+		Subject:  strings.Join(subject, ", "),
+		Keywords: keywords,
+		Creator:  metaCreator,
+		Created:  v.Date,
+	}
+}
+
+// metaLabel returns the label in the primary language without the trailing
+// colon.
+func metaLabel(loc *Locale, key string) string {
+	label, _ := loc.Labels(key)
+	return strings.TrimRight(label, ": ")
+}
+
+// orgName returns the organisation name of the address, or the person name,
+// if the organisation is not set.
+func orgName(a Address) string {
+	if a.Organisation != "" {
+		return a.Organisation
+	}
+	return a.Name
+}
+
+// setMetadata sets the document information of the gofpdf document.
+func (f *InvoiceForm) setMetadata(md *Metadata) {
+	f.pdf.SetTitle(md.Title, true)
+	f.pdf.SetAuthor(md.Author, true)
+	f.pdf.SetSubject(md.Subject, true)
+	f.pdf.SetKeywords(strings.Join(md.Keywords, ", "), true)
+	f.pdf.SetCreator(md.Creator, true)
+	if !md.Created.IsZero() {
+		f.pdf.SetCreationDate(md.Created)
+	}
+}
+
+// unipdfInfoMu guards the unipdf document information, that is set in
+// package variables of the unipdf model.
+var unipdfInfoMu sync.Mutex
+
+// withUnipdfMetadata calls fn with the unipdf document information set to
+// md, and resets it afterwards.
+func withUnipdfMetadata(md *Metadata, fn func() error) error {
+	unipdfInfoMu.Lock()
+	defer unipdfInfoMu.Unlock()
+	set := func(md *Metadata) {
+		model.SetPdfTitle(pdfText(md.Title))
+		model.SetPdfAuthor(pdfText(md.Author))
+		model.SetPdfSubject(pdfText(md.Subject))
+		model.SetPdfKeywords(pdfText(strings.Join(md.Keywords, ", ")))
+		model.SetPdfCreator(pdfText(md.Creator))
+		model.SetPdfCreationDate(md.Created)
+	}
+	set(md)
+	defer set(&Metadata{})
+	return fn()
+}
+
+// pdfText returns s as the PDF text string: unchanged, if s is ASCII, or
+// UTF-16BE with the byte order mark, as unipdf writes the document
+// information strings as is.
+func pdfText(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+	buf := []byte{0xfe, 0xff}
+	for _, r := range utf16.Encode([]rune(s)) {
+		buf = append(buf, byte(r>>8), byte(r))
+	}
+	return string(buf)
+}
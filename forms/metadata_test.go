@@ -0,0 +1,131 @@
+package forms
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unipdf/v3/core"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+func TestContent_Metadata(t *testing.T) {
+	date := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		total   string
+		fields  InvoiceFields
+		want    *Metadata
+		wantErr bool
+	}{
+		{"full",
+			"$ 1,000.00",
+			InvoiceFields{
+				Date:        date,
+				PeriodStart: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+				PeriodEnd:   time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC),
+				PONumber:    "PO-7",
+				Address:     Address{Name: "John Doe", Organisation: "ACME Consulting"},
+				BillTo:      Address{Name: "Client Ltd"},
+				Keywords:    []string{"consulting", " ", "march "},
+			},
+			&Metadata{
+				Title:    "INVOICE 42",
+				Author:   "ACME Consulting",
+				Subject:  "INVOICE 42, ACME Consulting, BILL TO: Client Ltd, Time period: 01/03/2020 - 31/03/2020, BALANCE DUE: $ 1,000.00",
+				Keywords: []string{"42", "Client Ltd", "PO-7", "consulting", "march"},
+				Creator:  metaCreator,
+				Created:  date,
+			},
+			false,
+		},
+		{"empty",
+			"",
+			InvoiceFields{},
+			&Metadata{Title: "INVOICE 42", Subject: "INVOICE 42", Keywords: []string{"42"}, Creator: metaCreator},
+			false,
+		},
+		{"bilingual uses the primary language",
+			"",
+			InvoiceFields{Language: "de", SecondLanguage: "en"},
+			&Metadata{Title: "RECHNUNG 42", Subject: "RECHNUNG 42", Keywords: []string{"42"}, Creator: metaCreator},
+			false,
+		},
+		{"unsupported language", "", InvoiceFields{Language: "xx"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newContent("42")
+			c.SetTotal("", tt.total)
+			got, err := c.Metadata(&tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Content.Metadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Content.Metadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderer_Write_metadata(t *testing.T) {
+	fields := InvoiceFields{
+		Date:     time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		Address:  Address{Organisation: "ACME Consulting"},
+		BillTo:   Address{Name: "ООО «Ромашка»"},
+		Keywords: []string{"consulting"},
+	}
+	want := map[string]string{
+		"Title":    "INVOICE 42",
+		"Author":   "ACME Consulting",
+		"Keywords": "42, ООО «Ромашка», consulting",
+		"Creator":  metaCreator,
+	}
+	for _, name := range Renderers() {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRenderer(name, "42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+			var buf bytes.Buffer
+			if err := r.Write(&buf, &fields); err != nil {
+				t.Fatalf("Write() unexpected error = %v", err)
+			}
+			info := pdfInfo(t, buf.Bytes())
+			for key, val := range want {
+				if got := info[key]; got != val {
+					t.Errorf("Write() /%s = %q, want %q", key, got, val)
+				}
+			}
+			if info["CreationDate"] == "" || info["CreationDate"][:10] != "D:20200401" {
+				t.Errorf("Write() /CreationDate = %q, want the invoice date", info["CreationDate"])
+			}
+		})
+	}
+}
+
+// pdfInfo returns the decoded document information strings of the pdf.
+func pdfInfo(t *testing.T, pdf []byte) map[string]string {
+	t.Helper()
+	reader, err := model.NewPdfReader(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("output is not a PDF: %v", err)
+	}
+	trailer, err := reader.GetTrailer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict, ok := core.GetDict(trailer.Get("Info"))
+	if !ok {
+		t.Fatal("no document information")
+	}
+	info := make(map[string]string)
+	for _, key := range dict.Keys() {
+		if s, ok := core.GetString(dict.Get(key)); ok {
+			info[string(key)] = s.Decoded()
+		}
+	}
+	return info
+}
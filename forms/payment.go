@@ -0,0 +1,559 @@
+package forms
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	"rsc.io/qr"
+)
+
+// Payment codes
+const (
+	PaymentEPC     = "epc"     // EPC069-12 SEPA credit transfer QR code (GiroCode)
+	PaymentSwissQR = "swissqr" // Swiss QR-bill payment part and receipt
+)
+
+// payment code measurements
+const (
+	epcQRSz = 30.0 // mm
+
+	swissH       = 105.0 // QR-bill height
+	swissW       = 210.0 // QR-bill width
+	swissRcptW   = 62.0  // receipt width
+	swissQRSz    = 46.0  // QR code size without quiet zone
+	swissCrossSz = 7.0   // Swiss cross size
+	swissMargin  = 5.0
+)
+
+// Payment is the structured payment information encoded in payment codes.
+type Payment struct {
+	IBAN      string
+	BIC       string
+	Amount    decimal.Decimal
+	Currency  string
+	Reference string // creditor reference (RF or QR reference)
+	Message   string // unstructured remittance information
+	Creditor  Address
+	Debtor    Address
+}
+
+// NewPayment returns the payment information for the invoice, the creditor
+// reference is derived from the invoice number.
+func NewPayment(invoiceID string, fields *InvoiceFields, amount decimal.Decimal, currency string) (*Payment, error) {
+	iban := compact(fields.IBAN)
+	if err := validIBAN(iban); err != nil {
+		return nil, err
+	}
+	p := Payment{
+		IBAN:     iban,
+		BIC:      compact(fields.BIC),
+		Amount:   amount.RoundBank(2),
+		Currency: strings.ToUpper(currency),
+		Message:  "Invoice " + invoiceID,
+		Creditor: fields.Address,
+		Debtor:   fields.BillTo,
+	}
+	var err error
+	if isQRIBAN(iban) {
+		p.Reference, err = qrReference(invoiceID)
+	} else {
+		p.Reference, err = creditorReference(invoiceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// EPC returns the EPC069-12 (version 002) QR code payload.
+func (p *Payment) EPC() (string, error) {
+	if p.Currency != "EUR" {
+		return "", fmt.Errorf("epc: only EUR payments are supported, got %q", p.Currency)
+	}
+	if p.Amount.LessThan(decimal.New(1, -2)) || p.Amount.GreaterThan(decimal.RequireFromString("999999999.99")) {
+		return "", fmt.Errorf("epc: amount out of range: %s", p.Amount)
+	}
+	name := p.Creditor.Organisation
+	if name == "" {
+		name = p.Creditor.Name
+	}
+	if name == "" {
+		return "", errors.New("epc: beneficiary name is empty")
+	}
+	lines := []string{
+		"BCD",
+		"002",
+		"1", // UTF-8
+		"SCT",
+		p.BIC,
+		truncate(name, 70),
+		p.IBAN,
+		"EUR" + p.Amount.StringFixed(2),
+		"", // purpose
+		p.Reference,
+		"", // unstructured remittance, only one of the two is allowed
+	}
+	if p.Reference == "" {
+		lines[10] = truncate(p.Message, 140)
+	}
+	payload := strings.Join(lines, "\n")
+	if len(payload) > 331 {
+		return "", fmt.Errorf("epc: payload too long: %d bytes", len(payload))
+	}
+	return payload, nil
+}
+
+// SwissQR returns the Swiss QR-bill (version 2.0) QR code payload.
+func (p *Payment) SwissQR() (string, error) {
+	if !strings.HasPrefix(p.IBAN, "CH") && !strings.HasPrefix(p.IBAN, "LI") {
+		return "", fmt.Errorf("swiss qr: IBAN must be a CH or LI account: %s", p.IBAN)
+	}
+	if p.Currency != "CHF" && p.Currency != "EUR" {
+		return "", fmt.Errorf("swiss qr: currency must be CHF or EUR, got %q", p.Currency)
+	}
+	if p.Amount.LessThan(decimal.New(1, -2)) || p.Amount.GreaterThan(decimal.RequireFromString("999999999.99")) {
+		return "", fmt.Errorf("swiss qr: amount out of range: %s", p.Amount)
+	}
+	creditor, err := swissAddress(p.Creditor)
+	if err != nil {
+		return "", fmt.Errorf("swiss qr: creditor: %s", err)
+	}
+	debtor := make([]string, 7) // unknown debtor is left blank
+	if !blankAddress(p.Debtor) {
+		if debtor, err = swissAddress(p.Debtor); err != nil {
+			return "", fmt.Errorf("swiss qr: debtor: %s", err)
+		}
+	}
+	refType := "NON"
+	switch {
+	case isQRIBAN(p.IBAN):
+		refType = "QRR"
+	case p.Reference != "":
+		refType = "SCOR"
+	}
+
+	lines := []string{"SPC", "0200", "1", p.IBAN}
+	lines = append(lines, creditor...)
+	lines = append(lines, make([]string, 7)...) // ultimate creditor, reserved
+	lines = append(lines, p.Amount.StringFixed(2), p.Currency)
+	lines = append(lines, debtor...)
+	lines = append(lines, refType, p.Reference, truncate(p.Message, 140), "EPD")
+	return strings.Join(lines, "\n"), nil
+}
+
+// swissAddress returns the structured address elements of the QR-bill.
+func swissAddress(a Address) ([]string, error) {
+	name := a.Organisation
+	if name == "" {
+		name = a.Name
+	}
+	if name == "" || a.Town == "" || a.Postcode == "" || a.CountryCode == "" {
+		return nil, errors.New("name, town, postcode and country code are required")
+	}
+	return []string{
+		"S",
+		truncate(name, 70),
+		truncate(a.Street, 70),
+		"", // building number is part of the street
+		truncate(a.Postcode, 16),
+		truncate(a.Town, 35),
+		strings.ToUpper(a.CountryCode),
+	}, nil
+}
+
+// blankAddress returns true if the address has no name and postal address.
+func blankAddress(a Address) bool {
+	return a.Organisation == "" && a.Name == "" && a.Street == "" && a.Postcode == "" && a.Town == "" && a.CountryCode == ""
+}
+
+// paymentCode draws the payment code below y, and returns the Y position
+// after it.
+func (f *InvoiceForm) paymentCode(y float64, fields *InvoiceFields) (lastY float64, err error) {
+	code := strings.ToLower(fields.PaymentCode)
+	if code == "" {
+		return y, nil
+	}
+	p, err := NewPayment(f.invoiceID, fields, f.payable, f.currency)
+	if err != nil {
+		return y, err
+	}
+	switch code {
+	case PaymentEPC:
+		return f.epcCode(y, p)
+	case PaymentSwissQR:
+		return f.swissQRBill(y, p)
+	default:
+		return y, fmt.Errorf("unknown payment code: %q", fields.PaymentCode)
+	}
+}
+
+// epcCode draws the GiroCode at the right side of the page below y.
+func (f *InvoiceForm) epcCode(y float64, p *Payment) (float64, error) {
+	payload, err := p.EPC()
+	if err != nil {
+		return y, err
+	}
+	code, err := qr.Encode(payload, qr.M)
+	if err != nil {
+		return y, err
+	}
+	const captionH = defCellHeight
+	y += defCellHeight
+	if y+epcQRSz+captionH > f.maxY-f.m.Bottom {
+		f.pdf.AddPage()
+		y = f.m.Top
+	}
+	x := f.maxX - f.m.Right - epcQRSz
+	f.qrAt(x, y, epcQRSz, code)
+	f.pdf.SetXY(x, y+epcQRSz)
+	f.setFont(f.s.Body.FamilyStr, "", f.s.Body.Size-2)
+	f.pdf.SetTextColor(f.s.Body.fg())
+	f.cell(epcQRSz, captionH, f.loc.Label(LabelScanToPay), "", 1, "C", false, 0, "")
+	return f.pdf.GetY(), nil
+}
+
+// swissQRBill draws the QR-bill payment part and receipt at the bottom of
+// the page, adding a new page if the content ends below y.
+func (f *InvoiceForm) swissQRBill(y float64, p *Payment) (float64, error) {
+	payload, err := p.SwissQR()
+	if err != nil {
+		return y, err
+	}
+	code, err := qr.Encode(payload, qr.M)
+	if err != nil {
+		return y, err
+	}
+	if y > f.maxY-swissH {
+		f.pdf.AddPage()
+	}
+	// the slip is drawn to the page edges.
+	f.slip = true
+	f.pdf.SetAutoPageBreak(false, 0)
+	defer f.pdf.SetAutoPageBreak(true, f.m.Bottom)
+
+	top := f.maxY - swissH
+	left := (f.maxX - swissW) / 2
+
+	// separation lines
+	f.pdf.SetDrawColor(toRGB(cBlack))
+	f.pdf.SetLineWidth(lThin)
+	f.pdf.SetDashPattern([]float64{1, 1}, 0)
+	f.pdf.Line(0, top, f.maxX, top)
+	f.pdf.Line(left+swissRcptW, top, left+swissRcptW, f.maxY)
+	f.pdf.SetDashPattern(nil, 0)
+
+	account := formatIBAN(p.IBAN)
+	creditor := slipLines(p.Creditor)
+	debtor := slipLines(p.Debtor)
+	reference := p.Reference
+	if isQRIBAN(p.IBAN) {
+		reference = formatQRReference(reference)
+	} else {
+		reference = formatIBAN(reference)
+	}
+	amount := formatSwissAmount(p.Amount)
+
+	// receipt
+	x := left + swissMargin
+	w := swissRcptW - 2*swissMargin
+	f.swissText(x, top+swissMargin, w, 11, "B", f.qrLabel(labelQRReceipt))
+	y = top + 12
+	y = f.swissSection(x, y, w, 6, 8, f.qrLabel(labelQRAccount), append([]string{account}, creditor...))
+	if reference != "" {
+		y = f.swissSection(x, y, w, 6, 8, f.qrLabel(labelQRReference), []string{reference})
+	}
+	f.swissDebtor(x, y, 52, 20, 6, 8, debtor)
+	f.swissAmount(x, top+68, 6, 8, p.Currency, amount)
+	f.pdf.SetXY(x, top+82)
+	f.setFont(helvetica, "B", 6)
+	f.cell(w, 0, f.qrLabel(labelQRAcceptance), "", 0, "R", false, 0, "")
+
+	// payment part
+	x = left + swissRcptW + swissMargin
+	f.swissText(x, top+swissMargin, 51, 11, "B", f.qrLabel(labelQRPaymentPart))
+	qrY := top + 17
+	f.qrAt(x, qrY, swissQRSz, code)
+	f.swissCross(x+(swissQRSz-swissCrossSz)/2, qrY+(swissQRSz-swissCrossSz)/2)
+	f.swissAmount(x, top+68, 8, 10, p.Currency, amount)
+
+	x = left + swissRcptW + 56
+	w = swissW - swissRcptW - 56 - swissMargin
+	y = f.swissSection(x, top+swissMargin, w, 8, 10, f.qrLabel(labelQRAccount), append([]string{account}, creditor...))
+	if reference != "" {
+		y = f.swissSection(x, y, w, 8, 10, f.qrLabel(labelQRReference), []string{reference})
+	}
+	if p.Message != "" {
+		y = f.swissSection(x, y, w, 8, 10, f.qrLabel(labelQRAdditional), []string{p.Message})
+	}
+	f.swissDebtor(x, y, 65, 25, 8, 10, debtor)
+
+	return f.maxY, nil
+}
+
+// swissDebtor draws the debtor section, or the blank box of size w × h for
+// the debtor to fill in by hand, if the debtor is unknown.
+func (f *InvoiceForm) swissDebtor(x, y, w, h, headSz, valSz float64, debtor []string) {
+	if len(debtor) > 0 {
+		f.swissSection(x, y, w, headSz, valSz, f.qrLabel(labelQRPayableBy), debtor)
+		return
+	}
+	f.swissText(x, y, w, headSz, "B", f.qrLabel(labelQRPayableByBlank))
+	f.swissBlank(x, f.pdf.GetY()+1, w, h)
+}
+
+// swissBlank draws the corner marks of the blank field of size w × h at x,
+// y.
+func (f *InvoiceForm) swissBlank(x, y, w, h float64) {
+	const (
+		mark  = 3.0  // corner mark length, mm
+		width = 0.26 // 0.75 pt
+	)
+	f.pdf.SetDrawColor(toRGB(cBlack))
+	f.pdf.SetLineWidth(width)
+	for _, c := range [][4]float64{{x, y, 1, 1}, {x + w, y, -1, 1}, {x, y + h, 1, -1}, {x + w, y + h, -1, -1}} {
+		f.pdf.Line(c[0], c[1], c[0]+c[2]*mark, c[1])
+		f.pdf.Line(c[0], c[1], c[0], c[1]+c[3]*mark)
+	}
+}
+
+// qrLabel returns the QR-bill label in the primary language, the standard
+// does not allow bilingual labels.
+func (f *InvoiceForm) qrLabel(key string) string {
+	return f.loc.primary.label(key)
+}
+
+func (f *InvoiceForm) swissText(x, y, w, size float64, style, str string) {
+	f.pdf.SetXY(x, y)
+	f.setFont(helvetica, style, size)
+	f.pdf.SetTextColor(toRGB(cBlack))
+	f.pdf.MultiCell(w, f.pdf.PointConvert(size)*1.2, f.text(str), "", "L", false)
+}
+
+// swissSection draws the heading and value lines, and returns the Y
+// position for the next section.
+func (f *InvoiceForm) swissSection(x, y, w, headSz, valSz float64, heading string, lines []string) float64 {
+	f.swissText(x, y, w, headSz, "B", heading)
+	for _, line := range lines {
+		f.swissText(x, f.pdf.GetY(), w, valSz, "", line)
+	}
+	return f.pdf.GetY() + f.pdf.PointConvert(valSz)
+}
+
+func (f *InvoiceForm) swissAmount(x, y, headSz, valSz float64, currency, amount string) {
+	const curW = 15
+	f.swissText(x, y, curW, headSz, "B", f.qrLabel(labelQRCurrency))
+	f.swissText(x+curW, y, 30, headSz, "B", f.qrLabel(labelQRAmount))
+	y = f.pdf.GetY()
+	f.swissText(x, y, curW, valSz, "", currency)
+	f.swissText(x+curW, y, 30, valSz, "", amount)
+}
+
+// swissCross draws the Swiss cross logo with the white border at x, y.
+func (f *InvoiceForm) swissCross(x, y float64) {
+	const (
+		border = 0.5
+		sz     = swissCrossSz - 2*border
+		armW   = sz * 6 / 32 * 1.2
+		armL   = sz * 20 / 32
+	)
+	f.pdf.SetFillColor(toRGB(cWhite))
+	f.pdf.Rect(x, y, swissCrossSz, swissCrossSz, "F")
+	f.pdf.SetFillColor(toRGB(cBlack))
+	f.pdf.Rect(x+border, y+border, sz, sz, "F")
+	f.pdf.SetFillColor(toRGB(cWhite))
+	cx, cy := x+swissCrossSz/2, y+swissCrossSz/2
+	f.pdf.Rect(cx-armW/2, cy-armL/2, armW, armL, "F")
+	f.pdf.Rect(cx-armL/2, cy-armW/2, armL, armW, "F")
+}
+
+// qrAt draws the QR code as vector rectangles at x, y, sz is the size of the
+// code without the quiet zone.
+func (f *InvoiceForm) qrAt(x, y, sz float64, code *qr.Code) {
+	module := sz / float64(code.Size)
+	f.pdf.SetFillColor(toRGB(cBlack))
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; {
+			if !code.Black(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Black(col, row) {
+				col++
+			}
+			f.pdf.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module, "F")
+		}
+	}
+}
+
+// slipLines returns the name and postal address lines for the payment
+// slip.
+func slipLines(a Address) []string {
+	name := a.Organisation
+	if name == "" {
+		name = a.Name
+	}
+	var lines []string
+	for _, s := range []string{name, a.Street, nvljoin([]string{a.Postcode, a.Town}, " ")} {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return lines
+}
+
+// compact removes spaces from the account number and converts it to upper
+// case.
+func compact(s string) string {
+	return strings.ToUpper(strings.Replace(s, " ", "", -1))
+}
+
+// validIBAN checks the IBAN format and check digits.
+func validIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 {
+		return fmt.Errorf("invalid IBAN length: %q", iban)
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return fmt.Errorf("invalid IBAN check digits: %q", iban)
+	}
+	return nil
+}
+
+// mod97 returns the ISO 7064 MOD 97-10 remainder of the alphanumeric
+// string, letters are converted to numbers A=10..Z=35.  It returns -1 if
+// the string contains other characters.
+func mod97(s string) int {
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case '0' <= r && r <= '9':
+			digits.WriteRune(r)
+		case 'A' <= r && r <= 'Z':
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		default:
+			return -1
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return -1
+	}
+	return int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
+
+// isQRIBAN returns true, if the IBAN is a Swiss QR-IBAN, that requires the
+// QR reference.
+func isQRIBAN(iban string) bool {
+	if len(iban) < 9 || !(strings.HasPrefix(iban, "CH") || strings.HasPrefix(iban, "LI")) {
+		return false
+	}
+	iid := iban[4:9]
+	return "30000" <= iid && iid <= "31999"
+}
+
+// creditorReference returns the ISO 11649 creditor reference (RF) for the
+// alphanumeric characters of the invoice number.
+func creditorReference(invoiceID string) (string, error) {
+	ref := alnum(strings.ToUpper(invoiceID))
+	if ref == "" {
+		return "", fmt.Errorf("creditor reference: invoice number %q has no alphanumeric characters", invoiceID)
+	}
+	if len(ref) > 21 {
+		ref = ref[len(ref)-21:]
+	}
+	check := 98 - mod97(ref+"RF00")
+	return fmt.Sprintf("RF%02d%s", check, ref), nil
+}
+
+// qrReference returns the 27 digit Swiss QR reference for the digits of the
+// invoice number.
+func qrReference(invoiceID string) (string, error) {
+	var digits strings.Builder
+	for _, r := range invoiceID {
+		if '0' <= r && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	ref := digits.String()
+	if ref == "" {
+		return "", fmt.Errorf("qr reference: invoice number %q has no digits", invoiceID)
+	}
+	if len(ref) > 26 {
+		ref = ref[len(ref)-26:]
+	}
+	ref = strings.Repeat("0", 26-len(ref)) + ref
+	return ref + string('0'+mod10(ref)), nil
+}
+
+// mod10 returns the recursive modulo 10 check digit.
+func mod10(digits string) byte {
+	table := [10]byte{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+	var carry byte
+	for i := 0; i < len(digits); i++ {
+		carry = table[(carry+digits[i]-'0')%10]
+	}
+	return (10 - carry) % 10
+}
+
+func alnum(s string) string {
+	var buf strings.Builder
+	for _, r := range s {
+		if ('0' <= r && r <= '9') || ('A' <= r && r <= 'Z') {
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String()
+}
+
+// formatIBAN formats the IBAN or creditor reference in groups of four.
+func formatIBAN(s string) string {
+	var buf strings.Builder
+	for i, r := range s {
+		if i > 0 && i%4 == 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// formatQRReference formats the QR reference as 2 digits followed by groups
+// of five.
+func formatQRReference(s string) string {
+	if len(s) != 27 {
+		return s
+	}
+	var buf strings.Builder
+	buf.WriteString(s[:2])
+	for i := 2; i < len(s); i += 5 {
+		buf.WriteByte(' ')
+		buf.WriteString(s[i : i+5])
+	}
+	return buf.String()
+}
+
+// formatSwissAmount formats the amount with space as thousands separator.
+func formatSwissAmount(amount decimal.Decimal) string {
+	s := amount.StringFixed(2)
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	var buf strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String() + frac
+}
+
+// truncate truncates the string to n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
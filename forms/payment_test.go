@@ -0,0 +1,193 @@
+package forms
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func Test_validIBAN(t *testing.T) {
+	tests := []struct {
+		iban    string
+		wantErr bool
+	}{
+		{"DE89370400440532013000", false},
+		{"CH4431999123000889012", false},
+		{"DE89370400440532013001", true},
+		{"DE89", true},
+		{"DE8937040044053201300!", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			if err := validIBAN(tt.iban); (err != nil) != tt.wantErr {
+				t.Errorf("validIBAN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_creditorReference(t *testing.T) {
+	got, err := creditorReference("5390-0754-7034")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "RF18539007547034"; got != want {
+		t.Errorf("creditorReference() = %q, want %q", got, want)
+	}
+	if _, err := creditorReference("--"); err == nil {
+		t.Error("creditorReference() expected error for invoice number without alphanumerics")
+	}
+}
+
+func Test_qrReference(t *testing.T) {
+	tests := []struct {
+		invoiceID string
+		want      string
+	}{
+		{"21000000000313947143000901", "210000000003139471430009017"},
+		{"INV-42", "000000000000000000000000420"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.invoiceID, func(t *testing.T) {
+			got, err := qrReference(tt.invoiceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("qrReference() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := qrReference("INV"); err == nil {
+		t.Error("qrReference() expected error for invoice number without digits")
+	}
+}
+
+func testPaymentFields(code, iban string) *InvoiceFields {
+	return &InvoiceFields{
+		IBAN:        iban,
+		BIC:         "COBADEFFXXX",
+		PaymentCode: code,
+		Address:     Address{Name: "Seller", Street: "Bahnhofstrasse 1", Postcode: "8001", Town: "Zürich", CountryCode: "CH"},
+		BillTo:      Address{Organisation: "Buyer AG", Street: "Marktgasse 2", Postcode: "3011", Town: "Bern", CountryCode: "CH"},
+	}
+}
+
+func TestPayment_EPC(t *testing.T) {
+	p, err := NewPayment("INV-42", testPaymentFields(PaymentEPC, "DE89 3704 0044 0532 0130 00"), decimal.RequireFromString("1234.5"), "eur")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.EPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BCD\n002\n1\nSCT\nCOBADEFFXXX\nSeller\nDE89370400440532013000\nEUR1234.50\n\nRF55INV42\n"
+	if got != want {
+		t.Errorf("EPC() = %q, want %q", got, want)
+	}
+	p.Currency = "USD"
+	if _, err := p.EPC(); err == nil {
+		t.Error("EPC() expected error for non-EUR currency")
+	}
+}
+
+func TestPayment_SwissQR(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		want    []string
+		wantErr bool
+	}{
+		{"qr-iban", "CH4431999123000889012", []string{"SPC\n0200\n1\nCH4431999123000889012\nS\nSeller\nBahnhofstrasse 1\n\n8001\nZürich\nCH\n", "\nQRR\n000000000000000000000000420\n"}, false},
+		{"iban", "CH9300762011623852957", []string{"\n100.00\nCHF\nS\nBuyer AG\n", "\nSCOR\nRF"}, false},
+		{"foreign iban", "DE89370400440532013000", nil, true},
+		{"unknown debtor", "CH9300762011623852957", []string{"\n100.00\nCHF\n\n\n\n\n\n\n\nSCOR\nRF"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := testPaymentFields(PaymentSwissQR, tt.iban)
+			if tt.name == "unknown debtor" {
+				fields.BillTo = Address{}
+			}
+			p, err := NewPayment("INV-42", fields, decimal.New(100, 0), "CHF")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.SwissQR()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SwissQR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("SwissQR() = %q, missing %q", got, want)
+				}
+			}
+			if !tt.wantErr && !strings.HasSuffix(got, "\nInvoice INV-42\nEPD") {
+				t.Errorf("SwissQR() = %q, must end with message and EPD", got)
+			}
+		})
+	}
+}
+
+func TestInvoiceForm_paymentCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  *InvoiceFields
+		wantErr bool
+	}{
+		{"epc", testPaymentFields(PaymentEPC, "DE89370400440532013000"), false},
+		{"swiss", testPaymentFields(PaymentSwissQR, "CH4431999123000889012"), false},
+		{"unknown", testPaymentFields("bitcoin", "DE89370400440532013000"), true},
+		{"bad iban", testPaymentFields(PaymentEPC, "DE00370400440532013000"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency := "EUR"
+			if tt.fields.PaymentCode == PaymentSwissQR {
+				currency = "CHF"
+			}
+			f := NewInvoice("42", PgA4, nil, nil)
+			f.AddEntry("work", "1.00", "100.00", "100.00").
+				SetTotal("", "100.00").
+				SetPayable(decimal.New(100, 0), currency)
+			var buf bytes.Buffer
+			err := f.Write(&buf, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InvoiceForm.Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvoiceForm_swissQRBill(t *testing.T) {
+	fields := testPaymentFields(PaymentSwissQR, "CH4431999123000889012")
+	fields.BillTo = Address{} // blank "payable by" box
+	p, err := NewPayment("42", fields, decimal.New(100, 0), "CHF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewInvoice("42", PgA4, nil, nil)
+	if f.loc, err = NewLocale("", ""); err != nil {
+		t.Fatal(err)
+	}
+	// the current position is ignored, the content ends at y.
+	f.pdf.SetY(f.maxY - 10)
+	if _, err := f.swissQRBill(f.m.Top+50, p); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.pdf.PageNo(); n != 1 {
+		t.Errorf("pages = %d, want the slip on the first page", n)
+	}
+	if _, err := f.swissQRBill(f.maxY-swissH+1, p); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.pdf.PageNo(); n != 2 {
+		t.Errorf("pages = %d, want the slip on the new page", n)
+	}
+	if err := f.pdf.Error(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,44 @@
+package forms
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Renderer backends.
+const (
+	RendererGofpdf = "gofpdf" // InvoiceForm, the default
+	RendererUnipdf = "unipdf" // UnipdfForm
+)
+
+// Renderer renders the invoice content.  The content is filled with the
+// invoice lines, totals and account details before the invoice is written.
+type Renderer interface {
+	AddEntry(description, qty, price, total string) *Content
+	AddSubTotal(name, value string) *Content
+	SetTotal(name, value string) *Content
+	AddAccountDetail(name, value string) *Content
+	// Contents returns the invoice content.
+	Contents() *Content
+	// Write generates the invoice and writes it to w.
+	Write(w io.Writer, fields *InvoiceFields) error
+}
+
+// Renderers returns the PDF renderer names.
+func Renderers() []string {
+	return []string{RendererGofpdf, RendererUnipdf}
+}
+
+// NewRenderer creates the PDF renderer with the theme.  Empty name selects
+// the default renderer.
+func NewRenderer(name string, invoiceID string, t *Theme) (Renderer, error) {
+	switch strings.ToLower(name) {
+	case "", RendererGofpdf:
+		return NewInvoiceTheme(invoiceID, t)
+	case RendererUnipdf:
+		return NewUnipdf(invoiceID, t)
+	default:
+		return nil, fmt.Errorf("unknown renderer %q, available: %s", name, strings.Join(Renderers(), ", "))
+	}
+}
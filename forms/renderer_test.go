@@ -0,0 +1,14 @@
+package forms
+
+import "testing"
+
+func TestNewRenderer(t *testing.T) {
+	for _, name := range []string{"", RendererGofpdf, "UniPDF"} {
+		if _, err := NewRenderer(name, "42", nil); err != nil {
+			t.Errorf("NewRenderer(%q) unexpected error = %v", name, err)
+		}
+	}
+	if _, err := NewRenderer("latex", "42", nil); err == nil {
+		t.Errorf("NewRenderer(%q) expected error", "latex")
+	}
+}
@@ -0,0 +1,323 @@
+package forms
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/gunnsth/pkcs7"
+	"github.com/unidoc/unipdf/v3/annotator"
+	"github.com/unidoc/unipdf/v3/core"
+	"github.com/unidoc/unipdf/v3/creator"
+	"github.com/unidoc/unipdf/v3/model"
+	"github.com/unidoc/unipdf/v3/model/sighandler"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// default signature field size and offset from the bottom right corner of
+// the page, mm.
+const (
+	defSignWidth   = 70
+	defSignHeight  = 20
+	defSignRight   = 10
+	defSignBottom  = 20
+	signFontSize   = 8
+	signDateFormat = "2006-01-02 15:04:05 -07:00"
+)
+
+// SignOptions are the invoice signature options.
+type SignOptions struct {
+	Reason    string `yaml:",omitempty"`
+	Location  string `yaml:",omitempty"`
+	Page      int    `yaml:",omitempty"` // page of the signature field, default is the first page, negative counts from the last page
+	Field     *Box   `yaml:",omitempty"` // signature field, mm from the top left corner of the page, default is the bottom right corner
+	Invisible bool   `yaml:",omitempty"` // sign without the visible signature field
+}
+
+// Validate checks the signature field position.
+func (o *SignOptions) Validate() error {
+	if o == nil || o.Field == nil {
+		return nil
+	}
+	if o.Field.X < 0 || o.Field.Y < 0 || o.Field.Width <= 0 || o.Field.Height <= 0 {
+		return fmt.Errorf("sign: invalid signature field %+v, size must be positive", *o.Field)
+	}
+	return nil
+}
+
+// Signer signs the invoice PDFs with the plain PKCS#7 detached signature
+// (adbe.pkcs7.detached).  It is not the PAdES signature (ETSI.CAdES.detached),
+// that some validators require for the advanced electronic signature.
+type Signer struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+	opts SignOptions
+}
+
+// NewSigner creates the signer with the RSA key and certificate from the
+// PKCS#12 data.
+func NewSigner(p12 []byte, password string, opts *SignOptions) (*Signer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	key, cert, err := pkcs12.Decode(p12, password)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %s", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("sign: unsupported key type %T, only RSA keys are supported", key)
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("sign: certificate %q is valid from %s to %s", cert.Subject.CommonName, cert.NotBefore.Format(signDateFormat), cert.NotAfter.Format(signDateFormat))
+	}
+	s := Signer{key: rsaKey, cert: cert}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return &s, nil
+}
+
+// LoadSigner creates the signer from the PKCS#12 key file.
+func LoadSigner(filename string, password string, opts *SignOptions) (*Signer, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(data, password, opts)
+}
+
+// Sign signs the PDF and writes it to w.  The signature is appended to the
+// PDF as the incremental update, so the embedded files, i.e. Factur-X xml,
+// are kept intact.
+func (s *Signer) Sign(w io.Writer, pdf []byte) error {
+	reader, err := model.NewPdfReader(bytes.NewReader(pdf))
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	appender, err := model.NewPdfAppender(reader)
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	handler, err := sighandler.NewAdobePKCS7Detached(s.key, s.cert)
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	now := time.Now()
+	sig := model.NewPdfSignature(handler)
+	sig.SetName(s.cert.Subject.CommonName)
+	sig.SetDate(now, "")
+	if s.opts.Reason != "" {
+		sig.SetReason(s.opts.Reason)
+	}
+	if s.opts.Location != "" {
+		sig.SetLocation(s.opts.Location)
+	}
+	if err := sig.Initialize(); err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+
+	pages, err := reader.GetNumPages()
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	pageNo := s.opts.Page
+	switch {
+	case pageNo == 0:
+		pageNo = 1
+	case pageNo < 0:
+		pageNo += pages + 1
+	}
+	if pageNo < 1 || pageNo > pages {
+		return fmt.Errorf("sign: page %d not found, the invoice has %d pages", s.opts.Page, pages)
+	}
+	page, err := reader.GetPage(pageNo)
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	field, err := s.field(sig, page, now)
+	if err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	if err := appender.Sign(pageNo, field); err != nil {
+		return fmt.Errorf("sign: %s", err)
+	}
+	return withUnipdfNotice(func() error { return appender.Write(w) })
+}
+
+// field returns the signature field on the page.
+func (s *Signer) field(sig *model.PdfSignature, page *model.PdfPage, date time.Time) (*model.PdfFieldSignature, error) {
+	if s.opts.Invisible {
+		field := model.NewPdfFieldSignature(sig)
+		field.Rect = core.MakeArrayFromFloats([]float64{0, 0, 0, 0})
+		return field, nil
+	}
+	mbox, err := page.GetMediaBox()
+	if err != nil {
+		return nil, err
+	}
+	pageW, pageH := mbox.Width()/creator.PPMM, mbox.Height()/creator.PPMM
+	box := Box{X: pageW - defSignRight - defSignWidth, Y: pageH - defSignBottom - defSignHeight, Width: defSignWidth, Height: defSignHeight}
+	if s.opts.Field != nil {
+		box = *s.opts.Field
+	}
+	// PDF coordinates start in the bottom left corner.
+	opts := annotator.NewSignatureFieldOpts()
+	opts.FontSize = signFontSize
+	opts.BorderSize = 0.5
+	opts.Rect = []float64{
+		mbox.Llx + box.X*creator.PPMM,
+		mbox.Lly + (pageH-box.Y-box.Height)*creator.PPMM,
+		mbox.Llx + (box.X+box.Width)*creator.PPMM,
+		mbox.Lly + (pageH-box.Y)*creator.PPMM,
+	}
+	lines := []*annotator.SignatureLine{
+		annotator.NewSignatureLine("Digitally signed by", s.cert.Subject.CommonName),
+		annotator.NewSignatureLine("Date", date.Format(signDateFormat)),
+	}
+	if s.opts.Reason != "" {
+		lines = append(lines, annotator.NewSignatureLine("Reason", s.opts.Reason))
+	}
+	if s.opts.Location != "" {
+		lines = append(lines, annotator.NewSignatureLine("Location", s.opts.Location))
+	}
+	return annotator.NewSignatureField(sig, lines, opts)
+}
+
+// SignFile signs the PDF file in place.
+func (s *Signer) SignFile(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := s.Sign(&buf, data); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// SignatureStatus is the result of the signature check.
+type SignatureStatus struct {
+	Name     string
+	Date     time.Time
+	Reason   string
+	Location string
+	Subject  string // signing certificate subject
+
+	Verified bool  // the signed content is not changed
+	Whole    bool  // the signature covers the whole document, not only the earlier revision
+	Trusted  bool  // the certificate chains to the trusted roots
+	Err      error // verification error, if not verified
+}
+
+// ErrNotSigned is returned by VerifyPDF, if the PDF has no signatures.
+var ErrNotSigned = errors.New("document is not signed")
+
+// VerifyPDF checks the PKCS#7 detached signatures of the PDF.  Certificates
+// are checked against the roots, if roots is nil, the system roots are used.
+func VerifyPDF(pdf []byte, roots *x509.CertPool) ([]SignatureStatus, error) {
+	reader, err := model.NewPdfReader(bytes.NewReader(pdf))
+	if err != nil {
+		return nil, err
+	}
+	if roots == nil {
+		if roots, err = x509.SystemCertPool(); err != nil {
+			roots = x509.NewCertPool()
+		}
+	}
+	var sts []SignatureStatus
+	if reader.AcroForm != nil {
+		for _, f := range reader.AcroForm.AllFields() {
+			field, ok := f.GetContext().(*model.PdfFieldSignature)
+			if !ok || field.V == nil {
+				continue
+			}
+			sts = append(sts, verifySignature(pdf, field.V, roots))
+		}
+	}
+	if len(sts) == 0 {
+		return nil, ErrNotSigned
+	}
+	return sts, nil
+}
+
+// verifySignature checks the signature of the pdf.
+func verifySignature(pdf []byte, sig *model.PdfSignature, roots *x509.CertPool) SignatureStatus {
+	st := SignatureStatus{
+		Name:     sig.Name.Decoded(),
+		Reason:   sig.Reason.Decoded(),
+		Location: sig.Location.Decoded(),
+	}
+	if sig.M != nil {
+		if date, err := model.NewPdfDate(sig.M.Decoded()); err == nil {
+			st.Date = date.ToGoTime()
+		}
+	}
+	if sig.SubFilter == nil || *sig.SubFilter != "adbe.pkcs7.detached" || sig.Contents == nil || sig.ByteRange == nil {
+		st.Err = errors.New("unsupported signature type")
+		return st
+	}
+
+	// signed content
+	var (
+		content bytes.Buffer
+		end     int64
+	)
+	br := sig.ByteRange.Elements()
+	for i := 0; i+1 < len(br); i += 2 {
+		off, err1 := core.GetNumberAsInt64(br[i])
+		n, err2 := core.GetNumberAsInt64(br[i+1])
+		if err1 != nil || err2 != nil || off < 0 || n < 0 || off+n > int64(len(pdf)) {
+			st.Err = fmt.Errorf("invalid byte range %s", sig.ByteRange)
+			return st
+		}
+		content.Write(pdf[off : off+n])
+		end = off + n
+	}
+	st.Whole = end == int64(len(pdf))
+
+	p7, err := pkcs7.Parse(sig.Contents.Bytes())
+	if err != nil {
+		st.Err = err
+		return st
+	}
+	if signer := p7.GetOnlySigner(); signer != nil {
+		st.Subject = signer.Subject.String()
+	}
+	p7.Content = content.Bytes()
+	if err := p7.Verify(); err != nil {
+		st.Err = err
+		return st
+	}
+	st.Verified = true
+	st.Trusted = p7.VerifyWithChain(roots) == nil
+	return st
+}
+
+// VerifyFile checks the signatures of the PDF file.
+func VerifyFile(filename string, roots *x509.CertPool) ([]SignatureStatus, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyPDF(data, roots)
+}
+
+// LoadRoots loads the PEM encoded trusted root certificates.
+func LoadRoots(filename string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no certificates found", filename)
+	}
+	return pool, nil
+}
@@ -0,0 +1,123 @@
+package forms
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+const (
+	testSignKey      = "testdata/signer.p12"
+	testSignPassword = "test"
+	testSignCert     = "testdata/signer.pem"
+)
+
+func testInvoicePDF(t *testing.T) []byte {
+	t.Helper()
+	f := NewInvoice("42", PgA4, nil, nil)
+	f.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+	var buf bytes.Buffer
+	if err := f.Write(&buf, &InvoiceFields{Date: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSigner_Sign(t *testing.T) {
+	roots, err := LoadRoots(testSignCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := testInvoicePDF(t)
+	tests := []struct {
+		name    string
+		opts    *SignOptions
+		roots   *x509.CertPool
+		wantErr bool
+	}{
+		{"default", nil, roots, false},
+		{"positioned on the last page", &SignOptions{Reason: "Invoice", Location: "Berlin", Page: -1, Field: &Box{X: 10, Y: 250, Width: 60, Height: 20}}, roots, false},
+		{"invisible, untrusted", &SignOptions{Invisible: true}, x509.NewCertPool(), false},
+		{"page out of range", &SignOptions{Page: 2}, roots, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := LoadSigner(testSignKey, testSignPassword, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			err = s.Sign(&buf, pdf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Signer.Sign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !bytes.HasPrefix(buf.Bytes(), pdf) {
+				t.Errorf("Signer.Sign() the original document is changed, want incremental update")
+			}
+			sts, err := VerifyPDF(buf.Bytes(), tt.roots)
+			if err != nil {
+				t.Fatalf("VerifyPDF() error = %v", err)
+			}
+			if len(sts) != 1 {
+				t.Fatalf("VerifyPDF() signatures = %d, want 1", len(sts))
+			}
+			st := sts[0]
+			if !st.Verified || !st.Whole || st.Err != nil {
+				t.Errorf("VerifyPDF() = %+v, want verified whole document", st)
+			}
+			if wantTrusted := tt.roots == roots; st.Trusted != wantTrusted {
+				t.Errorf("VerifyPDF() Trusted = %v, want %v", st.Trusted, wantTrusted)
+			}
+			if st.Name != "ACME Consulting" {
+				t.Errorf("VerifyPDF() Name = %q, want %q", st.Name, "ACME Consulting")
+			}
+			if tt.opts != nil && st.Reason != tt.opts.Reason {
+				t.Errorf("VerifyPDF() Reason = %q, want %q", st.Reason, tt.opts.Reason)
+			}
+
+			// the document changed after signing.
+			changed := append(buf.Bytes(), []byte("\n% changed\n")...)
+			sts, err = VerifyPDF(changed, tt.roots)
+			if err != nil {
+				t.Fatalf("VerifyPDF() changed error = %v", err)
+			}
+			if sts[0].Whole {
+				t.Errorf("VerifyPDF() changed document Whole = true")
+			}
+			// the signed content is tampered with.
+			tampered := append([]byte(nil), buf.Bytes()...)
+			tampered[len(pdf)/2] ^= 1
+			sts, err = VerifyPDF(tampered, tt.roots)
+			if err != nil {
+				t.Fatalf("VerifyPDF() tampered error = %v", err)
+			}
+			if sts[0].Verified || sts[0].Err == nil {
+				t.Errorf("VerifyPDF() tampered document is verified")
+			}
+		})
+	}
+}
+
+func TestVerifyPDF_notSigned(t *testing.T) {
+	if _, err := VerifyPDF(testInvoicePDF(t), nil); err != ErrNotSigned {
+		t.Errorf("VerifyPDF() error = %v, want %v", err, ErrNotSigned)
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	data, err := ioutil.ReadFile(testSignKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(data, "wrong", nil); err == nil {
+		t.Errorf("NewSigner() wrong password expected error")
+	}
+	if _, err := NewSigner(data, testSignPassword, &SignOptions{Field: &Box{Width: -1}}); err == nil {
+		t.Errorf("NewSigner() invalid field expected error")
+	}
+}
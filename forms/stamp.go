@@ -0,0 +1,206 @@
+package forms
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Invoice statuses.
+const (
+	StatusIssued = ""      // issued invoice, no stamp
+	StatusDraft  = "draft" // draft sent for approval
+	StatusPaid   = "paid"  // paid copy, i.e. the receipt
+	StatusVoid   = "void"  // cancelled invoice
+)
+
+// Stamp kinds.
+const (
+	StampWatermark = "watermark" // diagonal text across every page
+	StampCorner    = "corner"    // framed text in the top corner of the first page
+)
+
+const (
+	stampAlpha  = 0.25 // stamp opacity
+	stampAngle  = 15   // corner stamp rotation, degrees
+	stampSize   = 28   // corner stamp font size, pt
+	stampBorder = 0.8  // corner stamp frame line width, mm
+)
+
+// Stamp is the invoice status watermark or stamp.  Empty fields are taken
+// from the invoice status.
+type Stamp struct {
+	Text  string `yaml:",omitempty"` // text, default is the status label
+	Color string `yaml:",omitempty"` // hex colour, i.e. "#c00000"
+	Kind  string `yaml:",omitempty"` // "watermark" or "corner"
+}
+
+// statusStamps are the default stamps of the invoice statuses.
+var statusStamps = map[string]Stamp{
+	StatusDraft: {Color: "#808080", Kind: StampWatermark},
+	StatusPaid:  {Color: "#2e8b57", Kind: StampCorner},
+	StatusVoid:  {Color: "#c00000", Kind: StampWatermark},
+}
+
+// statusLabels are the stamp label keys of the statuses.
+var statusLabels = map[string]string{
+	StatusDraft: LabelDraft,
+	StatusPaid:  LabelPaid,
+	StatusVoid:  LabelVoid,
+}
+
+// Statuses returns the invoice statuses that have a stamp.
+func Statuses() []string {
+	return []string{StatusDraft, StatusPaid, StatusVoid}
+}
+
+// ValidStatus checks that the invoice status is known.
+func ValidStatus(status string) error {
+	if status == StatusIssued {
+		return nil
+	}
+	if _, ok := statusStamps[strings.ToLower(status)]; !ok {
+		return fmt.Errorf("unknown invoice status %q, must be one of: %s", status, strings.Join(Statuses(), ", "))
+	}
+	return nil
+}
+
+// Validate checks the stamp colour and kind.
+func (s *Stamp) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Color != "" {
+		if _, err := parseColor(s.Color); err != nil {
+			return fmt.Errorf("stamp: %s", err)
+		}
+	}
+	switch s.Kind {
+	case "", StampWatermark, StampCorner:
+	default:
+		return fmt.Errorf("stamp: unknown kind %q, must be %q or %q", s.Kind, StampWatermark, StampCorner)
+	}
+	return nil
+}
+
+// stamp returns the stamp of the invoice status, with the configured stamp
+// fields applied, or nil, if the invoice has no stamp.
+func (v *InvoiceFields) stamp(loc *Locale) (*Stamp, error) {
+	if err := ValidStatus(v.Status); err != nil {
+		return nil, err
+	}
+	if err := v.Stamp.Validate(); err != nil {
+		return nil, err
+	}
+	status := strings.ToLower(v.Status)
+	st, ok := statusStamps[status]
+	if !ok {
+		if v.Stamp == nil || v.Stamp.Text == "" {
+			return nil, nil
+		}
+		// custom stamp on the issued invoice, i.e. "COPY"
+		st = statusStamps[StatusDraft]
+	}
+	st.Text, _ = loc.Labels(statusLabels[status])
+	if v.Stamp != nil {
+		st.Text = nvl(v.Stamp.Text, st.Text)
+		st.Color = nvl(v.Stamp.Color, st.Color)
+		st.Kind = nvl(v.Stamp.Kind, st.Kind)
+	}
+	return &st, nil
+}
+
+// color returns the stamp colour.  The colour is validated by stamp.
+func (s *Stamp) color() color.RGBA {
+	c, _ := parseColor(s.Color)
+	return c
+}
+
+// stamp prints the stamp: watermark on every page, or the corner stamp on
+// the first page.  It is called when the invoice is laid out, so the stamp
+// is printed over the content.
+func (f *InvoiceForm) stamp(st *Stamp) {
+	if st == nil {
+		return
+	}
+	last := f.pdf.PageNo()
+	family, style := f.family, f.style
+	size, _ := f.pdf.GetFontSize()
+	r, g, b := f.pdf.GetTextColor()
+	dr, dg, db := f.pdf.GetDrawColor()
+	lw := f.pdf.GetLineWidth()
+
+	if st.Kind == StampCorner {
+		f.pdf.SetPage(1)
+		f.cornerStamp(st)
+	} else {
+		for p := 1; p <= last; p++ {
+			f.pdf.SetPage(p)
+			f.watermark(st)
+		}
+	}
+
+	f.pdf.SetPage(last)
+	f.setFont(family, style, size)
+	f.pdf.SetTextColor(r, g, b)
+	f.pdf.SetDrawColor(dr, dg, db)
+	f.pdf.SetLineWidth(lw)
+}
+
+// watermark prints the stamp text diagonally across the current page.
+func (f *InvoiceForm) watermark(st *Stamp) {
+	c := st.color()
+	cx, cy := f.maxX/2, f.maxY/2
+	angle := math.Atan2(f.maxY, f.maxX) * 180 / math.Pi
+
+	// the text is scaled to 70% of the page diagonal
+	f.setFont(f.s.Title.FamilyStr, "B", 100)
+	w := f.width(st.Text)
+	if w == 0 {
+		return
+	}
+	size := 100 * 0.7 * math.Hypot(f.maxX, f.maxY) / w
+	f.setFont(f.s.Title.FamilyStr, "B", size)
+	w = f.width(st.Text)
+	_, h := f.pdf.GetFontSize()
+
+	f.pdf.TransformBegin()
+	f.pdf.SetAlpha(stampAlpha, "Normal")
+	f.pdf.TransformRotate(angle, cx, cy)
+	f.pdf.SetTextColor(int(c.R), int(c.G), int(c.B))
+	f.pdf.Text(cx-w/2, cy+h/3, f.text(st.Text))
+	f.pdf.SetAlpha(1, "Normal")
+	f.pdf.TransformEnd()
+}
+
+// cornerStamp prints the framed stamp text at the top of the current page,
+// between the title and the logo.
+func (f *InvoiceForm) cornerStamp(st *Stamp) {
+	const pad = 2.0
+	c := st.color()
+	f.setFont(f.s.Title.FamilyStr, "B", stampSize)
+	w := f.width(st.Text) + 2*pad
+	_, h := f.pdf.GetFontSize()
+	h += 2 * pad
+	cx, cy := f.m.Left+f.w*0.55, f.m.Top+h
+
+	f.pdf.TransformBegin()
+	f.pdf.SetAlpha(2*stampAlpha, "Normal")
+	f.pdf.TransformRotate(stampAngle, cx, cy)
+	f.pdf.SetDrawColor(int(c.R), int(c.G), int(c.B))
+	f.pdf.SetLineWidth(stampBorder)
+	f.pdf.RoundedRect(cx-w/2, cy-h/2, w, h, pad, "1234", "D")
+	f.pdf.SetTextColor(int(c.R), int(c.G), int(c.B))
+	f.pdf.Text(cx-w/2+pad, cy+(h-2*pad)/3, f.text(st.Text))
+	f.pdf.SetAlpha(1, "Normal")
+	f.pdf.TransformEnd()
+}
+
+// nvl returns s, or def, if s is empty.
+func nvl(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
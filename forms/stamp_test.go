@@ -0,0 +1,92 @@
+package forms
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInvoiceFields_stamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  InvoiceFields
+		want    *Stamp
+		wantErr bool
+	}{
+		{"issued", InvoiceFields{}, nil, false},
+		{"stamp without text", InvoiceFields{Stamp: &Stamp{Color: "#000"}}, nil, false},
+		{"draft", InvoiceFields{Status: "DRAFT"}, &Stamp{Text: "DRAFT", Color: "#808080", Kind: StampWatermark}, false},
+		{"paid localized", InvoiceFields{Status: StatusPaid, Language: "de", SecondLanguage: "en"}, &Stamp{Text: "BEZAHLT", Color: "#2e8b57", Kind: StampCorner}, false},
+		{"void overridden", InvoiceFields{Status: StatusVoid, Stamp: &Stamp{Text: "CANCELLED", Kind: StampCorner}}, &Stamp{Text: "CANCELLED", Color: "#c00000", Kind: StampCorner}, false},
+		{"custom", InvoiceFields{Stamp: &Stamp{Text: "COPY", Color: "#00f"}}, &Stamp{Text: "COPY", Color: "#00f", Kind: StampWatermark}, false},
+		{"unknown status", InvoiceFields{Status: "sent"}, nil, true},
+		{"invalid colour", InvoiceFields{Status: StatusPaid, Stamp: &Stamp{Color: "green"}}, nil, true},
+		{"invalid kind", InvoiceFields{Status: StatusPaid, Stamp: &Stamp{Kind: "round"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := NewLocale(tt.fields.Language, tt.fields.SecondLanguage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.fields.stamp(loc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InvoiceFields.stamp() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InvoiceFields.stamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvoiceForm_stamp(t *testing.T) {
+	core := defStyle
+	for _, font := range []*Font{&core.Title, &core.Heading, &core.Body, &core.Address, &core.TableHead, &core.TableRowOdd, &core.TableRowEven} {
+		font.FamilyStr = helvetica
+	}
+	day := time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+	var rows []AppendixRow
+	for i := 0; i < 20; i++ {
+		rows = append(rows, AppendixRow{Date: day, Start: day, End: day.Add(time.Hour), Description: "work", Hours: decimal.New(1, 0)})
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		wantPages int
+		want      string
+		wantCount int // stamps in the output
+	}{
+		{"issued", StatusIssued, 2, "(DRAFT)", 0},
+		{"draft", StatusDraft, 2, "(DRAFT)", 2},
+		{"paid", StatusPaid, 2, "(PAID)", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewInvoice("42", PgA4, nil, &core)
+			f.Fpdf().SetCompression(false)
+			f.AddEntry("work", "20.00", "100.00", "2000.00").SetTotal("", "2000.00")
+			f.AddAppendixGroup("ACME-1: work", rows, decimal.New(20, 0))
+			var buf bytes.Buffer
+			if err := f.Write(&buf, &InvoiceFields{Date: time.Now(), Status: tt.status}); err != nil {
+				t.Fatalf("InvoiceForm.Write() error = %v", err)
+			}
+			if got := f.Fpdf().PageCount(); got != tt.wantPages {
+				t.Errorf("InvoiceForm.Write() pages = %d, want %d", got, tt.wantPages)
+			}
+			if got := strings.Count(buf.String(), tt.want); got != tt.wantCount {
+				t.Errorf("InvoiceForm.Write() stamps %s = %d, want %d", tt.want, got, tt.wantCount)
+			}
+			// page numbers are printed after the stamp on the last page
+			if !strings.Contains(buf.String(), "(Page 2 of 2)") {
+				t.Errorf("InvoiceForm.Write() output does not contain the last page number")
+			}
+		})
+	}
+}
@@ -0,0 +1,1050 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/vector"
+)
+
+// svgKappa is the control point distance of the cubic Bézier quarter circle.
+const svgKappa = 0.5522847498
+
+// svgImage is the SVG image subset, that is enough for logos: paths and
+// basic shapes with solid fill and stroke, opacity, groups, transforms and
+// simple CSS rules.  Gradients and patterns are drawn with the fallback
+// colour.  Documents with gradients without the fallback, text, <use>,
+// clipping, masks or filters are rejected, as they can't be drawn as
+// intended.
+type svgImage struct {
+	width, height float64    // intrinsic size, px
+	viewBox       [4]float64 // min x, min y, width, height
+	shapes        []svgShape
+}
+
+// svgShape is the shape in the viewBox coordinates.
+type svgShape struct {
+	path        []svgSeg
+	fill        *color.NRGBA // nil is no fill
+	stroke      *color.NRGBA // nil is no stroke
+	strokeWidth float64
+	evenOdd     bool
+}
+
+// svgSeg is the path segment: moveto 'M', lineto 'L', cubic Bézier 'C' or
+// closepath 'Z'.  Only 'C' uses all points: two control points and the end
+// point.
+type svgSeg struct {
+	cmd byte
+	pts [3]svgPoint
+}
+
+type svgPoint struct{ x, y float64 }
+
+// svgMatrix is the affine transformation [a c e; b d f].
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(p svgPoint) svgPoint {
+	return svgPoint{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+// scale returns the average scale factor, for the stroke width.
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// svgStyle is the inherited presentation attributes.  The opacity of the
+// group is applied to each of its shapes.
+type svgStyle struct {
+	ctm           svgMatrix
+	color         color.NRGBA // currentColor
+	fill          *color.NRGBA
+	stroke        *color.NRGBA
+	strokeWidth   float64
+	evenOdd       bool
+	opacity       float64
+	fillOpacity   float64
+	strokeOpacity float64
+}
+
+// svgSkip are the elements that are not drawn.
+var svgSkip = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "symbol": true, "pattern": true,
+	"marker": true, "linearGradient": true, "radialGradient": true, "style": true,
+	"title": true, "desc": true, "metadata": true, "script": true, "filter": true,
+}
+
+// svgUnsupported are the elements that would be drawn, but are not
+// supported.
+var svgUnsupported = map[string]bool{
+	"use": true, "text": true, "image": true, "foreignObject": true, "switch": true,
+}
+
+// svgUnsupportedAttrs are the attributes referencing unsupported effects.
+var svgUnsupportedAttrs = []string{"clip-path", "mask", "filter"}
+
+// isSVG reports whether data looks like the SVG document.
+func isSVG(data []byte) bool {
+	data = bytes.TrimLeft(data, "\xef\xbb\xbf \t\r\n")
+	if !bytes.HasPrefix(data, []byte("<")) {
+		return false
+	}
+	n := len(data)
+	if n > 1024 {
+		n = 1024
+	}
+	return bytes.Contains(data[:n], []byte("<svg"))
+}
+
+// parseSVG parses the SVG document.
+func parseSVG(data []byte) (*svgImage, error) {
+	css, err := parseSVGStyles(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	var (
+		img    *svgImage
+		styles []svgStyle
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %s", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			attrs := css.attrs(el)
+			if img == nil {
+				if el.Name.Local != "svg" {
+					return nil, fmt.Errorf("svg: root element is %q, not svg", el.Name.Local)
+				}
+				if img, err = newSVGImage(attrs); err != nil {
+					return nil, err
+				}
+				black := color.NRGBA{A: 0xff}
+				styles = append(styles, svgStyle{ctm: svgIdentity, color: black, fill: &black, strokeWidth: 1, opacity: 1, fillOpacity: 1, strokeOpacity: 1})
+			}
+			if svgSkip[el.Name.Local] || attrs["display"] == "none" {
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("svg: %s", err)
+				}
+				continue
+			}
+			if svgUnsupported[el.Name.Local] {
+				return nil, fmt.Errorf("svg: <%s> elements are not supported", el.Name.Local)
+			}
+			for _, key := range svgUnsupportedAttrs {
+				if v, ok := attrs[key]; ok && v != "none" {
+					return nil, fmt.Errorf("svg: %s is not supported", key)
+				}
+			}
+			st, err := styles[len(styles)-1].inherit(attrs)
+			if err != nil {
+				return nil, err
+			}
+			styles = append(styles, st)
+			path, err := svgElementPath(el.Name.Local, attrs)
+			if err != nil {
+				return nil, err
+			}
+			if len(path) > 0 && (st.fill != nil || st.stroke != nil) {
+				img.shapes = append(img.shapes, st.shape(path))
+			}
+		case xml.EndElement:
+			if len(styles) > 0 {
+				styles = styles[:len(styles)-1]
+			}
+		}
+	}
+	if img == nil {
+		return nil, errors.New("svg: no svg element")
+	}
+	return img, nil
+}
+
+// svgDeclarations sets the properties of the CSS declarations, i.e.
+// "fill:red; stroke:none", to attrs.
+func svgDeclarations(attrs map[string]string, decls string) {
+	for _, decl := range strings.Split(decls, ";") {
+		if i := strings.IndexByte(decl, ':'); i > 0 {
+			v := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(decl[i+1:]), "!important"))
+			attrs[strings.TrimSpace(decl[:i])] = v
+		}
+	}
+}
+
+// newSVGImage returns the image with the size and viewBox of the root
+// element.  Missing size is taken from the viewBox and vice versa.
+func newSVGImage(attrs map[string]string) (*svgImage, error) {
+	img := svgImage{width: svgLength(attrs["width"]), height: svgLength(attrs["height"])}
+	if vb := svgNumbers(attrs["viewBox"]); len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+		copy(img.viewBox[:], vb)
+		switch {
+		case img.width == 0 && img.height == 0:
+			img.width, img.height = vb[2], vb[3]
+		case img.width == 0:
+			img.width = img.height * vb[2] / vb[3]
+		case img.height == 0:
+			img.height = img.width * vb[3] / vb[2]
+		}
+	} else {
+		img.viewBox = [4]float64{0, 0, img.width, img.height}
+	}
+	if img.width <= 0 || img.height <= 0 {
+		return nil, errors.New("svg: image size is not set, width and height or viewBox are required")
+	}
+	return &img, nil
+}
+
+// svgUnits are the length units in px.
+var svgUnits = map[string]float64{
+	"": 1, "px": 1, "pt": 96.0 / 72, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96,
+}
+
+// svgLength returns the length in px, or 0, if the length is relative or
+// invalid.
+func svgLength(s string) float64 {
+	num := strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyz%")
+	unit, ok := svgUnits[s[len(num):]]
+	if !ok {
+		return 0
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0
+	}
+	return v * unit
+}
+
+// svgNumbers returns the numbers of the list separated with spaces or
+// commas.
+func svgNumbers(s string) []float64 {
+	sc := svgScanner{s: s}
+	var nums []float64
+	for {
+		v, ok := sc.number()
+		if !ok {
+			return nums
+		}
+		nums = append(nums, v)
+	}
+}
+
+// inherit returns the style of the element with the attributes applied.
+func (st svgStyle) inherit(attrs map[string]string) (svgStyle, error) {
+	var err error
+	if s, ok := attrs["color"]; ok {
+		if st.color, err = svgColor(s, st.color); err != nil {
+			return st, err
+		}
+	}
+	if s, ok := attrs["fill"]; ok {
+		if st.fill, err = svgPaint(s, st.color); err != nil {
+			return st, err
+		}
+	}
+	if s, ok := attrs["stroke"]; ok {
+		if st.stroke, err = svgPaint(s, st.color); err != nil {
+			return st, err
+		}
+	}
+	opacities := []struct {
+		key   string
+		v     *float64
+		inner float64 // the opacity nests, and the others replace the inherited value
+	}{
+		{"opacity", &st.opacity, st.opacity},
+		{"fill-opacity", &st.fillOpacity, 1},
+		{"stroke-opacity", &st.strokeOpacity, 1},
+	}
+	for _, o := range opacities {
+		if s, ok := attrs[o.key]; ok {
+			a, err := svgAlpha(s)
+			if err != nil {
+				return st, fmt.Errorf("svg: invalid %s %q", o.key, s)
+			}
+			*o.v = a * o.inner
+		}
+	}
+	if s, ok := attrs["stroke-width"]; ok {
+		st.strokeWidth = svgLength(s)
+	}
+	if s, ok := attrs["fill-rule"]; ok {
+		st.evenOdd = s == "evenodd"
+	}
+	if s, ok := attrs["transform"]; ok {
+		m, err := svgTransform(s)
+		if err != nil {
+			return st, err
+		}
+		st.ctm = st.ctm.mul(m)
+	}
+	return st, nil
+}
+
+// shape returns the shape of the path, transformed to the viewBox
+// coordinates.
+func (st svgStyle) shape(path []svgSeg) svgShape {
+	for i := range path {
+		for j := range path[i].pts {
+			path[i].pts[j] = st.ctm.apply(path[i].pts[j])
+		}
+	}
+	return svgShape{
+		path:        path,
+		fill:        withAlpha(st.fill, st.opacity*st.fillOpacity),
+		stroke:      withAlpha(st.stroke, st.opacity*st.strokeOpacity),
+		strokeWidth: st.strokeWidth * st.ctm.scale(),
+		evenOdd:     st.evenOdd,
+	}
+}
+
+// withAlpha returns the colour with the alpha multiplied by a, or nil, if
+// the colour is nil or fully transparent.
+func withAlpha(c *color.NRGBA, a float64) *color.NRGBA {
+	if c == nil {
+		return nil
+	}
+	ret := *c
+	ret.A = uint8(math.Round(float64(c.A) * math.Max(0, math.Min(1, a))))
+	if ret.A == 0 {
+		return nil
+	}
+	return &ret
+}
+
+// svgPaint returns the paint colour, or nil for "none".  Gradients and
+// patterns are painted with the fallback colour, i.e. "url(#grad) #c00000",
+// and without the fallback they are not supported.
+func svgPaint(s string, current color.NRGBA) (*color.NRGBA, error) {
+	if strings.HasPrefix(s, "url(") {
+		fallback := ""
+		if i := strings.IndexByte(s, ')'); i > 0 {
+			fallback = strings.TrimSpace(s[i+1:])
+		}
+		if fallback == "" {
+			return nil, fmt.Errorf("svg: paint %q is not supported, gradients and patterns require the fallback colour", s)
+		}
+		s = fallback
+	}
+	switch strings.ToLower(s) {
+	case "none", "transparent":
+		return nil, nil
+	}
+	c, err := svgColor(s, current)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// svgColor parses the CSS colour: hex, rgb(), rgba(), hsl(), hsla(),
+// the named colour or currentColor.
+func svgColor(s string, current color.NRGBA) (color.NRGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := colornames.Map[s]; ok {
+		return color.NRGBA{c.R, c.G, c.B, c.A}, nil
+	}
+	invalid := fmt.Errorf("svg: unsupported colour %q", s)
+	switch {
+	case s == "currentcolor":
+		return current, nil
+	case s == "transparent":
+		return color.NRGBA{}, nil
+	case strings.HasPrefix(s, "#"):
+		hex := s[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			var b strings.Builder
+			for _, r := range hex {
+				b.WriteRune(r)
+				b.WriteRune(r)
+			}
+			hex = b.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			return color.NRGBA{}, invalid
+		}
+		return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+	}
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return color.NRGBA{}, invalid
+	}
+	fn := s[:open]
+	args := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool {
+		return r == ',' || r == '/' || r == ' ' || r == '\t'
+	})
+	if len(args) != 3 && len(args) != 4 {
+		return color.NRGBA{}, invalid
+	}
+	alpha := 1.0
+	if len(args) == 4 {
+		a, err := svgAlpha(args[3])
+		if err != nil {
+			return color.NRGBA{}, invalid
+		}
+		alpha = a
+	}
+	var v [3]float64
+	switch fn {
+	case "rgb", "rgba":
+		for i := range v {
+			n, err := svgChannel(args[i], 255)
+			if err != nil {
+				return color.NRGBA{}, invalid
+			}
+			v[i] = n / 255
+		}
+	case "hsl", "hsla":
+		h, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+		if err != nil {
+			return color.NRGBA{}, invalid
+		}
+		sat, err1 := svgChannel(args[1], 1)
+		light, err2 := svgChannel(args[2], 1)
+		if err1 != nil || err2 != nil || !strings.HasSuffix(args[1], "%") || !strings.HasSuffix(args[2], "%") {
+			return color.NRGBA{}, invalid
+		}
+		v = hslToRGB(h, sat, light)
+	default:
+		return color.NRGBA{}, invalid
+	}
+	u8 := func(f float64) uint8 { return uint8(math.Round(math.Max(0, math.Min(1, f)) * 255)) }
+	return color.NRGBA{u8(v[0]), u8(v[1]), u8(v[2]), u8(alpha)}, nil
+}
+
+// svgChannel parses the colour channel, the number in range 0..max or
+// the percentage.
+func svgChannel(s string, max float64) (float64, error) {
+	pct := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if pct {
+		v = v * max / 100
+	}
+	return math.Max(0, math.Min(max, v)), nil
+}
+
+// svgAlpha parses the opacity, the number in range 0..1 or the percentage.
+func svgAlpha(s string) (float64, error) {
+	return svgChannel(strings.TrimSpace(s), 1)
+}
+
+// hslToRGB converts the hue in degrees, saturation and lightness in range
+// 0..1 to rgb.
+func hslToRGB(h, s, l float64) [3]float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	return [3]float64{r + m, g + m, b + m}
+}
+
+// svgTransform parses the transform list.
+func svgTransform(s string) (svgMatrix, error) {
+	m := svgIdentity
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t\r\n,") {
+		open, end := strings.IndexByte(s, '('), strings.IndexByte(s, ')')
+		if open < 0 || end < open {
+			return m, fmt.Errorf("svg: invalid transform %q", s)
+		}
+		name, args := strings.TrimSpace(s[:open]), svgNumbers(s[open+1:end])
+		s = s[end+1:]
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t svgMatrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("svg: matrix requires 6 arguments, got %d", len(args))
+			}
+			copy(t[:], args)
+		case "translate":
+			t = svgMatrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = svgMatrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			sin, cos := math.Sincos(a)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.
+				mul(svgMatrix{cos, sin, -sin, cos, 0, 0}).
+				mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = svgMatrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = svgMatrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return m, fmt.Errorf("svg: unknown transform %q", name)
+		}
+		m = m.mul(t)
+	}
+	return m, nil
+}
+
+// svgElementPath returns the path of the shape element, or nil, if the
+// element is not a shape.
+func svgElementPath(name string, attrs map[string]string) ([]svgSeg, error) {
+	num := func(key string) float64 { return svgLength(attrs[key]) }
+	var p svgPath
+	switch name {
+	case "path":
+		return parseSVGPath(attrs["d"])
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return nil, nil
+		}
+		rx, ry := num("rx"), num("ry")
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+		p.moveTo(svgPoint{x + rx, y})
+		p.lineTo(svgPoint{x + w - rx, y})
+		p.corner(svgPoint{x + w, y + ry}, rx, ry, 0)
+		p.lineTo(svgPoint{x + w, y + h - ry})
+		p.corner(svgPoint{x + w - rx, y + h}, rx, ry, 1)
+		p.lineTo(svgPoint{x + rx, y + h})
+		p.corner(svgPoint{x, y + h - ry}, rx, ry, 2)
+		p.lineTo(svgPoint{x, y + ry})
+		p.corner(svgPoint{x + rx, y}, rx, ry, 3)
+		p.close()
+	case "circle", "ellipse":
+		cx, cy := num("cx"), num("cy")
+		rx, ry := num("rx"), num("ry")
+		if name == "circle" {
+			rx, ry = num("r"), num("r")
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil, nil
+		}
+		p.moveTo(svgPoint{cx + rx, cy})
+		p.corner(svgPoint{cx, cy + ry}, rx, ry, 1)
+		p.corner(svgPoint{cx - rx, cy}, rx, ry, 2)
+		p.corner(svgPoint{cx, cy - ry}, rx, ry, 3)
+		p.corner(svgPoint{cx + rx, cy}, rx, ry, 0)
+		p.close()
+	case "line":
+		p.moveTo(svgPoint{num("x1"), num("y1")})
+		p.lineTo(svgPoint{num("x2"), num("y2")})
+	case "polyline", "polygon":
+		pts := svgNumbers(attrs["points"])
+		for i := 0; i+1 < len(pts); i += 2 {
+			if i == 0 {
+				p.moveTo(svgPoint{pts[0], pts[1]})
+			} else {
+				p.lineTo(svgPoint{pts[i], pts[i+1]})
+			}
+		}
+		if name == "polygon" && len(p.segs) > 0 {
+			p.close()
+		}
+	}
+	return p.segs, nil
+}
+
+// svgPath builds the path of absolute segments.
+type svgPath struct {
+	segs       []svgSeg
+	cur, start svgPoint
+}
+
+func (p *svgPath) moveTo(pt svgPoint) {
+	p.segs = append(p.segs, svgSeg{cmd: 'M', pts: [3]svgPoint{pt}})
+	p.cur, p.start = pt, pt
+}
+
+func (p *svgPath) lineTo(pt svgPoint) {
+	p.segs = append(p.segs, svgSeg{cmd: 'L', pts: [3]svgPoint{pt}})
+	p.cur = pt
+}
+
+func (p *svgPath) cubicTo(c1, c2, pt svgPoint) {
+	p.segs = append(p.segs, svgSeg{cmd: 'C', pts: [3]svgPoint{c1, c2, pt}})
+	p.cur = pt
+}
+
+func (p *svgPath) close() {
+	p.segs = append(p.segs, svgSeg{cmd: 'Z'})
+	p.cur = p.start
+}
+
+// corner draws the quarter ellipse to pt.  The quadrant is the corner of the
+// rounded rectangle, clockwise from the top right: 0 – top right, 1 – bottom
+// right, 2 – bottom left, 3 – top left.
+func (p *svgPath) corner(pt svgPoint, rx, ry float64, quadrant int) {
+	if rx == 0 || ry == 0 {
+		p.lineTo(pt)
+		return
+	}
+	kx, ky := rx*svgKappa, ry*svgKappa
+	c := p.cur
+	var c1, c2 svgPoint
+	switch quadrant {
+	case 0: // along the top edge, down the right edge
+		c1, c2 = svgPoint{c.x + kx, c.y}, svgPoint{pt.x, pt.y - ky}
+	case 1: // down the right edge, along the bottom edge
+		c1, c2 = svgPoint{c.x, c.y + ky}, svgPoint{pt.x + kx, pt.y}
+	case 2: // along the bottom edge, up the left edge
+		c1, c2 = svgPoint{c.x - kx, c.y}, svgPoint{pt.x, pt.y + ky}
+	default: // up the left edge, along the top edge
+		c1, c2 = svgPoint{c.x, c.y - ky}, svgPoint{pt.x - kx, pt.y}
+	}
+	p.cubicTo(c1, c2, pt)
+}
+
+// arcTo draws the elliptical arc to pt, converted to cubic Béziers.
+func (p *svgPath) arcTo(rx, ry, rotation float64, large, sweep bool, pt svgPoint) {
+	p0 := p.cur
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || p0 == pt {
+		p.lineTo(pt)
+		return
+	}
+	// endpoint to centre parameterisation, SVG 1.1 F.6.5
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (p0.x-pt.x)/2, (p0.y-pt.y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (p0.x+pt.x)/2
+	cy := sin*cx1 + cos*cy1 + (p0.y+pt.y)/2
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	t := 4.0 / 3 * math.Tan(step/4)
+	point := func(a float64) (svgPoint, svgPoint) {
+		sa, ca := math.Sincos(a)
+		// point and derivative on the ellipse
+		x, y := rx*ca, ry*sa
+		tx, ty := -rx*sa, ry*ca
+		return svgPoint{cx + cos*x - sin*y, cy + sin*x + cos*y},
+			svgPoint{cos*tx - sin*ty, sin*tx + cos*ty}
+	}
+	a := theta
+	from, d0 := point(a)
+	for i := 0; i < n; i++ {
+		a += step
+		to, d1 := point(a)
+		if i == n-1 {
+			to = pt
+		}
+		p.cubicTo(
+			svgPoint{from.x + t*d0.x, from.y + t*d0.y},
+			svgPoint{to.x - t*d1.x, to.y - t*d1.y},
+			to)
+		from, d0 = to, d1
+	}
+}
+
+// parseSVGPath parses the path data.
+func parseSVGPath(d string) ([]svgSeg, error) {
+	var (
+		p        svgPath
+		sc       = svgScanner{s: d}
+		cmd      byte
+		ctrl     svgPoint // last control point, for smooth curves
+		lastCurv byte     // last curve command, 'C' or 'Q'
+	)
+	for {
+		sc.skipSpace()
+		if sc.eof() {
+			break
+		}
+		if c := sc.s[sc.i]; (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			cmd = c
+			sc.i++
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("svg: path %q: expecting command at %d", d, sc.i)
+		}
+		rel := cmd >= 'a'
+		pt := func(x, y float64) svgPoint {
+			if rel {
+				return svgPoint{p.cur.x + x, p.cur.y + y}
+			}
+			return svgPoint{x, y}
+		}
+		args, err := sc.args(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("svg: path %q: %s", d, err)
+		}
+		curv := byte(0)
+		switch cmd | 0x20 {
+		case 'm':
+			p.moveTo(pt(args[0], args[1]))
+			// subsequent pairs are implicit lineto
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+		case 'l':
+			p.lineTo(pt(args[0], args[1]))
+		case 'h':
+			x := args[0]
+			if rel {
+				x += p.cur.x
+			}
+			p.lineTo(svgPoint{x, p.cur.y})
+		case 'v':
+			y := args[0]
+			if rel {
+				y += p.cur.y
+			}
+			p.lineTo(svgPoint{p.cur.x, y})
+		case 'c':
+			c1, c2, end := pt(args[0], args[1]), pt(args[2], args[3]), pt(args[4], args[5])
+			p.cubicTo(c1, c2, end)
+			ctrl, curv = c2, 'C'
+		case 's':
+			c1 := p.cur
+			if lastCurv == 'C' {
+				c1 = svgPoint{2*p.cur.x - ctrl.x, 2*p.cur.y - ctrl.y}
+			}
+			c2, end := pt(args[0], args[1]), pt(args[2], args[3])
+			p.cubicTo(c1, c2, end)
+			ctrl, curv = c2, 'C'
+		case 'q', 't':
+			var q, end svgPoint
+			if cmd|0x20 == 'q' {
+				q, end = pt(args[0], args[1]), pt(args[2], args[3])
+			} else {
+				q = p.cur
+				if lastCurv == 'Q' {
+					q = svgPoint{2*p.cur.x - ctrl.x, 2*p.cur.y - ctrl.y}
+				}
+				end = pt(args[0], args[1])
+			}
+			c := p.cur
+			p.cubicTo(
+				svgPoint{c.x + 2.0/3*(q.x-c.x), c.y + 2.0/3*(q.y-c.y)},
+				svgPoint{end.x + 2.0/3*(q.x-end.x), end.y + 2.0/3*(q.y-end.y)},
+				end)
+			ctrl, curv = q, 'Q'
+		case 'a':
+			p.arcTo(args[0], args[1], args[2], args[3] != 0, args[4] != 0, pt(args[5], args[6]))
+		case 'z':
+			p.close()
+			cmd = 0 // closepath takes no arguments
+		default:
+			return nil, fmt.Errorf("svg: path %q: unknown command %q", d, cmd)
+		}
+		lastCurv = curv
+	}
+	return p.segs, nil
+}
+
+// svgArgs is the number of arguments of the path commands.
+var svgArgs = map[byte]int{'m': 2, 'l': 2, 'h': 1, 'v': 1, 'c': 6, 's': 4, 'q': 4, 't': 2, 'a': 7, 'z': 0}
+
+// svgScanner scans the numbers of the path data and attribute lists.
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) eof() bool { return sc.i >= len(sc.s) }
+
+func (sc *svgScanner) skipSpace() {
+	for !sc.eof() && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+// args scans the arguments of the path command.
+func (sc *svgScanner) args(cmd byte) ([]float64, error) {
+	n, ok := svgArgs[cmd|0x20]
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q", cmd)
+	}
+	args := make([]float64, n)
+	for i := range args {
+		var ok bool
+		if cmd|0x20 == 'a' && (i == 3 || i == 4) {
+			args[i], ok = sc.flag()
+		} else {
+			args[i], ok = sc.number()
+		}
+		if !ok {
+			return nil, fmt.Errorf("command %q requires %d arguments", cmd, n)
+		}
+	}
+	return args, nil
+}
+
+// flag scans the arc flag, that can be written without the separator.
+func (sc *svgScanner) flag() (float64, bool) {
+	sc.skipSpace()
+	if sc.eof() || (sc.s[sc.i] != '0' && sc.s[sc.i] != '1') {
+		return 0, false
+	}
+	sc.i++
+	return float64(sc.s[sc.i-1] - '0'), true
+}
+
+// number scans the number, i.e. "-1.5e3".  Numbers can follow each other
+// without the separator, if unambiguous: "1-2" or "0.5.5".
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skipSpace()
+	start := sc.i
+	digits := func() bool {
+		from := sc.i
+		for !sc.eof() && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+			sc.i++
+		}
+		return sc.i > from
+	}
+	sign := func() {
+		if !sc.eof() && (sc.s[sc.i] == '-' || sc.s[sc.i] == '+') {
+			sc.i++
+		}
+	}
+	sign()
+	ok := digits()
+	if !sc.eof() && sc.s[sc.i] == '.' {
+		sc.i++
+		ok = digits() || ok
+	}
+	if !ok {
+		sc.i = start
+		return 0, false
+	}
+	if !sc.eof() && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		mark := sc.i
+		sc.i++
+		sign()
+		if !digits() {
+			sc.i = mark
+		}
+	}
+	v, err := strconv.ParseFloat(sc.s[start:sc.i], 64)
+	if err != nil {
+		sc.i = start
+		return 0, false
+	}
+	return v, true
+}
+
+// viewport returns the scale and offset of the viewBox in the box of size
+// w × h, the image is centred (xMidYMid meet).
+func (img *svgImage) viewport(w, h float64) (scale, dx, dy float64) {
+	vb := img.viewBox
+	scale = math.Min(w/vb[2], h/vb[3])
+	dx = (w-vb[2]*scale)/2 - vb[0]*scale
+	dy = (h-vb[3]*scale)/2 - vb[1]*scale
+	return scale, dx, dy
+}
+
+// rasterize renders the image of the size in pixels.  Strokes are drawn as
+// the quads of the flattened path segments with round joins.
+func (img *svgImage) rasterize(width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	scale, dx, dy := img.viewport(float64(width), float64(height))
+	tr := func(p svgPoint) (float32, float32) {
+		return float32(p.x*scale + dx), float32(p.y*scale + dy)
+	}
+	paint := func(z *vector.Rasterizer, c *color.NRGBA) {
+		z.Draw(dst, dst.Bounds(), image.NewUniform(*c), image.Point{})
+	}
+	for _, sh := range img.shapes {
+		if sh.fill != nil {
+			// the rasterizer fills with the nonzero rule only
+			z := vector.NewRasterizer(width, height)
+			z.DrawOp = draw.Over
+			open := false
+			for _, seg := range sh.path {
+				switch seg.cmd {
+				case 'M':
+					if open {
+						z.ClosePath()
+					}
+					z.MoveTo(tr(seg.pts[0]))
+					open = true
+				case 'L':
+					z.LineTo(tr(seg.pts[0]))
+				case 'C':
+					x1, y1 := tr(seg.pts[0])
+					x2, y2 := tr(seg.pts[1])
+					x3, y3 := tr(seg.pts[2])
+					z.CubeTo(x1, y1, x2, y2, x3, y3)
+				case 'Z':
+					z.ClosePath()
+					open = false
+				}
+			}
+			if open {
+				z.ClosePath()
+			}
+			paint(z, sh.fill)
+		}
+		if sh.stroke != nil && sh.strokeWidth > 0 {
+			z := vector.NewRasterizer(width, height)
+			z.DrawOp = draw.Over
+			hw := sh.strokeWidth * scale / 2
+			for _, line := range sh.flatten(8) {
+				// round joins and caps
+				for _, p := range line {
+					cx, cy := tr(p)
+					var circle [12][2]float32
+					for i := range circle {
+						sin, cos := math.Sincos(float64(i) * math.Pi / 6)
+						circle[i] = [2]float32{cx + float32(cos*hw), cy + float32(sin*hw)}
+					}
+					polygon(z, circle[:]...)
+				}
+				for i := 1; i < len(line); i++ {
+					x0, y0 := tr(line[i-1])
+					x1, y1 := tr(line[i])
+					l := math.Hypot(float64(x1-x0), float64(y1-y0))
+					if l == 0 {
+						continue
+					}
+					nx, ny := float32(-float64(y1-y0)/l*hw), float32(float64(x1-x0)/l*hw)
+					polygon(z, [2]float32{x0 + nx, y0 + ny}, [2]float32{x1 + nx, y1 + ny}, [2]float32{x1 - nx, y1 - ny}, [2]float32{x0 - nx, y0 - ny})
+				}
+			}
+			paint(z, sh.stroke)
+		}
+	}
+	return dst
+}
+
+// polygon adds the polygon to the rasterizer.  All polygons are added with
+// the same orientation, so that the overlapping ones are filled with the
+// nonzero rule.
+func polygon(z *vector.Rasterizer, pts ...[2]float32) {
+	var area float32
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		area += pts[i][0]*pts[j][1] - pts[j][0]*pts[i][1]
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	z.MoveTo(pts[0][0], pts[0][1])
+	for _, p := range pts[1:] {
+		z.LineTo(p[0], p[1])
+	}
+	z.ClosePath()
+}
+
+// flatten returns the polylines of the shape path, curves are split into n
+// lines.
+func (sh *svgShape) flatten(n int) [][]svgPoint {
+	var (
+		lines      [][]svgPoint
+		cur, start svgPoint
+	)
+	for _, seg := range sh.path {
+		switch seg.cmd {
+		case 'M':
+			cur, start = seg.pts[0], seg.pts[0]
+			lines = append(lines, []svgPoint{cur})
+			continue
+		case 'Z':
+			cur = start
+		case 'L':
+			cur = seg.pts[0]
+		case 'C':
+			p0 := cur
+			for i := 1; i < n; i++ {
+				t := float64(i) / float64(n)
+				lines[len(lines)-1] = append(lines[len(lines)-1], cubicAt(p0, seg.pts[0], seg.pts[1], seg.pts[2], t))
+			}
+			cur = seg.pts[2]
+		}
+		if len(lines) == 0 {
+			lines = append(lines, nil)
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], cur)
+	}
+	return lines
+}
+
+// cubicAt returns the point of the cubic Bézier curve at t.
+func cubicAt(p0, p1, p2, p3 svgPoint, t float64) svgPoint {
+	u := 1 - t
+	a, b, c, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return svgPoint{a*p0.x + b*p1.x + c*p2.x + d*p3.x, a*p0.y + b*p1.y + c*p2.y + d*p3.y}
+}
@@ -0,0 +1,168 @@
+package forms
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// svgCSS is the stylesheet of the <style> elements.  Only the simple
+// selectors are supported: the element name, class, id and "*", and the
+// selector lists of them.
+type svgCSS []svgRule
+
+// svgRule is the CSS rule with one simple selector.
+type svgRule struct {
+	selector    string
+	specificity int // 0 for "*", 1 for the element, 10 for class, 100 for id
+	decls       string
+}
+
+// parseSVGStyles returns the rules of all <style> elements of the document.
+func parseSVGStyles(data []byte) (svgCSS, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	var (
+		css     svgCSS
+		inStyle bool
+		text    strings.Builder
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("svg: %s", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			inStyle = el.Name.Local == "style"
+		case xml.CharData:
+			if inStyle {
+				text.Write(el)
+			}
+		case xml.EndElement:
+			if el.Name.Local == "style" {
+				rules, err := parseCSS(text.String())
+				if err != nil {
+					return nil, err
+				}
+				css = append(css, rules...)
+				text.Reset()
+			}
+			inStyle = false
+		}
+	}
+	return css, nil
+}
+
+// parseCSS parses the stylesheet.  At-rules and complex selectors are not
+// supported.
+func parseCSS(s string) ([]svgRule, error) {
+	for {
+		start := strings.Index(s, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+2:], "*/")
+		if end < 0 {
+			s = s[:start]
+			break
+		}
+		s = s[:start] + " " + s[start+2+end+2:]
+	}
+	var rules []svgRule
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		open, end := strings.IndexByte(s, '{'), strings.IndexByte(s, '}')
+		if open < 0 || end < open {
+			return nil, fmt.Errorf("svg: invalid css %q", s)
+		}
+		selectors, decls := strings.TrimSpace(s[:open]), s[open+1:end]
+		s = s[end+1:]
+		if strings.HasPrefix(selectors, "@") {
+			return nil, fmt.Errorf("svg: css %s is not supported", strings.Fields(selectors)[0])
+		}
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			spec, ok := cssSpecificity(sel)
+			if !ok {
+				return nil, fmt.Errorf("svg: css selector %q is not supported", sel)
+			}
+			rules = append(rules, svgRule{selector: sel, specificity: spec, decls: decls})
+		}
+	}
+	return rules, nil
+}
+
+// cssSpecificity returns the specificity of the simple selector, or false,
+// if the selector is not simple.
+func cssSpecificity(sel string) (int, bool) {
+	if sel == "*" {
+		return 0, true
+	}
+	spec, name := 1, sel
+	switch {
+	case strings.HasPrefix(sel, "."):
+		spec, name = 10, sel[1:]
+	case strings.HasPrefix(sel, "#"):
+		spec, name = 100, sel[1:]
+	}
+	if name == "" {
+		return 0, false
+	}
+	for _, r := range name {
+		if !(r == '-' || r == '_' || '0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return 0, false
+		}
+	}
+	return spec, true
+}
+
+// matches returns true if the rule selector matches the element.
+func (r *svgRule) matches(el xml.StartElement, id string, classes []string) bool {
+	switch {
+	case r.selector == "*":
+		return true
+	case strings.HasPrefix(r.selector, "."):
+		for _, c := range classes {
+			if c == r.selector[1:] {
+				return true
+			}
+		}
+		return false
+	case strings.HasPrefix(r.selector, "#"):
+		return id == r.selector[1:]
+	}
+	return el.Name.Local == r.selector
+}
+
+// attrs returns the element attributes: the presentation attributes, then
+// the stylesheet rules in the specificity order, then the style attribute
+// properties.
+func (css svgCSS) attrs(el xml.StartElement) map[string]string {
+	attrs := make(map[string]string, len(el.Attr))
+	for _, a := range el.Attr {
+		attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+	}
+	var (
+		matched []svgRule
+		classes = strings.Fields(attrs["class"])
+	)
+	for _, r := range css {
+		if r.matches(el, attrs["id"], classes) {
+			matched = append(matched, r)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].specificity < matched[j].specificity
+	})
+	for _, r := range matched {
+		svgDeclarations(attrs, r.decls)
+	}
+	svgDeclarations(attrs, attrs["style"])
+	return attrs
+}
@@ -0,0 +1,236 @@
+package forms
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseSVGPath(t *testing.T) {
+	pt := func(x, y float64) [3]svgPoint { return [3]svgPoint{{x, y}} }
+	tests := []struct {
+		name    string
+		d       string
+		want    []svgSeg
+		wantErr bool
+	}{
+		{"absolute",
+			"M10,20 L30 40 H50 V60 Z",
+			[]svgSeg{{'M', pt(10, 20)}, {'L', pt(30, 40)}, {'L', pt(50, 40)}, {'L', pt(50, 60)}, {cmd: 'Z'}},
+			false,
+		},
+		{"relative with implicit lineto",
+			"m10 20 10 10-5.5.5z",
+			[]svgSeg{{'M', pt(10, 20)}, {'L', pt(20, 30)}, {'L', pt(14.5, 30.5)}, {cmd: 'Z'}},
+			false,
+		},
+		{"quadratic as cubic",
+			"M0 0Q30 30 60 0",
+			[]svgSeg{{'M', pt(0, 0)}, {'C', [3]svgPoint{{20, 20}, {40, 20}, {60, 0}}}},
+			false,
+		},
+		{"smooth cubic",
+			"M0 0C0 10 10 10 10 0S20-10 20 0",
+			[]svgSeg{{'M', pt(0, 0)}, {'C', [3]svgPoint{{0, 10}, {10, 10}, {10, 0}}}, {'C', [3]svgPoint{{10, -10}, {20, -10}, {20, 0}}}},
+			false,
+		},
+		{"exponent", "M1e1 2E-1", []svgSeg{{'M', pt(10, 0.2)}}, false},
+		{"no command", "10 20", nil, true},
+		{"missing argument", "M10", nil, true},
+		{"arguments after closepath", "M0 0z 10 10", nil, true},
+		{"unknown command", "M0 0 X10", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSVGPath(tt.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSVGPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSVGPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseSVGPath_arc(t *testing.T) {
+	// half circle of radius 10 from (0, 0) to (20, 0), with the flags
+	// written without separators.
+	got, err := parseSVGPath("M0 0a10 10 0 0120 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[1].cmd != 'C' || got[2].cmd != 'C' {
+		t.Fatalf("parseSVGPath() = %v, want moveto and 2 curves", got)
+	}
+	if end := got[2].pts[2]; end != (svgPoint{20, 0}) {
+		t.Errorf("arc end = %v, want {20 0}", end)
+	}
+	// sweep flag 1 goes through the top of the circle (negative y).
+	if mid := got[1].pts[2]; !almostEqual(mid.x, 10) || !almostEqual(mid.y, -10) {
+		t.Errorf("arc middle = %v, want {10 -10}", mid)
+	}
+}
+
+func Test_svgTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		in      svgPoint
+		want    svgPoint
+		wantErr bool
+	}{
+		{"translate", "translate(10 20)", svgPoint{1, 1}, svgPoint{11, 21}, false},
+		{"scale", "scale(2)", svgPoint{1, 3}, svgPoint{2, 6}, false},
+		{"list is applied right to left", "translate(10,0) scale(2,3)", svgPoint{1, 1}, svgPoint{12, 3}, false},
+		{"rotate around the point", "rotate(90 10 10)", svgPoint{20, 10}, svgPoint{10, 20}, false},
+		{"matrix", "matrix(1 0 0 1 5 5)", svgPoint{0, 0}, svgPoint{5, 5}, false},
+		{"unknown", "perspective(2)", svgPoint{}, svgPoint{}, true},
+		{"matrix arguments", "matrix(1 0 0)", svgPoint{}, svgPoint{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := svgTransform(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("svgTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := m.apply(tt.in); !almostEqual(got.x, tt.want.x) || !almostEqual(got.y, tt.want.y) {
+				t.Errorf("svgTransform(%q) maps %v to %v, want %v", tt.s, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_svgPaint(t *testing.T) {
+	current := color.NRGBA{0x11, 0x22, 0x33, 0xff}
+	tests := []struct {
+		s       string
+		want    *color.NRGBA
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"transparent", nil, false},
+		{"#fff", &color.NRGBA{0xff, 0xff, 0xff, 0xff}, false},
+		{"#243761", &color.NRGBA{0x24, 0x37, 0x61, 0xff}, false},
+		{"#24376180", &color.NRGBA{0x24, 0x37, 0x61, 0x80}, false},
+		{"rgb(255, 0, 50%)", &color.NRGBA{0xff, 0, 0x80, 0xff}, false},
+		{"rgba(255, 0, 0, 0.5)", &color.NRGBA{0xff, 0, 0, 0x80}, false},
+		{"rgb(0 0 255 / 25%)", &color.NRGBA{0, 0, 0xff, 0x40}, false},
+		{"hsl(120, 100%, 25%)", &color.NRGBA{0, 0x80, 0, 0xff}, false},
+		{"hsla(240deg, 100%, 50%, 1)", &color.NRGBA{0, 0, 0xff, 0xff}, false},
+		{"Navy", &color.NRGBA{0, 0, 0x80, 0xff}, false},
+		{"cornflowerblue", &color.NRGBA{0x64, 0x95, 0xed, 0xff}, false},
+		{"currentColor", &current, false},
+		{"url(#grad) #c00000", &color.NRGBA{0xc0, 0, 0, 0xff}, false},
+		{"url(#grad)", nil, true},
+		{"bluish", nil, true},
+		{"#12345", nil, true},
+		{"cmyk(0, 0, 0, 1)", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := svgPaint(tt.s, current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("svgPaint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("svgPaint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseSVG(t *testing.T) {
+	img, err := parseSVG([]byte(testSVG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.viewBox != [4]float64{0, 0, 200, 100} {
+		t.Errorf("viewBox = %v", img.viewBox)
+	}
+	// the title is skipped, the rect, circle and path are drawn.
+	if len(img.shapes) != 3 {
+		t.Fatalf("shapes = %d, want 3", len(img.shapes))
+	}
+	circle := img.shapes[1]
+	if circle.fill != nil || circle.stroke == nil || circle.strokeWidth != 4 {
+		t.Errorf("circle style = fill %v, stroke %v %v, want the inherited group style", circle.fill, circle.stroke, circle.strokeWidth)
+	}
+	// the circle starts at (r, 0), translated to the group origin.
+	if start := circle.path[0].pts[0]; start != (svgPoint{130, 50}) {
+		t.Errorf("circle start = %v, want {130 50}", start)
+	}
+
+	if _, err := parseSVG([]byte(`<html><svg/></html>`)); err == nil || !strings.Contains(err.Error(), "not svg") {
+		t.Errorf("parseSVG() error = %v, want the root element error", err)
+	}
+}
+
+func Test_parseSVG_style(t *testing.T) {
+	img, err := parseSVG([]byte(`<svg width="10" height="10">
+<defs><style><![CDATA[ /* exported */ .st0{fill:#243761} rect, #b { fill: red; opacity: .5 } ]]></style></defs>
+<g color="teal" opacity="0.5">
+<rect class="st0" width="1" height="1"/>
+<path id="b" class="st0" d="M0 0h1v1z" style="fill:currentColor"/>
+<circle r="1" fill-opacity="50%"/>
+</g>
+</svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.NRGBA{
+		{0x24, 0x37, 0x61, 0x40}, // class overrides the element rule, opacities multiply
+		{0, 0x80, 0x80, 0x40},    // style attribute overrides the id rule
+		{0, 0, 0, 0x40},
+	}
+	if len(img.shapes) != len(want) {
+		t.Fatalf("shapes = %d, want %d", len(img.shapes), len(want))
+	}
+	for i, sh := range img.shapes {
+		if sh.fill == nil || *sh.fill != want[i] {
+			t.Errorf("shape %d fill = %v, want %v", i, sh.fill, want[i])
+		}
+	}
+}
+
+func Test_parseSVG_unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want string
+	}{
+		{"text", `<text>ACME</text>`, "<text> elements are not supported"},
+		{"use", `<use href="#logo"/>`, "<use> elements are not supported"},
+		{"clip path", `<g clip-path="url(#clip)"><rect width="1" height="1"/></g>`, "clip-path is not supported"},
+		{"gradient", `<rect width="1" height="1" fill="url(#grad)"/>`, "require the fallback colour"},
+		{"colour", `<rect width="1" height="1" fill="bluish"/>`, "unsupported colour"},
+		{"css selector", `<style>g rect{fill:red}</style>`, "selector \"g rect\" is not supported"},
+		{"css at-rule", `<style>@media print{rect{fill:red}}</style>`, "css @media is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSVG([]byte(`<svg width="10" height="10">` + tt.svg + `</svg>`))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseSVG() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_svgImage_rasterize(t *testing.T) {
+	img, err := parseSVG([]byte(`<svg width="20" height="10" viewBox="0 0 2 1"><rect width="1" height="1" fill="#c00000"/></svg>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := img.rasterize(20, 10)
+	if got := dst.RGBAAt(5, 5); got != (color.RGBA{0xc0, 0, 0, 0xff}) {
+		t.Errorf("rasterize() left half = %v, want the fill colour", got)
+	}
+	if got := dst.RGBAAt(15, 5); got.A != 0 {
+		t.Errorf("rasterize() right half = %v, want transparent", got)
+	}
+}
@@ -0,0 +1,264 @@
+package forms
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// page orientation
+const (
+	orientPortrait  = "P"
+	orientLandscape = "L"
+)
+
+// DefaultTheme is the name of the default theme.
+const DefaultTheme = "default"
+
+// Theme is the invoice form theme, that can be set in the config.  Theme
+// values override the base theme values.
+type Theme struct {
+	Base        string   `yaml:",omitempty"`          // built-in theme name, default is "default"
+	PageSize    string   `yaml:"page_size,omitempty"` // A3, A4, A5, Letter, Legal
+	Orientation string   `yaml:",omitempty"`          // portrait or landscape
+	Margins     *Margins `yaml:",omitempty"`
+
+	Font      string     `yaml:",omitempty"` // font family of all text, i.e. "Times" or a TTF family
+	Fonts     []TTF      `yaml:",omitempty"` // TrueType fonts
+	Title     *ThemeFont `yaml:",omitempty"`
+	Heading   *ThemeFont `yaml:",omitempty"`
+	Body      *ThemeFont `yaml:",omitempty"`
+	Address   *ThemeFont `yaml:",omitempty"`
+	TableHead *ThemeFont `yaml:"table_head,omitempty"`
+	TableRow  *ThemeFont `yaml:"table_row,omitempty"`
+
+	Accent      string    `yaml:",omitempty"`             // accent colour, i.e. "#497b9e"
+	LineSpacing float64   `yaml:"line_spacing,omitempty"` // line spacing multiplier
+	Columns     []float64 `yaml:",omitempty"`             // table column widths: #, description, qty, price, total
+	Logo        *Box      `yaml:",omitempty"`
+
+	EmbedFonts bool `yaml:"embed_fonts,omitempty"` // replace the core fonts with the bundled GoFont, so that all fonts are embedded
+}
+
+// ThemeFont is the font of the theme element.
+type ThemeFont struct {
+	Family     string  `yaml:",omitempty"`
+	Style      string  `yaml:",omitempty"` // B, I or BI
+	Size       float64 `yaml:",omitempty"` // size in pt
+	Color      string  `yaml:",omitempty"` // hex colour
+	Background string  `yaml:",omitempty"` // hex colour
+}
+
+// builtinThemes are the themes shipped with the program.
+var builtinThemes = map[string]*Theme{
+	DefaultTheme: {},
+	"classic": {
+		Font:      times,
+		Accent:    "#000000",
+		Title:     &ThemeFont{Size: 20, Color: "#000000"},
+		Heading:   &ThemeFont{Color: "#404040"},
+		TableHead: &ThemeFont{Color: "#ffffff", Background: "#404040"},
+	},
+	"modern": {
+		Accent:    "#243761",
+		Title:     &ThemeFont{Style: "B", Size: 20, Color: "#243761"},
+		Heading:   &ThemeFont{Style: "B", Color: "#4b76d2"},
+		TableHead: &ThemeFont{Style: "B", Background: "#243761"},
+	},
+	"compact": {
+		Margins:     &Margins{15, 15, 15, 15},
+		Title:       &ThemeFont{Size: 14},
+		Heading:     &ThemeFont{Size: 9},
+		Body:        &ThemeFont{Size: 9},
+		Address:     &ThemeFont{Size: 9},
+		TableHead:   &ThemeFont{Size: 9},
+		TableRow:    &ThemeFont{Size: 8},
+		LineSpacing: 1.25,
+		Columns:     []float64{0.05, 0.59, 0.12, 0.12, 0.12},
+		Logo:        &Box{Width: 14},
+	},
+}
+
+// Themes returns the built-in theme names.
+func Themes() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pageSizes are the page sizes supported by gofpdf.
+var pageSizes = []string{"A3", "A4", "A5", PgLetter, "Legal", "Tabloid"}
+
+// Validate checks the theme values.
+func (t *Theme) Validate() error {
+	_, _, err := t.resolve()
+	return err
+}
+
+// resolve returns the style and margins of the theme applied over its base
+// theme.
+func (t *Theme) resolve() (*Style, *Margins, error) {
+	s, m := defStyle, defMargins
+	if t == nil {
+		return &s, &m, nil
+	}
+	base := t.Base
+	if base == "" {
+		base = DefaultTheme
+	}
+	bt, ok := builtinThemes[strings.ToLower(base)]
+	if !ok {
+		return nil, nil, fmt.Errorf("theme: unknown base theme %q, available: %s", t.Base, strings.Join(Themes(), ", "))
+	}
+	for _, th := range []*Theme{bt, t} {
+		if err := th.apply(&s, &m); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, f := range []*Font{&s.Title, &s.Heading, &s.Body, &s.Address, &s.TableHead, &s.TableRowOdd, &s.TableRowEven} {
+		f.FamilyStr = s.family(f.FamilyStr)
+	}
+	return &s, &m, nil
+}
+
+// apply applies the theme values to the style and margins.
+func (t *Theme) apply(s *Style, m *Margins) error {
+	if t.PageSize != "" && !containsFold(pageSizes, t.PageSize) {
+		return fmt.Errorf("theme: unsupported page size %q, supported: %s", t.PageSize, strings.Join(pageSizes, ", "))
+	}
+	switch strings.ToLower(t.Orientation) {
+	case "", "p", "portrait", "l", "landscape":
+	default:
+		return fmt.Errorf("theme: invalid orientation %q, must be portrait or landscape", t.Orientation)
+	}
+	if t.Margins != nil {
+		*m = *t.Margins
+	}
+	s.Fonts = append(s.Fonts, t.Fonts...)
+	s.EmbedFonts = s.EmbedFonts || t.EmbedFonts
+	fonts := []struct {
+		tf   *ThemeFont
+		font *Font
+	}{
+		{t.Title, &s.Title},
+		{t.Heading, &s.Heading},
+		{t.Body, &s.Body},
+		{t.Address, &s.Address},
+		{t.TableHead, &s.TableHead},
+		{t.TableRow, &s.TableRowOdd},
+		{t.TableRow, &s.TableRowEven},
+	}
+	for _, f := range fonts {
+		if t.Font != "" {
+			f.font.FamilyStr = t.Font
+		}
+		if err := f.tf.apply(f.font); err != nil {
+			return err
+		}
+	}
+	if t.Accent != "" {
+		c, err := parseColor(t.Accent)
+		if err != nil {
+			return err
+		}
+		s.AccentColor = c
+	}
+	if t.LineSpacing != 0 {
+		s.LineSpacing = t.LineSpacing
+	}
+	if t.Columns != nil {
+		if err := validColumns(t.Columns); err != nil {
+			return err
+		}
+		s.Columns = t.Columns
+	}
+	if t.Logo != nil {
+		s.Logo = *t.Logo
+	}
+	return nil
+}
+
+func (tf *ThemeFont) apply(f *Font) error {
+	if tf == nil {
+		return nil
+	}
+	if tf.Family != "" {
+		f.FamilyStr = tf.Family
+	}
+	if tf.Style != "" {
+		f.StyleStr = strings.ToUpper(tf.Style)
+	}
+	if tf.Size != 0 {
+		f.Size = tf.Size
+	}
+	var err error
+	if tf.Color != "" {
+		if f.Foreground, err = parseColor(tf.Color); err != nil {
+			return err
+		}
+	}
+	if tf.Background != "" {
+		if f.Background, err = parseColor(tf.Background); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orientation returns the gofpdf page orientation.
+func (t *Theme) orientation() string {
+	if t != nil {
+		switch strings.ToLower(t.Orientation) {
+		case "l", "landscape":
+			return orientLandscape
+		}
+	}
+	return orientPortrait
+}
+
+// validColumns checks that there are widths for all table columns, and that
+// they add up to the text area width.
+func validColumns(cols []float64) error {
+	if len(cols) != len(tabColPc) {
+		return fmt.Errorf("theme: %d column widths expected, got %d", len(tabColPc), len(cols))
+	}
+	var sum float64
+	for _, c := range cols {
+		if c <= 0 {
+			return fmt.Errorf("theme: column widths must be positive: %v", cols)
+		}
+		sum += c
+	}
+	if math.Abs(sum-1) > 0.01 {
+		return fmt.Errorf("theme: column widths must add up to 1, got %.2f", sum)
+	}
+	return nil
+}
+
+// parseColor parses the hex colour in "#rrggbb" or "#rgb" format.
+func parseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("theme: invalid colour %q, must be #rrggbb", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0}, nil
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package forms
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTheme_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   *Theme
+		wantErr string
+	}{
+		{"nil", nil, ""},
+		{"builtin", &Theme{Base: "modern", Orientation: "landscape", PageSize: "a5"}, ""},
+		{"unknown base", &Theme{Base: "fancy"}, "unknown base theme"},
+		{"page size", &Theme{PageSize: "B4"}, "unsupported page size"},
+		{"orientation", &Theme{Orientation: "sideways"}, "invalid orientation"},
+		{"colour", &Theme{Accent: "teal"}, "invalid colour"},
+		{"font colour", &Theme{Title: &ThemeFont{Color: "#12345"}}, "invalid colour"},
+		{"columns count", &Theme{Columns: []float64{0.5, 0.5}}, "5 column widths expected"},
+		{"columns sum", &Theme{Columns: []float64{0.1, 0.1, 0.1, 0.1, 0.1}}, "must add up to 1"},
+		{"columns negative", &Theme{Columns: []float64{-0.1, 0.8, 0.1, 0.1, 0.1}}, "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.theme.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Theme.Validate() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Theme.Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTheme_resolve(t *testing.T) {
+	th := &Theme{
+		Base:    "classic",
+		Margins: &Margins{10, 10, 10, 10},
+		Accent:  "#f00",
+		Body:    &ThemeFont{Size: 12},
+		Logo:    &Box{X: 20, Y: 20, Width: 30},
+	}
+	s, m, err := th.resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *m != (Margins{10, 10, 10, 10}) {
+		t.Errorf("margins = %v", *m)
+	}
+	if s.AccentColor != (color.RGBA{255, 0, 0, 0}) {
+		t.Errorf("accent = %v", s.AccentColor)
+	}
+	if s.Body.FamilyStr != times || s.Body.Size != 12 {
+		t.Errorf("body font = %v, want classic family with overridden size", s.Body)
+	}
+	if s.Title.Size != 20 {
+		t.Errorf("title size = %v, want classic title size", s.Title.Size)
+	}
+	if s.Logo != (Box{X: 20, Y: 20, Width: 30}) {
+		t.Errorf("logo = %v", s.Logo)
+	}
+	if defStyle.Body.Size != 10 {
+		t.Errorf("default style was modified")
+	}
+}
+
+func TestTheme_resolve_embedFonts(t *testing.T) {
+	s, _, err := (&Theme{Base: "classic", Heading: &ThemeFont{Family: courier}, EmbedFonts: true}).resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []Font{s.Title, s.Heading, s.Body, s.Address, s.TableHead, s.TableRowOdd, s.TableRowEven} {
+		if f.FamilyStr != GoFont {
+			t.Errorf("font family = %q, want the embedded %q", f.FamilyStr, GoFont)
+		}
+	}
+	if got := s.family("Arial"); got != GoFont {
+		t.Errorf("family(Arial) = %q, want %q", got, GoFont)
+	}
+	if got := s.family("DejaVu"); got != "DejaVu" {
+		t.Errorf("family(DejaVu) = %q, want the TTF family", got)
+	}
+}
+
+func TestNewInvoiceTheme(t *testing.T) {
+	for _, name := range Themes() {
+		t.Run(name, func(t *testing.T) {
+			f, err := NewInvoiceTheme("42", &Theme{Base: name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+			var buf bytes.Buffer
+			if err := f.Write(&buf, &InvoiceFields{Date: time.Now()}); err != nil {
+				t.Errorf("InvoiceForm.Write() error = %v", err)
+			}
+		})
+	}
+	f, err := NewInvoiceTheme("42", &Theme{Orientation: "landscape"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.maxX <= f.maxY {
+		t.Errorf("landscape page size = %vx%v", f.maxX, f.maxY)
+	}
+}
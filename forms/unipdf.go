@@ -0,0 +1,560 @@
+package forms
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/unidoc/unipdf/v3/common/license"
+	"github.com/unidoc/unipdf/v3/creator"
+	"github.com/unidoc/unipdf/v3/model"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// unipdfLogoHeight is the height of the rasterised SVG logo, mm.  The
+// template scales the logo to the title height, about 15mm.
+const unipdfLogoHeight = 20
+
+// UnipdfForm renders the invoice with the unipdf creator invoice template.
+// The template has its own layout, only page setup, fonts and colours of the
+// theme are applied, and payment codes are not supported.  Unlicensed
+// unipdf adds a watermark to the output, see SetUnipdfLicense.
+type UnipdfForm struct {
+	Content
+
+	theme *Theme
+}
+
+// unipdf page sizes, in points.
+var unipdfPageSizes = map[string]creator.PageSize{
+	"a3":      creator.PageSizeA3,
+	"a4":      creator.PageSizeA4,
+	"a5":      creator.PageSizeA5,
+	"letter":  creator.PageSizeLetter,
+	"legal":   creator.PageSizeLegal,
+	"tabloid": {11 * creator.PPI, 17 * creator.PPI},
+}
+
+// NewUnipdf creates the unipdf invoice form with the theme.
+func NewUnipdf(invoiceID string, t *Theme) (*UnipdfForm, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &UnipdfForm{Content: newContent(invoiceID), theme: t}, nil
+}
+
+// SetUnipdfLicense sets the unipdf license key, that removes the watermark.
+func SetUnipdfLicense(key, customer string) error {
+	return license.SetLicenseKey(key, customer)
+}
+
+// UnipdfLicensed returns true if the unipdf license key is set.  Unlicensed
+// unipdf prints the notice, when the renderer or signer writes the PDF, it
+// is redirected to stderr.
+func UnipdfLicensed() bool {
+	return license.GetLicenseKey().IsLicensed()
+}
+
+var unipdfStdoutMu sync.Mutex
+
+// withUnipdfNotice calls fn, that writes the PDF with unipdf, with stdout
+// redirected to stderr, if unipdf is not licensed, so that the notice it
+// prints doesn't mix with the output written to stdout.
+func withUnipdfNotice(fn func() error) error {
+	if UnipdfLicensed() {
+		return fn()
+	}
+	unipdfStdoutMu.Lock()
+	defer unipdfStdoutMu.Unlock()
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+	return fn()
+}
+
+// unipdfWriter holds the state of the invoice being written.
+type unipdfWriter struct {
+	c     *creator.Creator
+	loc   *Locale
+	s     *Style
+	m     *Margins
+	stamp *Stamp // status stamp, if any
+
+	regular, bold *model.PdfFont
+	face          *fontFace // font face to check the text against
+
+	err error // first text error
+}
+
+// Write generates the PDF and writes it to w.
+func (f *UnipdfForm) Write(w io.Writer, fields *InvoiceFields) error {
+	val := *fields
+
+	loc, err := NewLocale(val.Language, val.SecondLanguage)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	if val.PaymentCode != "" {
+		return fmt.Errorf("invoice %s: payment codes are not supported by the %s renderer", f.invoiceID, RendererUnipdf)
+	}
+	stamp, err := val.stamp(loc)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	s, m, err := f.theme.resolve()
+	if err != nil {
+		return err
+	}
+
+	c := creator.New()
+	c.SetPageSize(f.pageSize())
+	c.SetPageMargins(m.Left*creator.PPMM, m.Right*creator.PPMM, m.Top*creator.PPMM, m.Bottom*creator.PPMM)
+	u := &unipdfWriter{c: c, loc: loc, s: s, m: m, stamp: stamp}
+	if err := u.loadFonts(s.Body.FamilyStr, s.Fonts); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	c.DrawHeader(u.header)
+	c.DrawFooter(u.footer)
+
+	c.NewPage()
+	inv, err := u.invoice(f, &val)
+	if err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	if err := c.Draw(inv); err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+	}
+	if len(f.appendix) > 0 {
+		c.NewPage()
+		if err := u.timesheet(f.appendix); err != nil {
+			return fmt.Errorf("invoice %s: %s", f.invoiceID, err)
+		}
+	}
+	if u.err != nil {
+		return fmt.Errorf("invoice %s: %s", f.invoiceID, u.err)
+	}
+	return withUnipdfMetadata(f.metadata(&val, loc), func() error {
+		return withUnipdfNotice(func() error { return c.Write(w) })
+	})
+}
+
+// pageSize returns the page size of the theme, default is A4.
+func (f *UnipdfForm) pageSize() creator.PageSize {
+	size := creator.PageSizeA4
+	if f.theme != nil && f.theme.PageSize != "" {
+		size = unipdfPageSizes[strings.ToLower(f.theme.PageSize)]
+	}
+	if f.theme.orientation() == orientLandscape {
+		size[0], size[1] = size[1], size[0]
+	}
+	return size
+}
+
+// invoice returns the creator invoice populated with the content.
+func (u *unipdfWriter) invoice(f *UnipdfForm, val *InvoiceFields) (*creator.Invoice, error) {
+	inv := u.c.NewInvoice()
+	defRegular, defBold := u.c.NewTextStyle().Font, inv.TitleStyle().Font
+
+	inv.SetTitle(u.text(u.loc.Label(LabelInvoice)))
+	logo, err := val.logo()
+	if err != nil {
+		return nil, err
+	}
+	if logo != nil {
+		data, err := logo.raster(logo.fit(0, unipdfLogoHeight))
+		if err != nil {
+			return nil, err
+		}
+		img, err := u.c.NewImageFromData(data)
+		if err != nil {
+			return nil, fmt.Errorf("logo: %s", err)
+		}
+		inv.SetLogo(img)
+	}
+
+	// invoice information
+	cell, _ := inv.SetNumber(u.text(f.invoiceID))
+	cell.Value = u.text(u.loc.Label(LabelNo))
+	if !val.Date.IsZero() {
+		cell, _ = inv.SetDate(u.loc.Date(val.Date))
+		cell.Value = u.text(u.loc.Label(LabelDate))
+	}
+	if !val.Due.IsZero() {
+		cell, _ = inv.SetDueDate(u.loc.Date(val.Due))
+		cell.Value = u.text(u.loc.Label(LabelDue))
+	}
+	if !val.PeriodStart.IsZero() || !val.PeriodEnd.IsZero() {
+		inv.AddInfo(u.text(u.loc.Label(LabelTimePeriod)), u.loc.Date(val.PeriodStart)+" - "+u.loc.Date(val.PeriodEnd))
+	}
+	if val.PONumber != "" {
+		inv.AddInfo(u.text(u.loc.Label(LabelPONumber)), u.text(val.PONumber))
+	}
+	if val.ContractID != "" {
+		inv.AddInfo(u.text(u.loc.Label(LabelContract)), u.text(val.ContractID))
+	}
+
+	inv.SetSellerAddress(u.address(val.Address))
+	inv.SetBuyerAddress(u.address(val.BillTo))
+
+	// lines
+	var cols []*creator.InvoiceCell
+	for i, key := range columnKeys[1:] {
+		col := inv.NewColumn(u.text(u.loc.Label(key)))
+		if i > 0 {
+			col.Alignment = creator.CellHorizontalAlignmentRight
+		}
+		col.BackgroundColor = rgb8(u.s.TableHead.Background)
+		col.TextStyle.Color = rgb8(u.s.TableHead.Foreground)
+		cols = append(cols, col)
+	}
+	inv.SetColumns(cols)
+	for _, e := range f.entries {
+		inv.AddLine(u.text(e[0]), u.text(e[1]), u.text(e[2]), u.text(e[3]))
+	}
+
+	// totals
+	for i, st := range f.subTotals {
+		if i == 0 {
+			cell, _ = inv.Subtotal()
+			cell.Value = u.text(st[0])
+			inv.SetSubtotal(u.text(st[1]))
+			continue
+		}
+		inv.AddTotalLine(u.text(st[0]), u.text(st[1]))
+	}
+	cell, _ = inv.Total()
+	cell.Value = u.text(f.total[0])
+	inv.SetTotal(u.text(f.total[1]))
+
+	// notes
+	inv.SetNotes(u.text(u.loc.Label(LabelRemarks)), u.text(val.Remarks))
+	inv.SetTerms("", "")
+	var account []string
+	for _, kv := range f.account {
+		if kv[1] != "" {
+			account = append(account, strings.TrimSpace(kv[0])+": "+kv[1])
+		}
+	}
+	inv.AddSection(u.text(u.loc.Label(LabelPaymentDetails)), u.text(strings.Join(account, "\n")))
+
+	u.restyle(inv, defRegular, defBold)
+	return inv, nil
+}
+
+// address returns the creator invoice address.  The template prints the
+// seller organisation as the heading.
+func (u *unipdfWriter) address(addr Address) *creator.InvoiceAddress {
+	name := addr.Organisation
+	if name == "" {
+		name = addr.Name
+	}
+	country := strings.Join(append([]string{addr.Country}, addr.legalLines(u.loc)...), "\n")
+	return &creator.InvoiceAddress{
+		Name:    u.text(name),
+		Street:  u.text(nvljoin([]string{addr.Floor, addr.Street}, delim)),
+		City:    u.text(nvljoin([]string{addr.Suburb, addr.Town}, delim)),
+		Zip:     u.text(addr.Postcode),
+		Country: u.text(strings.TrimPrefix(country, "\n")),
+		Phone:   u.text(addr.Phone),
+		Email:   u.text(addr.Email),
+	}
+}
+
+// restyle replaces the default template fonts with the form fonts, and
+// applies the theme colours.
+func (u *unipdfWriter) restyle(inv *creator.Invoice, defRegular, defBold *model.PdfFont) {
+	style := func(ts creator.TextStyle) creator.TextStyle {
+		if ts.Font == defBold {
+			ts.Font = u.bold
+		} else if ts.Font == defRegular {
+			ts.Font = u.regular
+		}
+		return ts
+	}
+	cells := append([]*creator.InvoiceCell{}, inv.Columns()...)
+	pair := func(desc, value *creator.InvoiceCell) { cells = append(cells, desc, value) }
+	pair(inv.Number())
+	pair(inv.Date())
+	pair(inv.DueDate())
+	pair(inv.Subtotal())
+	pair(inv.Total())
+	for _, pair := range append(inv.InfoLines(), inv.TotalLines()...) {
+		cells = append(cells, pair[0], pair[1])
+	}
+	for _, line := range inv.Lines() {
+		cells = append(cells, line...)
+	}
+	for _, cell := range cells {
+		cell.TextStyle = style(cell.TextStyle)
+	}
+
+	title := style(inv.TitleStyle())
+	title.Color = rgb8(u.s.Title.Foreground)
+	inv.SetTitleStyle(title)
+	heading := style(inv.AddressHeadingStyle())
+	heading.Color = rgb8(u.s.Heading.Foreground)
+	inv.SetAddressHeadingStyle(heading)
+	inv.SetAddressStyle(style(inv.AddressStyle()))
+	inv.SetNoteStyle(style(inv.NoteStyle()))
+	inv.SetNoteHeadingStyle(heading)
+}
+
+// timesheet draws the timesheet appendix table.
+func (u *unipdfWriter) timesheet(groups []AppendixGroup) error {
+	regular := u.c.NewTextStyle()
+	regular.Font = u.regular
+	regular.FontSize = u.s.TableRowOdd.Size
+	bold := regular
+	bold.Font = u.bold
+	head := bold
+	head.Color = rgb8(u.s.TableHead.Foreground)
+	headBg := rgb8(u.s.TableHead.Background)
+
+	title := u.c.NewStyledParagraph()
+	title.SetMargins(0, 0, 0, 10)
+	ts := bold
+	ts.FontSize = u.s.Title.Size
+	ts.Color = rgb8(u.s.Title.Foreground)
+	title.Append(u.text(u.loc.Label(LabelTimesheet))).Style = ts
+	if err := u.c.Draw(title); err != nil {
+		return err
+	}
+
+	table := u.c.NewTable(len(appendixKeys))
+	if err := table.SetColumnWidths(appendixColPc...); err != nil {
+		return err
+	}
+	cell := func(s string, style creator.TextStyle, align creator.CellHorizontalAlignment, colspan int) *creator.TableCell {
+		c := table.MultiColCell(colspan)
+		c.SetHorizontalAlignment(align)
+		c.SetBorder(creator.CellBorderSideBottom, creator.CellBorderStyleSingle, 0.5)
+		p := u.c.NewStyledParagraph()
+		p.SetMargins(2, 2, 2, 2)
+		p.Append(u.text(s)).Style = style
+		c.SetContent(p)
+		return c
+	}
+	left, center, right := creator.CellHorizontalAlignmentLeft, creator.CellHorizontalAlignmentCenter, creator.CellHorizontalAlignmentRight
+	for _, key := range appendixKeys {
+		cell(u.loc.Label(key), head, center, 1).SetBackgroundColor(headBg)
+	}
+	if err := table.SetHeaderRows(1, 1); err != nil {
+		return err
+	}
+	total := func(label string, style creator.TextStyle, hours string) {
+		cell(label, style, right, len(appendixKeys)-1)
+		cell(hours, style, right, 1)
+	}
+	var hours decimal.Decimal
+	for _, g := range groups {
+		cell(g.Title, bold, left, len(appendixKeys))
+		for _, r := range g.Rows {
+			if r.DayTotal {
+				total(u.loc.Label(LabelDayTotal), regular, u.loc.Number(r.Hours))
+				continue
+			}
+			cell(u.loc.Date(r.Date), regular, left, 1)
+			cell(clock(r.Start), regular, center, 1)
+			cell(clock(r.End), regular, center, 1)
+			cell(r.Description, regular, left, 1)
+			cell(u.loc.Number(r.Hours), regular, right, 1)
+		}
+		total(u.loc.Label(LabelIssueTotal), bold, u.loc.Number(g.Hours))
+		hours = hours.Add(g.Hours)
+	}
+	total(u.loc.Label(LabelTotalHours), bold, u.loc.Number(hours))
+	return u.c.Draw(table)
+}
+
+// footer draws the page number.
+func (u *unipdfWriter) footer(block *creator.Block, args creator.FooterFunctionArgs) {
+	p := u.c.NewStyledParagraph()
+	p.SetTextAlignment(creator.TextAlignmentCenter)
+	style := u.c.NewTextStyle()
+	style.Font = u.regular
+	style.FontSize = u.s.Body.Size - 2
+	p.Append(u.text(u.loc.page(args.PageNum, strconv.Itoa(args.TotalPages)))).Style = style
+	p.SetWidth(block.Width())
+	p.SetPos(0, block.Height()/2)
+	block.Draw(p)
+}
+
+// header draws the status stamp.  The header is drawn before the page
+// content, so the stamp is under the content, and the transparency is
+// imitated with the lighter colour.
+func (u *unipdfWriter) header(block *creator.Block, args creator.HeaderFunctionArgs) {
+	var stamp *creator.Block
+	switch {
+	case u.stamp == nil:
+		return
+	case u.stamp.Kind == StampCorner:
+		if args.PageNum != 1 {
+			return
+		}
+		stamp = u.cornerStamp()
+	default:
+		stamp = u.watermark()
+	}
+	if err := block.Draw(stamp); err != nil && u.err == nil {
+		u.err = err
+	}
+}
+
+// watermark returns the page block with the stamp text across the page
+// diagonal.
+func (u *unipdfWriter) watermark() *creator.Block {
+	w, h := u.c.Width(), u.c.Height()
+	// the text is scaled to 70% of the page diagonal
+	p := u.stampText(100, stampAlpha)
+	p = u.stampText(100*0.7*math.Hypot(w, h)/p.Width(), stampAlpha)
+	p.SetPos((w-p.Width())/2, (h-p.Height())/2)
+
+	blk := creator.NewBlock(w, h)
+	if err := blk.Draw(p); err != nil && u.err == nil {
+		u.err = err
+	}
+	blk.SetAngle(math.Atan2(h, w) * 180 / math.Pi)
+	return blk
+}
+
+// cornerStamp returns the block with the framed stamp text at the top of the
+// first page, between the title and the logo.
+func (u *unipdfWriter) cornerStamp() *creator.Block {
+	pad := 2 * creator.PPMM
+	p := u.stampText(stampSize, 2*stampAlpha)
+	w, h := p.Width()+2*pad, p.Height()+2*pad
+	p.SetPos(pad, pad)
+
+	frame := u.c.NewRectangle(0, 0, w, h)
+	frame.SetBorderWidth(stampBorder * creator.PPMM)
+	frame.SetBorderColor(rgb8(tint(u.stamp.color(), 2*stampAlpha)))
+
+	blk := creator.NewBlock(w, h)
+	for _, d := range []creator.Drawable{frame, p} {
+		if err := blk.Draw(d); err != nil && u.err == nil {
+			u.err = err
+		}
+	}
+	blk.SetAngle(stampAngle)
+	textW := u.c.Width() - (u.m.Left+u.m.Right)*creator.PPMM
+	blk.SetPos(u.m.Left*creator.PPMM+textW*0.55-w/2, u.m.Top*creator.PPMM+h/2)
+	return blk
+}
+
+// stampText returns the paragraph with the stamp text of the font size,
+// in the stamp colour lightened to the opacity.
+func (u *unipdfWriter) stampText(size float64, alpha float64) *creator.Paragraph {
+	p := u.c.NewParagraph(u.text(u.stamp.Text))
+	p.SetFont(u.bold)
+	p.SetFontSize(size)
+	p.SetEnableWrap(false)
+	p.SetColor(rgb8(tint(u.stamp.color(), alpha)))
+	return p
+}
+
+// tint returns the colour c blended with white, as if it was printed with
+// the opacity alpha.
+func tint(c color.RGBA, alpha float64) color.RGBA {
+	mix := func(v uint8) uint8 {
+		return uint8(255 - (255-float64(v))*alpha)
+	}
+	return color.RGBA{mix(c.R), mix(c.G), mix(c.B), c.A}
+}
+
+// rgb8 returns the creator colour.
+func rgb8(c color.RGBA) creator.Color {
+	return creator.ColorRGBFrom8bit(c.R, c.G, c.B)
+}
+
+// text returns the string, recording the error, if the font can't display
+// any of the characters.
+func (u *unipdfWriter) text(s string) string {
+	if r, ok := u.face.missing(s, styleRegular); ok && u.err == nil {
+		u.err = fmt.Errorf("font cannot display %q in %q, use a font that supports it", r, s)
+	}
+	return s
+}
+
+// loadFonts loads the regular and bold fonts of the family: one of the TTF
+// fonts, the bundled GoFont or a standard font.
+func (u *unipdfWriter) loadFonts(family string, ttfs []TTF) error {
+	for _, ttf := range ttfs {
+		if !strings.EqualFold(ttf.Family, family) {
+			continue
+		}
+		regular, err := ioutil.ReadFile(ttf.Regular)
+		if err != nil {
+			return fmt.Errorf("font %s: %s", family, err)
+		}
+		bold := regular
+		if ttf.Bold != "" {
+			if bold, err = ioutil.ReadFile(ttf.Bold); err != nil {
+				return fmt.Errorf("font %s: %s", family, err)
+			}
+		}
+		return u.loadTTF(family, regular, bold)
+	}
+	var regular, bold model.StdFontName
+	switch strings.ToLower(family) {
+	case strings.ToLower(GoFont):
+		return u.loadTTF(family, goregular.TTF, gobold.TTF)
+	case strings.ToLower(times):
+		regular, bold = model.TimesRomanName, model.TimesBoldName
+	case strings.ToLower(courier):
+		regular, bold = model.CourierName, model.CourierBoldName
+	default:
+		regular, bold = model.HelveticaName, model.HelveticaBoldName
+	}
+	var err error
+	if u.regular, err = model.NewStandard14Font(regular); err != nil {
+		return err
+	}
+	if u.bold, err = model.NewStandard14Font(bold); err != nil {
+		return err
+	}
+	u.face = &fontFace{core: true}
+	return nil
+}
+
+// loadTTF loads the TTF fonts data as composite fonts.
+func (u *unipdfWriter) loadTTF(family string, regular, bold []byte) error {
+	font, err := sfnt.Parse(regular)
+	if err != nil {
+		return fmt.Errorf("font %s: %s", family, err)
+	}
+	u.face = &fontFace{faces: map[string]*sfnt.Font{styleRegular: font}}
+	if u.regular, err = compositeFont(regular); err != nil {
+		return fmt.Errorf("font %s: %s", family, err)
+	}
+	if u.bold, err = compositeFont(bold); err != nil {
+		return fmt.Errorf("font %s: %s", family, err)
+	}
+	return nil
+}
+
+// compositeFont returns the composite font of the TTF font data.  unipdf
+// loads fonts from files only.
+func compositeFont(data []byte) (*model.PdfFont, error) {
+	f, err := ioutil.TempFile("", "font-*.ttf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return model.NewCompositePdfFontFromTTFFile(f.Name())
+}
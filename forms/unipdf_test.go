@@ -0,0 +1,98 @@
+package forms
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+func TestUnipdfForm_Write(t *testing.T) {
+	tests := []struct {
+		name      string
+		theme     *Theme
+		fields    InvoiceFields
+		appendix  bool
+		wantPages int
+		wantErr   string
+	}{
+		{"ok", nil, InvoiceFields{Date: time.Now(), Remarks: "thanks", Status: StatusPaid, BillTo: Address{Name: "ООО «Ромашка»"}}, false, 1, ""},
+		{"appendix", &Theme{Base: "modern", Orientation: "landscape"}, InvoiceFields{Date: time.Now(), Status: StatusDraft}, true, 2, ""},
+		{"core font", &Theme{Font: helvetica}, InvoiceFields{BillTo: Address{Name: "ООО «Ромашка»"}}, false, 0, "cannot display"},
+		{"payment code", nil, InvoiceFields{PaymentCode: PaymentEPC}, false, 0, "not supported"},
+		{"unknown status", nil, InvoiceFields{Status: "sent"}, false, 0, "unknown invoice status"},
+		{"unsupported language", nil, InvoiceFields{Language: "xx"}, false, 0, "unsupported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRenderer(RendererUnipdf, "42", tt.theme)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.AddEntry("work", "1.00", "100.00", "100.00").
+				AddSubTotal("SUBTOTAL", "100.00").
+				AddSubTotal("TAX", "0.00").
+				SetTotal("", "100.00").
+				AddAccountDetail("Bank", "ACME Bank")
+			if tt.appendix {
+				day := time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+				r.Contents().AddAppendixGroup("ACME-1", []AppendixRow{
+					{Date: day, Start: day, End: day.Add(time.Hour), Description: "work", Hours: decimal.New(1, 0)},
+				}, decimal.New(1, 0))
+			}
+			var buf bytes.Buffer
+			err = r.Write(&buf, &tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UnipdfForm.Write() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnipdfForm.Write() unexpected error = %v", err)
+			}
+			pdf, err := model.NewPdfReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("UnipdfForm.Write() output is not a PDF: %v", err)
+			}
+			if n, _ := pdf.GetNumPages(); n != tt.wantPages {
+				t.Errorf("UnipdfForm.Write() pages = %d, want %d", n, tt.wantPages)
+			}
+		})
+	}
+}
+
+func Test_withUnipdfNotice(t *testing.T) {
+	if UnipdfLicensed() {
+		t.Skip("unipdf is licensed")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	f, err := NewUnipdf("42", nil)
+	if err == nil {
+		f.AddEntry("work", "1.00", "100.00", "100.00").SetTotal("", "100.00")
+		err = f.Write(ioutil.Discard, &InvoiceFields{Date: time.Now()})
+	}
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > 0 {
+		t.Errorf("unipdf printed to stdout: %q", out)
+	}
+}
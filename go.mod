@@ -4,14 +4,16 @@ go 1.13
 
 require (
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
+	github.com/gunnsth/pkcs7 v0.0.0-20181213175627-3cffc6fbfe83
 	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/shopspring/decimal v1.3.1
 	github.com/unidoc/unipdf/v3 v3.3.0
-	golang.org/x/crypto v0.6.0 // indirect
-	golang.org/x/image v0.5.0 // indirect
+	golang.org/x/crypto v0.6.0
+	golang.org/x/image v0.5.0
 	golang.org/x/net v0.7.0
 	golang.org/x/oauth2 v0.5.0
 	google.golang.org/api v0.110.0
 	google.golang.org/genproto v0.0.0-20230216225411-c8e22ba71e44 // indirect
 	gopkg.in/yaml.v3 v3.0.1
+	rsc.io/qr v0.2.0
 )
@@ -1,11 +1,13 @@
 package sheet2inv
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/rusq/sheet2inv/bugtracker"
+	"github.com/rusq/sheet2inv/forms"
 
 	"github.com/shopspring/decimal"
 )
@@ -25,8 +27,47 @@ type Invoice struct {
 
 	Total decimal.Decimal // invoice total
 
+	Adjustments []Adjustment    `yaml:",omitempty"` // credits and charges applied to the total
+	Retainer    *RetainerUsage  `yaml:",omitempty"` // retainer usage, if the retainer is configured
+	Budgets     []*BudgetStatus `yaml:",omitempty"` // budget burn-down
+
+	err    error           // budget check error
+	warned map[string]bool // budgets that were reported as exceeded
+
 	tsIssues map[string][]*TsEntry
+	fixed    map[string]InvoiceEntry // fixed fee entries
 	ticketer bugtracker.Ticketer
+
+	recurring *RecurringInvoice // set, if the invoice is a recurring one
+	status    string            // status override, see SetStatus
+}
+
+// Adjustment kinds.
+const (
+	AdjustRetainer = "retainer" // retainer credit
+	AdjustOverage  = "overage"  // difference of the overage rate
+)
+
+// Adjustment is an amount added to (or subtracted from) the invoice total,
+// i.e. retainer credit.  The name is localized, when the invoice is
+// written, see name.
+type Adjustment struct {
+	Kind     string          // see Adjust* constants
+	Quantity decimal.Decimal // retainer used, or overage hours
+	Unit     string          `yaml:",omitempty"` // quantity unit, see Retainer* units
+	Amount   decimal.Decimal
+}
+
+// name returns the localized name of the adjustment, i.e. "RETAINER (8.00
+// hrs)".
+func (a *Adjustment) name(loc *forms.Locale, currency string) string {
+	switch a.Kind {
+	case AdjustRetainer:
+		return loc.Format(forms.LabelRetainer, formatRetainer(loc, a.Unit, currency, a.Quantity))
+	case AdjustOverage:
+		return loc.Format(forms.LabelOverage, loc.Hours(a.Quantity))
+	}
+	return a.Kind
 }
 
 type InvoiceEntry struct {
@@ -36,6 +77,16 @@ type InvoiceEntry struct {
 	Duration time.Duration
 	Rate     decimal.Decimal
 	Total    decimal.Decimal
+	Capped   bool `yaml:",omitempty"` // total was capped by the budget
+	Fixed    bool `yaml:",omitempty"` // fixed fee entry
+}
+
+// hours returns the billed hours of the entry.
+func (e *InvoiceEntry) hours() decimal.Decimal {
+	if e.Capped && e.Rate.IsPositive() {
+		return e.Total.Div(e.Rate)
+	}
+	return decimal.NewFromFloat(e.Duration.Hours())
 }
 
 func NewInvoices(values *InvoiceValues, ticketer bugtracker.Ticketer) *Invoices {
@@ -51,6 +102,16 @@ func (invs *Invoices) Append(e *TsEntry) {
 	}
 }
 
+// IDs returns the invoice IDs in the natural order.
+func (invs *Invoices) IDs() []string {
+	ids := make([]string, 0, len(invs.Invoices))
+	for id := range invs.Invoices {
+		ids = append(ids, id)
+	}
+	sortNatural(ids)
+	return ids
+}
+
 // Get returns the invoice by invoiceID
 func (invs *Invoices) Get(invoiceID string) *Invoice {
 	return invs.Invoices[invoiceID]
@@ -77,6 +138,22 @@ func (i *Invoice) Add(e *TsEntry) *Invoice {
 	return i
 }
 
+// AddFixed adds the fixed fee entry with the quantity and the unit price.
+func (i *Invoice) AddFixed(description string, qty, price decimal.Decimal) *Invoice {
+	if i.fixed == nil {
+		i.fixed = make(map[string]InvoiceEntry, 1)
+	}
+	key := fmt.Sprintf("%03d", len(i.fixed)+1)
+	i.fixed[key] = InvoiceEntry{
+		Summary:  description,
+		Duration: time.Duration(qty.Mul(decimal.NewFromInt(int64(time.Hour))).IntPart()),
+		Rate:     price,
+		Total:    qty.Mul(price),
+	}
+	i.Recalculate()
+	return i
+}
+
 // Recalculate recalculates all fields.  If IssueSummaryFunc is provided
 // it is called to fetch the summary from the Bugtracking system
 func (i *Invoice) Recalculate() *Invoice {
@@ -84,8 +161,15 @@ func (i *Invoice) Recalculate() *Invoice {
 		i.Entries = make(map[string]InvoiceEntry)
 	}
 	i.Total = decimal.New(0, 0)
+	var hours decimal.Decimal
 
-	for issue, tsEntries := range i.tsIssues {
+	issues := make([]string, 0, len(i.tsIssues))
+	for issue := range i.tsIssues {
+		issues = append(issues, issue)
+	}
+	sortNatural(issues) // ticketing system is queried in the stable order
+	for _, issue := range issues {
+		tsEntries := i.tsIssues[issue]
 		entry := InvoiceEntry{
 			Total: decimal.New(0, 0),
 		}
@@ -98,12 +182,14 @@ func (i *Invoice) Recalculate() *Invoice {
 					continue
 				}
 				entry.Duration += item.duration
+				// each issue is billed for its share of the entry
+				// duration, so that the entry is billed once.
+				entry.Rate = tsEntry.rate.Mul(tsEntry.multiplier)
+				entry.Total = entry.Total.Add(entry.Rate.Mul(decimal.NewFromFloat(item.duration.Hours())))
 				text = append(text, item.Description)
 			}
 			entry.Issue = issue
 			entry.Details = append(entry.Details, strings.Join(text, "; "))
-			entry.Rate = tsEntry.rate.Mul(tsEntry.multiplier)
-			entry.Total = entry.Total.Add(entry.Rate.Mul(decimal.NewFromFloat(tsEntry.Duration.Hours())))
 
 			if i.ticketer != nil {
 				var err error
@@ -115,8 +201,117 @@ func (i *Invoice) Recalculate() *Invoice {
 		}
 
 		i.Entries[issue] = entry
+	}
+	for key, entry := range i.fixed {
+		entry.Fixed = true
+		i.Entries[key] = entry
+	}
+
+	i.checkBudgets()
+
+	for _, entry := range i.Entries {
 		i.Total = i.Total.Add(entry.Total)
+		hours = hours.Add(entry.hours())
+	}
+
+	i.Adjustments = nil
+	i.Retainer = nil
+	if r := i.values.Retainer; r != nil {
+		i.Retainer = r.usage(i.InvoiceID, hours, i.Total)
+		i.Adjustments = append(i.Adjustments, i.Retainer.adjustments(r.Unit, i.Total)...)
 	}
 
 	return i
 }
+
+// checkBudgets checks the invoice entries against configured budgets.
+func (i *Invoice) checkBudgets() {
+	i.Budgets = nil
+	i.err = nil
+	period := i.period()
+	for _, b := range i.values.Budgets {
+		st, err := b.apply(i.InvoiceID, period, i.Entries)
+		if err != nil && i.err == nil {
+			i.err = err
+		}
+		if st.Exceeded && b.Policy == PolicyWarn && !i.warned[b.Name] {
+			if i.warned == nil {
+				i.warned = make(map[string]bool)
+			}
+			i.warned[b.Name] = true
+			log.Printf("warning: invoice %s: budget %q exceeded", i.InvoiceID, b.Name)
+		}
+		i.Budgets = append(i.Budgets, st)
+	}
+}
+
+// period returns the invoicing period key.
+func (i *Invoice) period() string {
+	fields := i.values.InvoiceFields
+	if fields.PeriodStart.IsZero() {
+		return fields.Date.Format(periodFmt)
+	}
+	return fields.PeriodStart.Format(periodFmt)
+}
+
+// Err returns the error, if the invoice has failed the budget check.
+func (i *Invoice) Err() error {
+	return i.err
+}
+
+// Net returns the invoice total with all adjustments applied, before tax.
+func (i *Invoice) Net() decimal.Decimal {
+	net := i.Total
+	for _, adj := range i.Adjustments {
+		net = net.Add(adj.Amount)
+	}
+	return net
+}
+
+// SetStatus overrides the configured invoice status, i.e. to print the
+// draft.
+func (i *Invoice) SetStatus(status string) error {
+	if err := forms.ValidStatus(status); err != nil {
+		return err
+	}
+	i.status = strings.ToLower(status)
+	return nil
+}
+
+// Status returns the invoice status: the status set with SetStatus, or the
+// configured one.  If neither is set, the invoice, that is fully covered by
+// the retainer or by the recorded payments, is paid.
+func (i *Invoice) Status() string {
+	if i.status != "" {
+		return i.status
+	}
+	if st := i.values.InvoiceFields.Status; st != "" {
+		return strings.ToLower(st)
+	}
+	if i.Retainer != nil && i.Retainer.Credit.IsPositive() && i.balanceDue().IsZero() {
+		return forms.StatusPaid
+	}
+	if paid := i.paid(); paid.IsPositive() && paid.GreaterThanOrEqual(i.balanceDue().Round(2)) {
+		return forms.StatusPaid
+	}
+	return forms.StatusIssued
+}
+
+// Commit records the invoice against the persistent balances, such as
+// retainer and budgets.  The configuration should be saved afterwards.
+func (i *Invoice) Commit() {
+	switch i.Status() {
+	case forms.StatusDraft, forms.StatusVoid:
+		// not issued, balances are not drawn down.
+		return
+	}
+	if r := i.values.Retainer; r != nil && i.Retainer != nil {
+		r.draw(i.InvoiceID, i.values.InvoiceFields.Date, i.Retainer)
+	}
+	for _, st := range i.Budgets {
+		st.budget.record(st, i.InvoiceID)
+	}
+	if i.recurring != nil {
+		i.recurring.markIssued(i.period())
+	}
+}
@@ -0,0 +1,113 @@
+package sheet2inv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// Timesheet appendix detail levels.
+const (
+	AppendixNone  = ""      // no appendix
+	AppendixDay   = "day"   // one row per issue and day
+	AppendixEntry = "entry" // every timesheet entry, with day subtotals
+)
+
+func validAppendix(level string) error {
+	switch level {
+	case AppendixNone, AppendixDay, AppendixEntry:
+		return nil
+	}
+	return fmt.Errorf("invalid appendix detail level %q, must be %q or %q", level, AppendixDay, AppendixEntry)
+}
+
+// addAppendix adds the timesheet appendix with the configured detail level to
+// the form content.  Fixed fee entries have no timesheet and are skipped.
+func (i *Invoice) addAppendix(f *forms.Content) {
+	level := i.values.Appendix
+	if level == AppendixNone {
+		return
+	}
+	for _, issue := range i.sortedKeys() {
+		entries, ok := i.tsIssues[issue]
+		if !ok {
+			continue
+		}
+		title := issue
+		if summary := i.values.IssueSummary[issue]; summary != "" {
+			title += ": " + summary
+		} else if summary := i.Entries[issue].Summary; summary != "" {
+			title += ": " + summary
+		}
+		rows, hours := appendixRows(issue, entries, level)
+		f.AddAppendixGroup(title, rows, hours)
+	}
+}
+
+// appendixRows returns the appendix rows of the issue and the total hours.
+// Items are counted the same way as in the invoice entry, so that the total
+// matches the invoice line.
+func appendixRows(issue string, entries []*TsEntry, level string) ([]forms.AppendixRow, decimal.Decimal) {
+	sorted := make([]*TsEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var (
+		rows  []forms.AppendixRow
+		total decimal.Decimal
+
+		day     time.Time       // current day
+		dayHrs  decimal.Decimal // current day hours
+		dayRows int             // number of items in the current day
+		details []string        // current day descriptions
+	)
+	flush := func() {
+		if dayRows == 0 {
+			return
+		}
+		switch level {
+		case AppendixDay:
+			rows = append(rows, forms.AppendixRow{Date: day, Description: strings.Join(details, "; "), Hours: dayHrs})
+		case AppendixEntry:
+			if dayRows > 1 {
+				rows = append(rows, forms.AppendixRow{Date: day, Hours: dayHrs, DayTotal: true})
+			}
+		}
+		total = total.Add(dayHrs)
+		dayHrs, dayRows, details = decimal.Zero, 0, nil
+	}
+	for _, e := range sorted {
+		if !sameDay(e.Start, day) {
+			flush()
+			day = e.Start
+		}
+		for _, item := range e.Items {
+			if item.Issue != issue {
+				continue
+			}
+			hours := decimal.NewFromFloat(item.duration.Hours())
+			dayHrs = dayHrs.Add(hours)
+			dayRows++
+			if level == AppendixEntry {
+				rows = append(rows, forms.AppendixRow{Date: e.Start, Start: e.Start, End: e.End, Description: item.Description, Hours: hours})
+			} else if item.Description != "" {
+				details = append(details, item.Description)
+			}
+		}
+	}
+	flush()
+	return rows, total
+}
+
+// sameDay returns true, if a and b are on the same calendar day.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,73 @@
+package sheet2inv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+func Test_appendixRows(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 3, day, hour, min, 0, 0, time.UTC)
+	}
+	entry := func(start, end time.Time, items ...Item) *TsEntry {
+		return (&TsEntry{Start: start, End: end, Items: items}).Recalculate()
+	}
+	entries := []*TsEntry{
+		entry(at(2, 9, 0), at(2, 10, 30), Item{Issue: "A-1", Description: "review"}),
+		entry(at(1, 9, 0), at(1, 11, 0), Item{Issue: "A-1", Description: "design"}, Item{Issue: "B-2", Description: "other"}),
+		entry(at(2, 14, 0), at(2, 15, 0), Item{Issue: "A-1", Description: "fixes"}),
+	}
+	tests := []struct {
+		name      string
+		level     string
+		wantRows  []forms.AppendixRow
+		wantHours string
+	}{
+		{"day", AppendixDay, []forms.AppendixRow{
+			{Date: at(1, 9, 0), Description: "design", Hours: dec("1")},
+			{Date: at(2, 9, 0), Description: "review; fixes", Hours: dec("2.5")},
+		}, "3.5"},
+		{"entry", AppendixEntry, []forms.AppendixRow{
+			{Date: at(1, 9, 0), Start: at(1, 9, 0), End: at(1, 11, 0), Description: "design", Hours: dec("1")},
+			{Date: at(2, 9, 0), Start: at(2, 9, 0), End: at(2, 10, 30), Description: "review", Hours: dec("1.5")},
+			{Date: at(2, 14, 0), Start: at(2, 14, 0), End: at(2, 15, 0), Description: "fixes", Hours: dec("1")},
+			{Date: at(2, 9, 0), Hours: dec("2.5"), DayTotal: true},
+		}, "3.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, hours := appendixRows("A-1", entries, tt.level)
+			if !hours.Equal(dec(tt.wantHours)) {
+				t.Errorf("appendixRows() hours = %s, want %s", hours, tt.wantHours)
+			}
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("appendixRows() = %v, want %v", rows, tt.wantRows)
+			}
+			for i := range rows {
+				got, want := rows[i], tt.wantRows[i]
+				if !got.Hours.Equal(want.Hours) {
+					t.Errorf("row %d: hours = %s, want %s", i, got.Hours, want.Hours)
+				}
+				got.Hours, want.Hours = decimal.Zero, decimal.Zero
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("row %d: %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_validAppendix(t *testing.T) {
+	for _, level := range []string{AppendixNone, AppendixDay, AppendixEntry} {
+		if err := validAppendix(level); err != nil {
+			t.Errorf("validAppendix(%q) unexpected error = %v", level, err)
+		}
+	}
+	if err := validAppendix("issue"); err == nil {
+		t.Errorf("validAppendix(%q) expected error", "issue")
+	}
+}
@@ -0,0 +1,173 @@
+package sheet2inv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rusq/sheet2inv/einvoice"
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+const defCurrency = "USD"
+
+// Document returns the structured e-invoice document for the invoice.
+func (i *Invoice) Document() (*einvoice.Document, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	if !i.values.Shipping.IsZero() {
+		// shipping is not taxed, and EN 16931 charges have the tax category
+		// of the invoice.
+		return nil, errors.New("e-invoice: shipping is not supported")
+	}
+	fields := &i.values.InvoiceFields
+	doc := einvoice.Document{
+		ID:          i.InvoiceID,
+		IssueDate:   fields.Date,
+		DueDate:     fields.Due,
+		Currency:    i.currency(),
+		Note:        fields.Remarks,
+		PeriodStart: fields.PeriodStart,
+		PeriodEnd:   fields.PeriodEnd,
+		// there's no buyer reference in the invoice fields, purchase order
+		// number or invoice number is used instead.
+		BuyerReference:    i.InvoiceID,
+		OrderReference:    fields.PONumber,
+		ContractReference: fields.ContractID,
+
+		Seller: party(fields.Address),
+		Buyer:  party(fields.BillTo),
+
+		TaxCategory: i.values.TaxCategory,
+		TaxPercent:  i.values.Tax.Mul(decimal.New(100, 0)),
+
+		Payment: einvoice.Payment{
+			Account:     fields.Account,
+			AccountName: fields.Address.Name,
+			Bank:        fields.BIC,
+			Reference:   i.InvoiceID,
+		},
+	}
+	if fields.IBAN != "" {
+		doc.Payment.Account = strings.Replace(fields.IBAN, " ", "", -1)
+	}
+	if fields.PONumber != "" {
+		doc.BuyerReference = fields.PONumber
+	}
+	if doc.TaxCategory == "" {
+		doc.TaxCategory = einvoice.TaxStandard
+		if i.values.Tax.IsZero() {
+			doc.TaxCategory = einvoice.TaxZero
+		}
+	}
+
+	loc, err := forms.NewLocale(fields.Language, fields.SecondLanguage)
+	if err != nil {
+		return nil, err
+	}
+	for n, line := range i.lines(loc, i.knownSummary) {
+		doc.Lines = append(doc.Lines, einvoice.Line{
+			ID:       strconv.Itoa(n + 1),
+			Name:     line.Description,
+			Quantity: line.hours(),
+			Price:    line.Rate,
+			Net:      line.Total,
+		})
+	}
+	for _, adj := range i.Adjustments {
+		doc.Adjustments = append(doc.Adjustments, einvoice.Adjustment{Reason: adj.name(loc, doc.Currency), Amount: adj.Amount})
+	}
+	return &doc, nil
+}
+
+// ToUBL generates the UBL 2.1 (Peppol BIS Billing 3.0) xml file.
+func (i *Invoice) ToUBL(filename string) error {
+	return writeFile(filename, i.WriteUBL)
+}
+
+// WriteUBL writes the invoice to w as UBL 2.1 (Peppol BIS Billing 3.0) xml.
+func (i *Invoice) WriteUBL(w io.Writer) error {
+	doc, err := i.Document()
+	if err != nil {
+		return err
+	}
+	return doc.WriteUBL(w)
+}
+
+func (i *Invoice) currency() string {
+	if i.values.Currency == "" {
+		return defCurrency
+	}
+	return i.values.Currency
+}
+
+func party(addr forms.Address) einvoice.Party {
+	name := addr.Organisation
+	if name == "" {
+		name = addr.Name
+	}
+	p := einvoice.Party{
+		Name:        name,
+		Street:      addr.Street,
+		Additional:  addr.Floor,
+		City:        addr.Town,
+		Postcode:    addr.Postcode,
+		CountryCode: addr.CountryCode,
+		LegalID:     addr.Registration,
+		Email:       addr.Email,
+		Phone:       addr.Phone,
+	}
+	// VAT and GST identifiers are VAT identifiers in EN 16931, the first
+	// identifier of any other scheme is the tax registration identifier.
+	for _, id := range addr.TaxIDList() {
+		switch strings.ToUpper(id.Scheme) {
+		case "VAT", "GST":
+			if p.VATID == "" {
+				p.VATID = id.Value
+			}
+		default:
+			if p.TaxID == "" {
+				p.TaxID = id.Value
+			}
+		}
+	}
+	return p
+}
+
+// ToFacturX generates the Factur-X (ZUGFeRD) hybrid PDF invoice with the
+// Cross Industry Invoice xml of the profile.
+func (i *Invoice) ToFacturX(filename string, profile string) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return i.WriteFacturX(w, profile)
+	})
+}
+
+// WriteFacturX renders the invoice PDF and writes it to w with the embedded
+// Cross Industry Invoice xml of the profile (MINIMUM, BASIC or EN16931).
+func (i *Invoice) WriteFacturX(w io.Writer, profile string) error {
+	doc, err := i.Document()
+	if err != nil {
+		return err
+	}
+	// PDF/A requires all fonts to be embedded.
+	theme := i.theme()
+	theme.EmbedFonts = true
+	var pdf bytes.Buffer
+	md, err := i.writePDF(&pdf, theme)
+	if err != nil {
+		return err
+	}
+	doc.Info = &einvoice.Info{
+		Title:    md.Title,
+		Author:   md.Author,
+		Subject:  md.Subject,
+		Keywords: strings.Join(md.Keywords, ", "),
+		Creator:  md.Creator,
+		Created:  md.Created,
+	}
+	return doc.WriteFacturX(w, pdf.Bytes(), profile)
+}
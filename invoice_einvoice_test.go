@@ -0,0 +1,94 @@
+package sheet2inv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/unidoc/unipdf/v3/core"
+)
+
+func TestInvoice_WriteFacturX(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2020, 3, 2, h, 0, 0, 0, time.UTC)
+	}
+	values := InvoiceValues{
+		Tax:          dec("0.2"),
+		Currency:     "EUR",
+		IssueSummary: map[string]string{"A-1": "Development"},
+		InvoiceFields: forms.InvoiceFields{
+			Date:    time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+			Due:     time.Date(2020, 4, 15, 0, 0, 0, 0, time.UTC),
+			IBAN:    "DE89 3704 0044 0532 0130 00",
+			Address: forms.Address{Name: "Seller Ltd", CountryCode: "DE", TaxIDs: []forms.TaxID{{Scheme: "VAT", Value: "DE123456789"}}},
+			BillTo:  forms.Address{Name: "ACME", CountryCode: "FR"},
+		},
+	}
+	inv := NewInvoice(&values, "42", nil)
+	inv.Add(&TsEntry{Invoice: "42", Start: at(9), End: at(12), Items: []Item{{Issue: "A-1"}}, rate: dec("100"), multiplier: dec("1")})
+	inv.Recalculate()
+
+	var buf bytes.Buffer
+	if err := inv.WriteFacturX(&buf, "basic"); err != nil {
+		t.Fatal(err)
+	}
+	parser, err := core.NewParser(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := parser.Resolve(parser.GetTrailer().Get("Root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalog, ok := core.GetDict(root)
+	if !ok {
+		t.Fatal("catalog is not found")
+	}
+	resolve := func(obj core.PdfObject) core.PdfObject {
+		obj, err := parser.Resolve(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	intents, ok := core.GetArray(resolve(catalog.Get("OutputIntents")))
+	if !ok || intents.Len() != 1 {
+		t.Fatalf("OutputIntents = %v, want one intent", catalog.Get("OutputIntents"))
+	}
+	intent, _ := core.GetDict(resolve(intents.Get(0)))
+	if s, _ := core.GetNameVal(intent.Get("S")); s != "GTS_PDFA1" {
+		t.Errorf("output intent /S = %q, want GTS_PDFA1", s)
+	}
+	af, ok := core.GetArray(resolve(catalog.Get("AF")))
+	if !ok || af.Len() != 1 {
+		t.Fatalf("AF = %v, want one associated file", catalog.Get("AF"))
+	}
+	spec, _ := core.GetDict(resolve(af.Get(0)))
+	if name, _ := core.GetStringVal(spec.Get("UF")); name != "factur-x.xml" {
+		t.Errorf("associated file = %q, want factur-x.xml", name)
+	}
+	metadata, ok := core.GetStream(resolve(catalog.Get("Metadata")))
+	if !ok {
+		t.Fatal("Metadata is not found")
+	}
+	xmp := string(metadata.Stream)
+	for _, want := range []string{
+		"<pdfaid:part>3</pdfaid:part>",
+		"<pdfaid:conformance>B</pdfaid:conformance>",
+		"<fx:ConformanceLevel>BASIC</fx:ConformanceLevel>",
+	} {
+		if !strings.Contains(xmp, want) {
+			t.Errorf("metadata does not contain %s", want)
+		}
+	}
+}
+
+func TestInvoice_Document_shipping(t *testing.T) {
+	values := InvoiceValues{Shipping: dec("12.5")}
+	if _, err := NewInvoice(&values, "42", nil).Document(); err == nil {
+		t.Error("Document() expected error for shipping")
+	}
+}
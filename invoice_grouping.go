@@ -0,0 +1,410 @@
+package sheet2inv
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rusq/sheet2inv/bugtracker"
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// Line grouping strategies.
+const (
+	GroupIssue  = "issue"  // one line per issue, the default
+	GroupDay    = "day"    // one line per day, with all issues of the day
+	GroupWeek   = "week"   // one line per ISO week and issue
+	GroupEpic   = "epic"   // one line per epic, issues without the epic are on their own lines
+	GroupSingle = "single" // one line with all hours
+)
+
+// Invoice line sort orders.  The order is descending, if prefixed with "-",
+// i.e. "-amount".  Fixed fee lines are always on top.
+const (
+	SortKey    = "key"    // natural order of the issue key, the default
+	SortDate   = "date"   // first date of the line
+	SortHours  = "hours"  // line hours
+	SortAmount = "amount" // line total
+)
+
+func validLineSort(order string) error {
+	switch strings.TrimPrefix(order, "-") {
+	case "", SortKey, SortDate, SortHours, SortAmount:
+		return nil
+	}
+	return fmt.Errorf("invalid line sort order %q, must be one of: %s, %s, %s, %s", order, SortKey, SortDate, SortHours, SortAmount)
+}
+
+// defDescriptions are the default line description templates of the
+// grouping strategies.
+var defDescriptions = map[string]string{
+	GroupIssue:  "{issue}: {summary}",
+	GroupDay:    "{date}: {issue}",
+	GroupWeek:   "{issue}: {summary} ({week})",
+	GroupEpic:   "{epic}: {summary}",
+	GroupSingle: "{summary} ({dates})",
+}
+
+// reTemplateVar matches the description template placeholder.
+var reTemplateVar = regexp.MustCompile(`\{(\w+)\}`)
+
+// templateVars are the description template placeholders.
+var templateVars = map[string]bool{
+	"issue":   true, // issue IDs of the line, comma separated
+	"summary": true, // issue summary, epic summary for the epic line, or "Consulting services" for the single line
+	"epic":    true, // epic ID, or issue ID, if the issue has no epic
+	"date":    true, // first date of the line
+	"dates":   true, // date range of the line
+	"week":    true, // ISO week of the first date, i.e. 2020-W10
+	"hours":   true, // hours of the line
+	"details": true, // timesheet descriptions, separated with semicolons
+}
+
+// Grouping is the invoice line grouping.  Fixed fee entries are always on
+// their own lines, and so are the items with different rates.
+type Grouping struct {
+	By          string            `yaml:"by,omitempty"`          // grouping strategy, see Group* constants, default is "issue"
+	Description string            `yaml:"description,omitempty"` // line description template, i.e. "{issue}: {summary} ({dates})"
+	Epics       map[string]string `yaml:"epics,omitempty"`       // issue epics, override the ticketing system
+}
+
+func (g *Grouping) validate() error {
+	if g == nil {
+		return nil
+	}
+	if _, ok := defDescriptions[g.strategy()]; !ok {
+		return fmt.Errorf("unknown line grouping %q, must be one of: %s, %s, %s, %s, %s", g.By, GroupIssue, GroupDay, GroupWeek, GroupEpic, GroupSingle)
+	}
+	for _, m := range reTemplateVar.FindAllStringSubmatch(g.Description, -1) {
+		if !templateVars[m[1]] {
+			return fmt.Errorf("line description: unknown placeholder %s", m[0])
+		}
+	}
+	return nil
+}
+
+// strategy returns the grouping strategy.
+func (g *Grouping) strategy() string {
+	if g == nil || g.By == "" {
+		return GroupIssue
+	}
+	return strings.ToLower(g.By)
+}
+
+// description returns the line description template.
+func (g *Grouping) description() string {
+	if g == nil || g.Description == "" {
+		return defDescriptions[g.strategy()]
+	}
+	return g.Description
+}
+
+// invoiceLine is the invoice line: the entries of the group, or the fixed fee
+// entry.
+type invoiceLine struct {
+	InvoiceEntry
+	Description string
+
+	key    string    // entry or group key
+	issues []string  // issues of the line
+	from   time.Time // first date
+	fixed  bool      // fixed fee line
+}
+
+// lineGroup accumulates the timesheet items of the line.  The items of the
+// line have the same rate, so that the line quantity multiplied by the rate
+// is the line total.
+type lineGroup struct {
+	key    string
+	epic   string
+	issues []string
+	from   time.Time
+	to     time.Time
+
+	entry InvoiceEntry
+}
+
+// portion is the part of the issue entry, billed on the day.
+type portion struct {
+	issue    string
+	date     time.Time
+	duration time.Duration
+	rate     decimal.Decimal
+	total    decimal.Decimal
+	details  string
+}
+
+// lines returns the invoice lines grouped by the configured strategy.  The
+// summary function returns the issue summary.
+func (i *Invoice) lines(loc *forms.Locale, summary func(*InvoiceEntry) string) []invoiceLine {
+	g := i.values.Grouping
+	tmpl := g.description()
+	strategy := g.strategy()
+
+	var (
+		lines  []invoiceLine
+		groups = make(map[string]*lineGroup)
+		order  []string
+	)
+	for _, key := range i.sortedKeys() {
+		entry := i.Entries[key]
+		if entry.Fixed {
+			lines = append(lines, fixedLine(key, entry))
+			continue
+		}
+		portions := i.portions(key)
+		if strategy == GroupIssue && singleRate(portions) {
+			// the line is the entry itself, only the dates are taken from
+			// the portions.
+			grp := lineGroup{key: key, epic: entry.Issue}
+			for _, p := range portions {
+				grp.extend(p)
+			}
+			grp.issues, grp.entry = []string{entry.Issue}, entry
+			lines = append(lines, i.line(&grp, tmpl, strategy, loc, summary))
+			continue
+		}
+		epic := entry.Issue
+		if strategy != GroupIssue {
+			epic = i.epic(entry.Issue)
+		}
+		for _, p := range portions {
+			var gk string
+			switch strategy {
+			case GroupIssue:
+				gk = key
+			case GroupDay:
+				gk = p.date.Format("2006-01-02")
+			case GroupWeek:
+				gk = isoWeek(p.date) + "\x00" + p.issue
+			case GroupEpic:
+				gk = epic
+			}
+			// items with different rates are on separate lines.
+			gk += "\x00" + p.rate.String()
+			grp, ok := groups[gk]
+			if !ok {
+				grp = &lineGroup{key: gk, epic: epic}
+				groups[gk] = grp
+				order = append(order, gk)
+			}
+			grp.entry.Capped = grp.entry.Capped || entry.Capped
+			grp.extend(p)
+		}
+	}
+	for _, gk := range order {
+		lines = append(lines, i.line(groups[gk], tmpl, strategy, loc, summary))
+	}
+	return sortLines(lines, i.values.LineSort)
+}
+
+// singleRate returns true if all portions have the same rate.
+func singleRate(portions []portion) bool {
+	for _, p := range portions {
+		if !p.rate.Equal(portions[0].rate) {
+			return false
+		}
+	}
+	return true
+}
+
+// fixedLine returns the line of the fixed fee entry.
+func fixedLine(key string, entry InvoiceEntry) invoiceLine {
+	return invoiceLine{InvoiceEntry: entry, Description: entry.Summary, key: key, fixed: true}
+}
+
+// sortLines sorts the lines in the order, see Sort* constants.  Fixed fee
+// lines go first, the ties are broken by the natural order of the keys.
+func sortLines(lines []invoiceLine, order string) []invoiceLine {
+	desc := strings.HasPrefix(order, "-")
+	by := strings.TrimPrefix(order, "-")
+	cmp := func(a, b *invoiceLine) int {
+		switch by {
+		case SortDate:
+			switch {
+			case a.from.Before(b.from):
+				return -1
+			case a.from.After(b.from):
+				return 1
+			}
+		case SortHours:
+			return a.hours().Cmp(b.hours())
+		case SortAmount:
+			return a.Total.Cmp(b.Total)
+		}
+		switch {
+		case naturalLess(a.key, b.key):
+			return -1
+		case naturalLess(b.key, a.key):
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(lines, func(x, y int) bool {
+		a, b := &lines[x], &lines[y]
+		if a.fixed != b.fixed {
+			return a.fixed
+		}
+		if a.fixed {
+			return naturalLess(a.key, b.key)
+		}
+		c := cmp(a, b)
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			return naturalLess(a.key, b.key)
+		}
+		return c < 0
+	})
+	return lines
+}
+
+// extend adds the portion to the group.
+func (grp *lineGroup) extend(p portion) {
+	if grp.from.IsZero() || p.date.Before(grp.from) {
+		grp.from = p.date
+	}
+	if p.date.After(grp.to) {
+		grp.to = p.date
+	}
+	found := false
+	for _, issue := range grp.issues {
+		found = found || issue == p.issue
+	}
+	if !found {
+		grp.issues = append(grp.issues, p.issue)
+	}
+	grp.entry.Rate = p.rate
+	grp.entry.Duration += p.duration
+	grp.entry.Total = grp.entry.Total.Add(p.total)
+	if p.details != "" {
+		grp.entry.Details = append(grp.entry.Details, p.details)
+	}
+}
+
+// line returns the invoice line of the group with the description.
+func (i *Invoice) line(grp *lineGroup, tmpl, strategy string, loc *forms.Locale, summary func(*InvoiceEntry) string) invoiceLine {
+	if len(grp.issues) == 1 && grp.entry.Issue == "" {
+		grp.entry.Issue = grp.issues[0]
+		grp.entry.Summary = i.Entries[grp.issues[0]].Summary
+	}
+	vars := func(name string) string {
+		switch name {
+		case "issue":
+			return strings.Join(grp.issues, ", ")
+		case "summary":
+			return i.lineSummary(grp, strategy, loc, summary)
+		case "epic":
+			return grp.epic
+		case "date":
+			return loc.Date(grp.from)
+		case "dates":
+			if sameDay(grp.from, grp.to) {
+				return loc.Date(grp.from)
+			}
+			return loc.Date(grp.from) + " - " + loc.Date(grp.to)
+		case "week":
+			return isoWeek(grp.from)
+		case "hours":
+			return loc.Number(decimal.NewFromFloat(grp.entry.Duration.Hours()))
+		case "details":
+			return strings.Join(grp.entry.Details, "; ")
+		}
+		return "{" + name + "}"
+	}
+	desc := reTemplateVar.ReplaceAllStringFunc(tmpl, func(m string) string {
+		return vars(m[1 : len(m)-1])
+	})
+	return invoiceLine{InvoiceEntry: grp.entry, Description: desc, key: grp.key, issues: grp.issues, from: grp.from}
+}
+
+// lineSummary returns the summary of the line: the summary of the issue, of
+// the epic, or of all issues of the line.
+func (i *Invoice) lineSummary(grp *lineGroup, strategy string, loc *forms.Locale, summary func(*InvoiceEntry) string) string {
+	switch {
+	case strategy == GroupSingle:
+		return loc.Label(forms.LabelServices)
+	case strategy == GroupEpic && (len(grp.issues) != 1 || grp.epic != grp.issues[0]):
+		epic := InvoiceEntry{Issue: grp.epic}
+		if i.ticketer != nil {
+			var err error
+			if epic.Summary, err = i.ticketer.Name(grp.epic); err != nil {
+				log.Println(grp.epic + " " + err.Error())
+			}
+		}
+		return summary(&epic)
+	}
+	summaries := make([]string, 0, len(grp.issues))
+	for _, issue := range grp.issues {
+		entry := i.Entries[issue]
+		summaries = append(summaries, summary(&entry))
+	}
+	return strings.Join(summaries, "; ")
+}
+
+// epic returns the epic of the issue: the configured one, the one from the
+// ticketing system, or the issue itself.
+func (i *Invoice) epic(issue string) string {
+	if g := i.values.Grouping; g != nil && g.Epics[issue] != "" {
+		return g.Epics[issue]
+	}
+	if ef, ok := i.ticketer.(bugtracker.EpicFinder); ok {
+		epic, err := ef.Epic(issue)
+		if err != nil {
+			log.Println(issue + " " + err.Error())
+		}
+		if epic != "" {
+			return epic
+		}
+	}
+	return issue
+}
+
+// portions returns the day portions of the issue entry, sorted by date.  If
+// the entry total was capped by the budget, the cap is distributed over the
+// portions in proportion to their amounts, so that the portions add up to the
+// entry total.
+func (i *Invoice) portions(issue string) []portion {
+	entries := make([]*TsEntry, len(i.tsIssues[issue]))
+	copy(entries, i.tsIssues[issue])
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Start.Before(entries[b].Start)
+	})
+	var (
+		pp  []portion
+		sum decimal.Decimal
+	)
+	for _, e := range entries {
+		rate := e.rate.Mul(e.multiplier)
+		for _, item := range e.Items {
+			if item.Issue != issue {
+				continue
+			}
+			total := rate.Mul(decimal.NewFromFloat(item.duration.Hours()))
+			pp = append(pp, portion{issue: issue, date: e.Start, duration: item.duration, rate: rate, total: total, details: item.Description})
+			sum = sum.Add(total)
+		}
+	}
+	entry := i.Entries[issue]
+	if !entry.Capped || sum.IsZero() || len(pp) == 0 {
+		return pp
+	}
+	rest := entry.Total
+	for n := range pp[:len(pp)-1] {
+		pp[n].total = pp[n].total.Mul(entry.Total).Div(sum).Round(2)
+		rest = rest.Sub(pp[n].total)
+	}
+	pp[len(pp)-1].total = rest
+	return pp
+}
+
+// isoWeek returns the ISO week of the date, i.e. "2020-W10".
+func isoWeek(t time.Time) string {
+	y, w := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", y, w)
+}
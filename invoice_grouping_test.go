@@ -0,0 +1,252 @@
+package sheet2inv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+)
+
+// fakeTracker is the ticketing system with the known issues and epics.
+type fakeTracker struct {
+	names map[string]string
+	epics map[string]string
+}
+
+func (f *fakeTracker) Name(issue string) (string, error) {
+	if name, ok := f.names[issue]; ok {
+		return name, nil
+	}
+	return "", errors.New("not found")
+}
+
+func (f *fakeTracker) Epic(issue string) (string, error) {
+	return f.epics[issue], nil
+}
+
+func testGroupingInvoice(g *Grouping) *Invoice {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 3, day, hour, min, 0, 0, time.UTC)
+	}
+	entry := func(start, end time.Time, rate string, items ...Item) *TsEntry {
+		return &TsEntry{Invoice: "1", Start: start, End: end, Items: items, rate: dec(rate), multiplier: dec("1")}
+	}
+	tracker := &fakeTracker{
+		names: map[string]string{"A-1": "Design", "A-10": "Review", "B-2": "Support", "E-1": "Platform"},
+		epics: map[string]string{"A-1": "E-1"},
+	}
+	inv := NewInvoice(&InvoiceValues{Grouping: g}, "1", tracker)
+	inv.Add(entry(at(2, 9, 0), at(2, 11, 0), "100", Item{Issue: "A-1", Description: "design"}, Item{Issue: "B-2", Description: "call"}))
+	inv.Add(entry(at(3, 9, 0), at(3, 10, 30), "100", Item{Issue: "A-1", Description: "review"}))
+	inv.Add(entry(at(10, 9, 0), at(10, 10, 0), "150", Item{Issue: "B-2", Description: "fix"}))
+	inv.AddFixed("Setup", dec("1"), dec("50"))
+	return inv
+}
+
+func TestInvoice_lines(t *testing.T) {
+	type line struct {
+		desc  string
+		hours string
+		rate  string
+		total string
+	}
+	tests := []struct {
+		name     string
+		grouping *Grouping
+		want     []line
+	}{
+		{"default", nil, []line{
+			{"Setup", "1", "50", "50"},
+			{"A-1: Design", "2.5", "100", "250"},
+			{"B-2: Support", "1", "100", "100"},
+			{"B-2: Support", "1", "150", "150"},
+		}},
+		{"day", &Grouping{By: GroupDay}, []line{
+			{"Setup", "1", "50", "50"},
+			{"02/03/2020: A-1, B-2", "2", "100", "200"},
+			{"03/03/2020: A-1", "1.5", "100", "150"},
+			{"10/03/2020: B-2", "1", "150", "150"},
+		}},
+		{"week", &Grouping{By: GroupWeek}, []line{
+			{"Setup", "1", "50", "50"},
+			{"A-1: Design (2020-W10)", "2.5", "100", "250"},
+			{"B-2: Support (2020-W10)", "1", "100", "100"},
+			{"B-2: Support (2020-W11)", "1", "150", "150"},
+		}},
+		{"epic", &Grouping{By: GroupEpic}, []line{
+			{"Setup", "1", "50", "50"},
+			{"B-2: Support", "1", "100", "100"},
+			{"B-2: Support", "1", "150", "150"},
+			{"E-1: Platform", "2.5", "100", "250"},
+		}},
+		{"epic override", &Grouping{By: GroupEpic, Epics: map[string]string{"B-2": "E-1"}}, []line{
+			{"Setup", "1", "50", "50"},
+			{"E-1: Platform", "3.5", "100", "350"},
+			{"E-1: Platform", "1", "150", "150"},
+		}},
+		{"single", &Grouping{By: GroupSingle}, []line{
+			{"Setup", "1", "50", "50"},
+			{"Consulting services (02/03/2020 - 03/03/2020)", "3.5", "100", "350"},
+			{"Consulting services (10/03/2020)", "1", "150", "150"},
+		}},
+		{"template", &Grouping{Description: "{issue} {hours}h: {details}"}, []line{
+			{"Setup", "1", "50", "50"},
+			{"A-1 2.50h: design; review", "2.5", "100", "250"},
+			{"B-2 1.00h: call", "1", "100", "100"},
+			{"B-2 1.00h: fix", "1", "150", "150"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testGroupingInvoice(tt.grouping)
+			loc, err := forms.NewLocale("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := inv.lines(loc, inv.knownSummary)
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines() = %+v, want %d lines", got, len(tt.want))
+			}
+			for n, want := range tt.want {
+				l := got[n]
+				if l.Description != want.desc {
+					t.Errorf("line %d: description = %q, want %q", n, l.Description, want.desc)
+				}
+				if h := l.hours(); !h.Equal(dec(want.hours)) {
+					t.Errorf("line %d: hours = %s, want %s", n, h, want.hours)
+				}
+				if !l.Rate.Equal(dec(want.rate)) || !l.Total.Equal(dec(want.total)) {
+					t.Errorf("line %d: rate, total = %s, %s, want %s, %s", n, l.Rate, l.Total, want.rate, want.total)
+				}
+				if net := l.hours().Mul(l.Rate); !net.Equal(l.Total) {
+					t.Errorf("line %d: hours * rate = %s, want the total %s", n, net, l.Total)
+				}
+			}
+		})
+	}
+}
+
+func TestInvoice_lines_sort(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"Setup", "A-1: Design", "A-10: Review", "B-2: Support", "B-2: Support"}},
+		{SortDate, []string{"Setup", "A-10: Review", "A-1: Design", "B-2: Support", "B-2: Support"}},
+		{"-" + SortHours, []string{"Setup", "A-1: Design", "A-10: Review", "B-2: Support", "B-2: Support"}},
+		{SortAmount, []string{"Setup", "A-10: Review", "B-2: Support", "B-2: Support", "A-1: Design"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			inv := testGroupingInvoice(nil)
+			inv.values.LineSort = tt.order
+			inv.Add(&TsEntry{
+				Invoice: "1",
+				Start:   time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC),
+				End:     time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC),
+				Items:   []Item{{Issue: "A-10"}},
+				rate:    dec("100"), multiplier: dec("1"),
+			})
+			loc, err := forms.NewLocale("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, l := range inv.lines(loc, inv.knownSummary) {
+				got = append(got, l.Description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvoice_lines_noIssue(t *testing.T) {
+	inv := testGroupingInvoice(nil)
+	inv.Add(&TsEntry{
+		Invoice: "1",
+		Start:   time.Date(2020, 3, 4, 9, 0, 0, 0, time.UTC),
+		End:     time.Date(2020, 3, 4, 11, 0, 0, 0, time.UTC),
+		Items:   []Item{{Description: "meeting"}},
+		rate:    dec("100"), multiplier: dec("1"),
+	})
+	loc, err := forms.NewLocale("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, l := range inv.lines(loc, inv.knownSummary) {
+		if l.Description == "Setup" {
+			if !l.fixed {
+				t.Error("fixed fee line is not fixed")
+			}
+			continue
+		}
+		if l.Issue != "" {
+			continue
+		}
+		found = true
+		if l.fixed || !l.hours().Equal(dec("2")) || !l.Rate.Equal(dec("100")) {
+			t.Errorf("line without the issue: fixed, hours, rate = %v, %s, %s, want false, 2, 100", l.fixed, l.hours(), l.Rate)
+		}
+	}
+	if !found {
+		t.Error("line without the issue is not found")
+	}
+}
+
+func TestInvoice_portions(t *testing.T) {
+	inv := testGroupingInvoice(nil)
+	entry := inv.Entries["A-1"]
+	entry.Total, entry.Capped = dec("200"), true
+	inv.Entries["A-1"] = entry
+
+	pp := inv.portions("A-1")
+	if len(pp) != 2 {
+		t.Fatalf("portions() = %+v, want 2", pp)
+	}
+	// 100 + 150 capped to 200.
+	if !pp[0].total.Equal(dec("80")) || !pp[1].total.Equal(dec("120")) {
+		t.Errorf("portions() totals = %s, %s, want 80, 120", pp[0].total, pp[1].total)
+	}
+	if !pp[0].date.Before(pp[1].date) {
+		t.Errorf("portions() are not sorted by date")
+	}
+}
+
+func TestGrouping_validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		grouping *Grouping
+		wantErr  bool
+	}{
+		{"nil", nil, false},
+		{"default", &Grouping{}, false},
+		{"epic", &Grouping{By: "Epic", Description: "{epic} {summary} {dates}"}, false},
+		{"unknown strategy", &Grouping{By: "month"}, true},
+		{"unknown placeholder", &Grouping{By: GroupDay, Description: "{date}: {client}"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.grouping.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Grouping.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validLineSort(t *testing.T) {
+	for _, order := range []string{"", SortKey, SortDate, "-" + SortHours, SortAmount} {
+		if err := validLineSort(order); err != nil {
+			t.Errorf("validLineSort(%q) unexpected error = %v", order, err)
+		}
+	}
+	for _, order := range []string{"issue", "--amount"} {
+		if err := validLineSort(order); err == nil {
+			t.Errorf("validLineSort(%q) expected error", order)
+		}
+	}
+}
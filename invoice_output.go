@@ -3,9 +3,8 @@ package sheet2inv
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
-	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/rusq/sheet2inv/forms"
@@ -14,31 +13,177 @@ import (
 
 // ToPDF generates a pdf file from the invoice.
 func (i *Invoice) ToPDF(filename string) error {
-	f := forms.NewInvoice(i.InvoiceID, forms.PgLetter, nil, nil)
-	// sorting entries
-	order := make([]string, 0, len(i.Entries))
-	for k := range i.Entries {
-		order = append(order, k)
+	return writeFile(filename, i.WritePDF)
+}
+
+// WritePDF generates the pdf from the invoice and writes it to w.
+func (i *Invoice) WritePDF(w io.Writer) error {
+	_, err := i.writePDF(w, i.theme())
+	return err
+}
+
+// theme returns the copy of the configured theme, with the default page
+// size.
+func (i *Invoice) theme() *forms.Theme {
+	theme := forms.Theme{PageSize: forms.PgLetter}
+	if t := i.values.Theme; t != nil {
+		theme = *t
+		if theme.PageSize == "" {
+			theme.PageSize = forms.PgLetter
+		}
+	}
+	return &theme
+}
+
+// writePDF renders the invoice PDF with the theme and writes it to w.  It
+// returns the PDF document information.
+func (i *Invoice) writePDF(w io.Writer, theme *forms.Theme) (*forms.Metadata, error) {
+	f, err := forms.NewRenderer(i.values.Renderer, i.InvoiceID, theme)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := i.fill(f.Contents())
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Write(w, fields); err != nil {
+		return nil, err
+	}
+	return f.Contents().Metadata(fields)
+}
+
+// validRenderer checks that the pdf renderer name is known.
+func validRenderer(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, r := range forms.Renderers() {
+		if strings.EqualFold(r, name) {
+			return nil
+		}
 	}
-	sort.Strings(order)
+	return fmt.Errorf("unknown pdf renderer %q, available: %s", name, strings.Join(forms.Renderers(), ", "))
+}
+
+// ToHTML generates an html file from the invoice.  If no template files are
+// given, the default template is used.
+func (i *Invoice) ToHTML(filename string, templateFiles ...string) error {
+	return writeFile(filename, func(w io.Writer) error {
+		return i.WriteHTML(w, templateFiles...)
+	})
+}
 
-	for _, key := range order {
-		entry := i.Entries[key]
-		summary := fmt.Sprintf("%s: %s", entry.Issue, i.summary(&entry))
-		duration := strconv.FormatFloat(entry.Duration.Hours(), 'f', 2, 64)
-		rate := entry.Rate.StringFixedBank(2)
-		total := entry.Total.StringFixedBank(2)
+// WriteHTML renders the invoice as html and writes it to w.
+func (i *Invoice) WriteHTML(w io.Writer, templateFiles ...string) error {
+	f, err := forms.NewHTML(i.InvoiceID, templateFiles...)
+	if err != nil {
+		return err
+	}
+	fields, err := i.fill(&f.Content)
+	if err != nil {
+		return err
+	}
+	return f.Write(w, fields)
+}
+
+// fill populates the form content with the invoice lines, totals and account
+// details, and returns the form fields.
+func (i *Invoice) fill(f *forms.Content) (*forms.InvoiceFields, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	fields := i.values.InvoiceFields
+	fields.Status = i.Status()
+	loc, err := forms.NewLocale(fields.Language, fields.SecondLanguage)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range i.lines(loc, i.summary) {
+		summary := line.Description
+		if line.Capped {
+			summary += " (" + loc.Label(forms.LabelCapped) + ")"
+		}
+		duration := loc.Number(decimal.NewFromFloat(line.Duration.Hours()))
+		rate := loc.Number(line.Rate)
+		total := loc.Number(line.Total)
 		f.AddEntry(summary, duration, rate, total)
 	}
 
-	f.AddSubTotal("SUBTOTAL", i.Total.StringFixedBank(2)).
-		AddSubTotal(fmt.Sprintf("TAX (%s%%)", i.values.Tax.String()), i.values.Tax.Mul(decimal.New(100, 0)).StringFixedBank(2)).
-		AddSubTotal("SHIPPING", i.values.Shipping.StringFixedBank(2)).
-		SetTotal("BALANCE DUE", "$ "+i.Total.Add(i.Total.Mul(i.values.Tax)).StringFixedBank(2))
+	f.AddSubTotal(loc.Label(forms.LabelSubtotal), loc.Number(i.Total))
+	for _, adj := range i.Adjustments {
+		f.AddSubTotal(adj.name(loc, i.currency()), loc.Number(adj.Amount))
+	}
+	percent := loc.Number(i.values.Tax.Mul(decimal.New(100, 0)))
+	f.AddSubTotal(fmt.Sprintf("%s (%s%%)", loc.Label(forms.LabelTax), percent), loc.Number(i.Net().Mul(i.values.Tax))).
+		AddSubTotal(loc.Label(forms.LabelShipping), loc.Number(i.values.Shipping)).
+		SetTotal(loc.Label(forms.LabelBalanceDue), loc.Amount(i.balanceDue(), i.currency()))
+
+	i.addAppendix(f)
+
+	f.AddAccountDetail(loc.Label(forms.LabelBank), fields.Bank).AddAccountDetail(loc.Label(forms.LabelAccountNo), fields.Account)
+	if fields.IBAN != "" {
+		f.AddAccountDetail(loc.Label(forms.LabelIBAN), fields.IBAN)
+	}
+	if fields.BIC != "" {
+		f.AddAccountDetail(loc.Label(forms.LabelBIC), fields.BIC)
+	}
+	f.SetPayable(i.balanceDue(), i.currency())
+
+	if i.Retainer != nil {
+		fields.Remarks = nvlJoinLines(fields.Remarks, i.Retainer.Remark(loc, i.values.Retainer.Unit, i.currency()))
+	}
+	return &fields, nil
+}
+
+// balanceDue returns the invoice amount payable, including tax and
+// shipping.
+func (i *Invoice) balanceDue() decimal.Decimal {
+	net := i.Net()
+	return net.Add(net.Mul(i.values.Tax)).Add(i.values.Shipping)
+}
+
+// knownSummary returns the summary of the entry, without asking the user.
+func (i *Invoice) knownSummary(entry *InvoiceEntry) string {
+	if s := i.values.IssueSummary[entry.Issue]; s != "" {
+		return s
+	}
+	return entry.Summary
+}
+
+// sortedKeys returns the entry keys in the natural order.
+func (i *Invoice) sortedKeys() []string {
+	keys := make([]string, 0, len(i.Entries))
+	for k := range i.Entries {
+		keys = append(keys, k)
+	}
+	sortNatural(keys)
+	return keys
+}
 
-	f.AddAccountDetail("Bank", i.values.InvoiceFields.Bank).AddAccountDetail("Account No.", i.values.InvoiceFields.Account)
+// writeFile creates the file and calls fn to write to it.  The file is
+// removed if fn returns an error.
+func writeFile(filename string, fn func(w io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
 
-	return f.Generate(filename, &i.values.InvoiceFields)
+// nvlJoinLines joins non-empty lines with a newline.
+func nvlJoinLines(lines ...string) string {
+	var nonEmpty []string
+	for _, l := range lines {
+		if l != "" {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
 }
 
 func (i *Invoice) summary(entry *InvoiceEntry) string {
@@ -0,0 +1,121 @@
+package sheet2inv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+func TestInvoice_fill_shipping(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2020, 3, 2, h, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		currency string
+		iban     string
+		payload  func(p *forms.Payment) (string, error)
+		want     string
+	}{
+		{"epc", "EUR", "DE89 3704 0044 0532 0130 00", (*forms.Payment).EPC, "\nEUR372.50\n"},
+		{"swiss qr", "CHF", "CH9300762011623852957", (*forms.Payment).SwissQR, "\n372.50\nCHF\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := InvoiceValues{
+				Tax:          dec("0.2"),
+				Shipping:     dec("12.5"),
+				Currency:     tt.currency,
+				IssueSummary: map[string]string{"A-1": "Development"},
+				InvoiceFields: forms.InvoiceFields{
+					IBAN:    tt.iban,
+					Address: forms.Address{Name: "Seller", Street: "Bahnhofstrasse 1", Postcode: "8001", Town: "Zürich", CountryCode: "CH"},
+				},
+			}
+			inv := NewInvoice(&values, "42", nil)
+			inv.Add(&TsEntry{Invoice: "42", Start: at(9), End: at(12), Items: []Item{{Issue: "A-1"}}, rate: dec("100"), multiplier: dec("1")})
+			inv.Recalculate()
+
+			f, err := forms.NewHTML(inv.InvoiceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields, err := inv.fill(&f.Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 300 net, 60 tax and 12.50 shipping.
+			amount, currency := f.Payable()
+			if !amount.Equal(dec("372.5")) || currency != tt.currency {
+				t.Errorf("Payable() = %v %s, want 372.5 %s", amount, currency, tt.currency)
+			}
+			p, err := forms.NewPayment(inv.InvoiceID, fields, amount, currency)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.payload(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("payload = %q, missing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvoice_WriteHTML_subTotals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "subtotals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := filepath.Join(dir, "totals.html")
+	if err := ioutil.WriteFile(tmpl, []byte(`{{range .SubTotals}}{{.Key}}={{.Value}};{{end}}{{.Total.Value}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(h int) time.Time {
+		return time.Date(2020, 3, 2, h, 0, 0, 0, time.UTC)
+	}
+	values := InvoiceValues{
+		Tax:          dec("0.2"),
+		Shipping:     dec("12.5"),
+		IssueSummary: map[string]string{"A-1": "Development", "B-2": "Support"},
+	}
+	inv := NewInvoice(&values, "42", nil)
+	inv.Add(&TsEntry{Invoice: "42", Start: at(9), End: at(12), Items: []Item{{Issue: "A-1"}}, rate: dec("100"), multiplier: dec("1")})
+	inv.Add(&TsEntry{Invoice: "42", Start: at(13), End: at(14), Items: []Item{{Issue: "B-2"}}, rate: dec("125.5"), multiplier: dec("1")})
+	inv.Recalculate()
+
+	var buf bytes.Buffer
+	if err := inv.WriteHTML(&buf, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	number := func(s string) decimal.Decimal {
+		return dec(strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+			return -1
+		}, s))
+	}
+	rows := strings.Split(buf.String(), ";")
+	if got, want := rows[1], "TAX (20.00%)=85.10"; got != want {
+		t.Errorf("tax subtotal = %q, want %q", got, want)
+	}
+	var sum decimal.Decimal
+	for _, row := range rows[:len(rows)-1] {
+		sum = sum.Add(number(row[strings.Index(row, "=")+1:]))
+	}
+	if total := number(rows[len(rows)-1]); !sum.Equal(total) || !total.Equal(inv.balanceDue()) {
+		t.Errorf("subtotals add up to %v, balance due is %v, want %v", sum, total, inv.balanceDue())
+	}
+}
@@ -0,0 +1,237 @@
+package sheet2inv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// LineRow is the invoice line of the spreadsheet export.  Adjustments, i.e.
+// the retainer credit, are exported as lines without hours, so that the
+// lines add up to the invoice balance.
+type LineRow struct {
+	Invoice string
+	Client  string
+	Issue   string // issue IDs of the line, comma separated
+	Summary string
+	Hours   decimal.Decimal
+	Rate    decimal.Decimal
+	Tax     decimal.Decimal // tax amount of the line, line taxes add up to the invoice tax
+	Total   decimal.Decimal // line total, excluding tax
+	Period  string
+}
+
+// lineColumns are the spreadsheet export columns.
+var lineColumns = []string{"Invoice", "Client", "Issue", "Summary", "Hours", "Rate", "Tax", "Total", "Period"}
+
+// LineRows returns the spreadsheet rows of the invoice lines.
+func (i *Invoice) LineRows() ([]LineRow, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	fields := &i.values.InvoiceFields
+	loc, err := forms.NewLocale(fields.Language, fields.SecondLanguage)
+	if err != nil {
+		return nil, err
+	}
+	row := LineRow{
+		Invoice: i.InvoiceID,
+		Client:  party(fields.BillTo).Name,
+		Period:  i.periodRange(),
+	}
+	var rows []LineRow
+	for _, line := range i.lines(loc, i.knownSummary) {
+		r := row
+		r.Issue = strings.Join(line.issues, ", ")
+		r.Summary = line.Description
+		if len(line.issues) == 1 {
+			r.Summary = i.knownSummary(&line.InvoiceEntry)
+		}
+		r.Hours, r.Rate, r.Total = line.hours(), line.Rate, line.Total
+		rows = append(rows, r)
+	}
+	for _, adj := range i.Adjustments {
+		r := row
+		r.Summary, r.Total = adj.name(loc, i.currency()), adj.Amount
+		rows = append(rows, r)
+	}
+	i.allocateTax(rows)
+	return rows, nil
+}
+
+// allocateTax sets the tax of the rows, rounded to cents.  The rounding
+// difference goes to the row with the largest total, so that the row taxes
+// add up to the invoice tax.
+func (i *Invoice) allocateTax(rows []LineRow) {
+	if len(rows) == 0 {
+		return
+	}
+	var (
+		sum     decimal.Decimal
+		largest int
+	)
+	for n := range rows {
+		rows[n].Tax = rows[n].Total.Mul(i.values.Tax).Round(2)
+		sum = sum.Add(rows[n].Tax)
+		if rows[n].Total.Abs().GreaterThan(rows[largest].Total.Abs()) {
+			largest = n
+		}
+	}
+	tax := i.Net().Mul(i.values.Tax).Round(2)
+	rows[largest].Tax = rows[largest].Tax.Add(tax.Sub(sum))
+}
+
+// periodRange returns the invoice time period, i.e. "2020-03-01 - 2020-03-31",
+// or the invoice month, if the period is not set.
+func (i *Invoice) periodRange() string {
+	fields := &i.values.InvoiceFields
+	if fields.PeriodStart.IsZero() || fields.PeriodEnd.IsZero() {
+		return i.period()
+	}
+	return fields.PeriodStart.Format("2006-01-02") + " - " + fields.PeriodEnd.Format("2006-01-02")
+}
+
+// LineRows returns the spreadsheet rows of all invoices in the invoice number
+// order.
+func (invs *Invoices) LineRows() ([]LineRow, error) {
+	var rows []LineRow
+	for _, id := range invs.IDs() {
+		rr, err := invs.Get(id).LineRows()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rr...)
+	}
+	return rows, nil
+}
+
+// WriteCSV writes the invoice lines to w as csv.
+func (i *Invoice) WriteCSV(w io.Writer) error {
+	rows, err := i.LineRows()
+	if err != nil {
+		return err
+	}
+	return WriteLinesCSV(w, rows)
+}
+
+// WriteXLSX writes the invoice lines to w as the Excel workbook.
+func (i *Invoice) WriteXLSX(w io.Writer) error {
+	rows, err := i.LineRows()
+	if err != nil {
+		return err
+	}
+	return WriteLinesXLSX(w, rows)
+}
+
+// WriteCSV writes the lines of all invoices to w as csv.
+func (invs *Invoices) WriteCSV(w io.Writer) error {
+	rows, err := invs.LineRows()
+	if err != nil {
+		return err
+	}
+	return WriteLinesCSV(w, rows)
+}
+
+// WriteXLSX writes the lines of all invoices to w as the Excel workbook.
+func (invs *Invoices) WriteXLSX(w io.Writer) error {
+	rows, err := invs.LineRows()
+	if err != nil {
+		return err
+	}
+	return WriteLinesXLSX(w, rows)
+}
+
+// WriteLinesCSV writes the rows to w as csv with the header.
+func WriteLinesCSV(w io.Writer, rows []LineRow) error {
+	buf := bufio.NewWriter(w)
+	cw := csv.NewWriter(buf)
+	if err := cw.Write(lineColumns); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		rec := []string{
+			r.Invoice, r.Client, r.Issue, r.Summary,
+			r.Hours.StringFixed(2), r.Rate.StringFixed(2), r.Tax.StringFixed(2), r.Total.StringFixed(2),
+			r.Period,
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// WriteLinesXLSX writes the rows to w as the Excel workbook.  The first sheet
+// has all lines, and there's the summary sheet for each client, with the
+// totals of the client invoices.
+func WriteLinesXLSX(w io.Writer, rows []LineRow) error {
+	lines := xlsxSheet{Name: "Lines", Rows: [][]xlsxCell{xlsxHeader(lineColumns...)}}
+	for _, r := range rows {
+		lines.Rows = append(lines.Rows, []xlsxCell{
+			xlsxText(r.Invoice), xlsxText(r.Client), xlsxText(r.Issue), xlsxText(r.Summary),
+			xlsxNumber(r.Hours), xlsxAmount(r.Rate), xlsxAmount(r.Tax), xlsxAmount(r.Total),
+			xlsxText(r.Period),
+		})
+	}
+	return writeXLSX(w, append([]xlsxSheet{lines}, clientSummaries(rows)...))
+}
+
+// clientSummaries returns the summary sheet of each client, in the order of
+// the first client appearance.
+func clientSummaries(rows []LineRow) []xlsxSheet {
+	type invoiceSum struct {
+		id, period      string
+		hours, net, tax decimal.Decimal
+	}
+	var (
+		clients  []string
+		byClient = make(map[string][]*invoiceSum)
+	)
+	for _, r := range rows {
+		sums, ok := byClient[r.Client]
+		if !ok {
+			clients = append(clients, r.Client)
+		}
+		if len(sums) == 0 || sums[len(sums)-1].id != r.Invoice {
+			sums = append(sums, &invoiceSum{id: r.Invoice, period: r.Period})
+			byClient[r.Client] = sums
+		}
+		s := sums[len(sums)-1]
+		s.hours = s.hours.Add(r.Hours)
+		s.net = s.net.Add(r.Total)
+		s.tax = s.tax.Add(r.Tax)
+	}
+
+	var (
+		sheets []xlsxSheet
+		names  = map[string]bool{"lines": true} // the lines sheet
+	)
+	for _, client := range clients {
+		sheet := xlsxSheet{
+			Name: sheetName(client, names),
+			Rows: [][]xlsxCell{xlsxHeader("Invoice", "Period", "Hours", "Net", "Tax", "Total")},
+		}
+		var total invoiceSum
+		for _, s := range byClient[client] {
+			sheet.Rows = append(sheet.Rows, []xlsxCell{
+				xlsxText(s.id), xlsxText(s.period), xlsxNumber(s.hours), xlsxAmount(s.net), xlsxAmount(s.tax), xlsxAmount(s.net.Add(s.tax)),
+			})
+			total.hours = total.hours.Add(s.hours)
+			total.net = total.net.Add(s.net)
+			total.tax = total.tax.Add(s.tax)
+		}
+		sheet.Rows = append(sheet.Rows, []xlsxCell{
+			{Value: "Total", Bold: true}, {}, xlsxBold(xlsxNumber(total.hours)), xlsxBold(xlsxAmount(total.net)), xlsxBold(xlsxAmount(total.tax)), xlsxBold(xlsxAmount(total.net.Add(total.tax))),
+		})
+		sheets = append(sheets, sheet)
+	}
+	return sheets
+}
@@ -0,0 +1,166 @@
+package sheet2inv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+func testSheetInvoice() *Invoice {
+	inv := testGroupingInvoice(nil)
+	inv.values.Tax = dec("0.1")
+	inv.values.InvoiceFields.BillTo = forms.Address{Name: "John", Organisation: "ACME, Inc."}
+	inv.values.InvoiceFields.PeriodStart = time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	inv.values.InvoiceFields.PeriodEnd = time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	inv.Adjustments = []Adjustment{{Kind: AdjustRetainer, Quantity: dec("1"), Unit: RetainerHours, Amount: dec("-100")}}
+	return inv
+}
+
+func TestInvoice_WriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testSheetInvoice().WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `Invoice,Client,Issue,Summary,Hours,Rate,Tax,Total,Period
+1,"ACME, Inc.",,Setup,1.00,50.00,5.00,50.00,2020-03-01 - 2020-03-31
+1,"ACME, Inc.",A-1,Design,2.50,100.00,25.00,250.00,2020-03-01 - 2020-03-31
+1,"ACME, Inc.",B-2,Support,1.00,100.00,10.00,100.00,2020-03-01 - 2020-03-31
+1,"ACME, Inc.",B-2,Support,1.00,150.00,15.00,150.00,2020-03-01 - 2020-03-31
+1,"ACME, Inc.",,RETAINER (1.00 hrs),0.00,0.00,-10.00,-100.00,2020-03-01 - 2020-03-31
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInvoice_LineRows_tax(t *testing.T) {
+	inv := NewInvoice(&InvoiceValues{Tax: dec("0.1")}, "1", nil)
+	start := time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+	for n, issue := range []string{"A-1", "B-2", "C-3"} {
+		// 0.15 each: 1 hour at 0.15, or 30 minutes at 0.30
+		end, rate := start.Add(time.Hour), "0.15"
+		if n == 2 {
+			end, rate = start.Add(30*time.Minute), "0.30"
+		}
+		inv.Add(&TsEntry{Invoice: "1", Start: start, End: end, Items: []Item{{Issue: issue}}, rate: dec(rate), multiplier: dec("1")})
+	}
+	inv.Recalculate()
+	rows, err := inv.LineRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each line tax rounds up to 0.02, the invoice tax is 0.05.
+	var tax decimal.Decimal
+	for _, r := range rows {
+		tax = tax.Add(r.Tax)
+	}
+	if !tax.Equal(dec("0.05")) {
+		t.Errorf("line taxes add up to %s, want the invoice tax 0.05", tax)
+	}
+
+	// hours are rounded to 2 decimal places.
+	var buf bytes.Buffer
+	if err := WriteLinesCSV(&buf, []LineRow{{Invoice: "1", Hours: dec("1").Div(dec("3"))}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\n1,,,,0.33,0.00,0.00,0.00,\n") {
+		t.Errorf("WriteLinesCSV() =\n%s\nwant hours 0.33", buf.String())
+	}
+}
+
+func TestInvoices_WriteXLSX(t *testing.T) {
+	invs := NewInvoices(&InvoiceValues{}, nil)
+	invs.Invoices["1"] = testSheetInvoice()
+	second := testSheetInvoice()
+	second.InvoiceID = "2"
+	second.values = &InvoiceValues{InvoiceFields: forms.InvoiceFields{BillTo: forms.Address{Organisation: "Lines"}}}
+	invs.Invoices["2"] = second
+
+	var buf bytes.Buffer
+	if err := invs.WriteXLSX(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every part must be well-formed xml.
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := d.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: %s", f.Name, err)
+				}
+				break
+			}
+		}
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet3.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("WriteXLSX() has no %s", name)
+		}
+	}
+	for _, want := range []string{`name="Lines"`, `name="ACME, Inc."`, `name="Lines (2)"`} {
+		if !strings.Contains(parts["xl/workbook.xml"], want) {
+			t.Errorf("workbook has no sheet %s", want)
+		}
+	}
+	// the summary row: 5.5 hours, 450 net, 45 tax, 495 total.
+	for _, want := range []string{`<v>5.5</v>`, `<v>450.00</v>`, `<v>45.00</v>`, `<v>495.00</v>`} {
+		if !strings.Contains(parts["xl/worksheets/sheet2.xml"], want) {
+			t.Errorf("summary sheet has no %s", want)
+		}
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], `<t xml:space="preserve">ACME, Inc.</t>`) {
+		t.Error("lines sheet has no client")
+	}
+}
+
+func Test_sheetName(t *testing.T) {
+	used := map[string]bool{"lines": true}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ACME", "ACME"},
+		{"acme", "acme (2)"},
+		{"A/B [test]", "A_B _test_"},
+		{"", "Client"},
+		{"Lines", "Lines (2)"},
+		{strings.Repeat("x", 40), strings.Repeat("x", 31)},
+		{strings.Repeat("x", 40), strings.Repeat("x", 27) + " (2)"},
+	}
+	for _, tt := range tests {
+		if got := sheetName(tt.name, used); got != tt.want {
+			t.Errorf("sheetName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func Test_colName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 8: "I", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := colName(i); got != want {
+			t.Errorf("colName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
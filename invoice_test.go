@@ -0,0 +1,114 @@
+package sheet2inv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+)
+
+func TestInvoice_Status(t *testing.T) {
+	covered := &RetainerUsage{Credit: dec("1000")}
+	tests := []struct {
+		name       string
+		configured string
+		override   string
+		retainer   *RetainerUsage
+		adjustment string
+		paid       string
+		want       string
+	}{
+		{"issued", "", "", nil, "0", "", forms.StatusIssued},
+		{"configured", "Draft", "", nil, "0", "", forms.StatusDraft},
+		{"override", forms.StatusDraft, forms.StatusVoid, nil, "0", "", forms.StatusVoid},
+		{"covered by retainer", "", "", covered, "-1000", "", forms.StatusPaid},
+		{"retainer overage", "", "", covered, "-800", "", forms.StatusIssued},
+		{"covered draft", forms.StatusDraft, "", covered, "-1000", "", forms.StatusDraft},
+		{"paid", "", "", nil, "0", "1000", forms.StatusPaid},
+		{"partly paid", "", "", nil, "0", "999.99", forms.StatusIssued},
+		{"overage paid", "", "", covered, "-800", "200", forms.StatusPaid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := &InvoiceValues{InvoiceFields: forms.InvoiceFields{Status: tt.configured}}
+			if tt.paid != "" {
+				values.Payments = []Payment{{Invoice: "1", Amount: dec(tt.paid)}}
+			}
+			i := &Invoice{
+				InvoiceID:   "1",
+				values:      values,
+				Total:       dec("1000"),
+				Retainer:    tt.retainer,
+				Adjustments: []Adjustment{{Kind: AdjustRetainer, Amount: dec(tt.adjustment)}},
+			}
+			if tt.override != "" {
+				if err := i.SetStatus(tt.override); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := i.Status(); got != tt.want {
+				t.Errorf("Invoice.Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if err := (&Invoice{}).SetStatus("sent"); err == nil {
+		t.Errorf("Invoice.SetStatus() expected error")
+	}
+}
+
+func TestInvoice_Commit_draft(t *testing.T) {
+	r := &RecurringInvoice{
+		Name:     "ACME",
+		Items:    []RecurringItem{{Description: "Hosting", Qty: dec("1"), Price: dec("500")}},
+		Schedule: Schedule{Every: Monthly, Day: 1, DueDay: 15, Start: date(2020, 1, 1)},
+	}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	values := &InvoiceValues{InvoiceFields: forms.InvoiceFields{Status: forms.StatusDraft}}
+	invs := r.Due(values, date(2020, 1, 10))
+	if len(invs) != 1 {
+		t.Fatalf("Due() returned %d invoices, want 1", len(invs))
+	}
+	invs[0].Commit()
+	// the draft is not issued, so it is still due.
+	if invs := r.Due(values, date(2020, 1, 10)); len(invs) != 1 {
+		t.Errorf("Due() after draft commit returned %d invoices, want 1", len(invs))
+	}
+}
+
+func TestInvoices_IDs(t *testing.T) {
+	invs := NewInvoices(&InvoiceValues{}, nil)
+	for _, id := range []string{"INV-10", "INV-9", "INV-100", "INV-1"} {
+		invs.Append(&TsEntry{Invoice: id, Items: []Item{{Issue: "A-1"}}})
+	}
+	want := []string{"INV-1", "INV-9", "INV-10", "INV-100"}
+	for n := 0; n < 5; n++ {
+		if got := invs.IDs(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Invoices.IDs() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestInvoice_Recalculate_multiItem(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2020, 3, 2, h, 0, 0, 0, time.UTC)
+	}
+	inv := NewInvoice(&InvoiceValues{}, "1", nil)
+	// 3 hours split between two issues, and an hour on one of them.
+	inv.Add(&TsEntry{Invoice: "1", Start: at(9), End: at(12), Items: []Item{{Issue: "A-1"}, {Issue: "B-2"}}, rate: dec("100"), multiplier: dec("1")})
+	inv.Add(&TsEntry{Invoice: "1", Start: at(13), End: at(14), Items: []Item{{Issue: "A-1"}}, rate: dec("100"), multiplier: dec("1")})
+	inv.Recalculate()
+
+	want := map[string]string{"A-1": "250", "B-2": "150"}
+	for issue, total := range want {
+		if got := inv.Entries[issue].Total; !got.Equal(dec(total)) {
+			t.Errorf("%s total = %v, want %v", issue, got, total)
+		}
+	}
+	// the entry duration is billed once, and not for each of its issues.
+	if !inv.Total.Equal(dec("400")) {
+		t.Errorf("Total = %v, want 400", inv.Total)
+	}
+}
@@ -0,0 +1,369 @@
+package sheet2inv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// Plain text accounting journal formats.
+const (
+	JournalLedger    = "ledger"    // ledger-cli
+	JournalHledger   = "hledger"   // hledger
+	JournalBeancount = "beancount" // beancount
+)
+
+// Default journal accounts, "{client}" is replaced with the client name.
+const (
+	defReceivable = "Assets:Receivable:{client}"
+	defRevenue    = "Income:Consulting"
+	defTaxPayable = "Liabilities:Tax"
+	defBank       = "Assets:Bank"
+)
+
+// journalAmountCol is the column, the posting amounts are aligned to.
+const journalAmountCol = 48
+
+// Journal is the accounting journal export configuration of the client.
+// Account names may contain "{client}", that is replaced with the client
+// name, i.e. "Assets:Receivable:{client}".
+type Journal struct {
+	Format      string `yaml:"format,omitempty"`      // "ledger" (default), "hledger" or "beancount"
+	Receivable  string `yaml:"receivable,omitempty"`  // accounts receivable, debited with the balance due
+	Revenue     string `yaml:"revenue,omitempty"`     // revenue, credited with the invoice total and shipping
+	Adjustments string `yaml:"adjustments,omitempty"` // retainer credit and other adjustments, default is the revenue account
+	Tax         string `yaml:"tax,omitempty"`         // tax payable, credited with the tax
+	Bank        string `yaml:"bank,omitempty"`        // bank account, debited with the payments
+}
+
+// Payment is the payment received for the invoice.
+type Payment struct {
+	Invoice   string
+	Date      time.Time
+	Amount    decimal.Decimal
+	Account   string `yaml:",omitempty"` // account the payment was received to, default is the journal bank account
+	Reference string `yaml:",omitempty"`
+}
+
+// Transaction is the journal transaction.
+type Transaction struct {
+	Date      time.Time
+	Code      string // invoice number
+	Payee     string
+	Narration string
+	Postings  []Posting
+}
+
+// Posting is the transaction posting, the positive amount is debit, and
+// negative is credit.
+type Posting struct {
+	Account  string
+	Amount   decimal.Decimal
+	Currency string
+}
+
+// ValidJournalFormat checks that the journal format is known, empty format
+// is the default.
+func ValidJournalFormat(format string) error {
+	switch format {
+	case "", JournalLedger, JournalHledger, JournalBeancount:
+		return nil
+	}
+	return fmt.Errorf("invalid journal format %q, must be one of: %s, %s, %s", format, JournalLedger, JournalHledger, JournalBeancount)
+}
+
+func (j *Journal) validate() error {
+	if j == nil {
+		return nil
+	}
+	return ValidJournalFormat(j.Format)
+}
+
+func (p *Payment) validate() error {
+	switch {
+	case p.Invoice == "":
+		return errors.New("payment: invoice number is not set")
+	case p.Date.IsZero():
+		return fmt.Errorf("payment for invoice %s: date is not set", p.Invoice)
+	case !p.Amount.IsPositive():
+		return fmt.Errorf("payment for invoice %s: amount must be positive", p.Invoice)
+	}
+	return nil
+}
+
+// accountName returns the account name with the client placeholder
+// replaced, or the default account, if the name is empty.
+func accountName(name, def, client string) string {
+	if name == "" {
+		name = def
+	}
+	return strings.Replace(name, "{client}", accountComponent(client), -1)
+}
+
+// accountComponent returns the client name usable as the account name
+// component: letters and digits, other characters are replaced with dashes.
+func accountComponent(s string) string {
+	var (
+		b    strings.Builder
+		dash bool
+	)
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "Unknown"
+	}
+	return b.String()
+}
+
+// accounts returns the journal accounts of the invoice.
+func (i *Invoice) accounts() Journal {
+	var j Journal
+	if i.values.Journal != nil {
+		j = *i.values.Journal
+	}
+	client := party(i.values.InvoiceFields.BillTo).Name
+	j.Receivable = accountName(j.Receivable, defReceivable, client)
+	j.Revenue = accountName(j.Revenue, defRevenue, client)
+	j.Adjustments = accountName(j.Adjustments, j.Revenue, client)
+	j.Tax = accountName(j.Tax, defTaxPayable, client)
+	j.Bank = accountName(j.Bank, defBank, client)
+	return j
+}
+
+// Transactions returns the journal transactions of the invoice: the invoice
+// itself, debiting accounts receivable and crediting revenue and tax payable,
+// and the recorded payments of the invoice.  Draft and void invoices have no
+// transactions.
+func (i *Invoice) Transactions() ([]Transaction, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	switch i.Status() {
+	case forms.StatusDraft, forms.StatusVoid:
+		return nil, nil
+	}
+	var (
+		acc   = i.accounts()
+		cur   = i.currency()
+		payee = party(i.values.InvoiceFields.BillTo).Name
+	)
+	posting := func(account string, amount decimal.Decimal) Posting {
+		return Posting{Account: account, Amount: amount, Currency: cur}
+	}
+
+	// amounts are rounded separately, the receivable balances the rest, so
+	// that the transaction balances exactly.
+	revenue := i.Total.Round(2)
+	postings := []Posting{posting(acc.Revenue, revenue.Neg())}
+	receivable := revenue
+	for _, adj := range i.Adjustments {
+		amount := adj.Amount.Round(2)
+		postings = append(postings, posting(acc.Adjustments, amount.Neg()))
+		receivable = receivable.Add(amount)
+	}
+	tax := receivable.Mul(i.values.Tax).Round(2)
+	if !tax.IsZero() {
+		postings = append(postings, posting(acc.Tax, tax.Neg()))
+		receivable = receivable.Add(tax)
+	}
+	if shipping := i.values.Shipping.Round(2); !shipping.IsZero() {
+		postings = append(postings, posting(acc.Revenue, shipping.Neg()))
+		receivable = receivable.Add(shipping)
+	}
+	postings = append([]Posting{posting(acc.Receivable, receivable)}, postings...)
+
+	var txns []Transaction
+	if postings = nonZero(postings); len(postings) > 0 {
+		txns = append(txns, Transaction{
+			Date:      i.values.InvoiceFields.Date,
+			Code:      i.InvoiceID,
+			Payee:     payee,
+			Narration: "Invoice " + i.InvoiceID,
+			Postings:  postings,
+		})
+	}
+	for _, p := range i.values.Payments {
+		if p.Invoice != i.InvoiceID {
+			continue
+		}
+		bank := acc.Bank
+		if p.Account != "" {
+			bank = accountName(p.Account, acc.Bank, payee)
+		}
+		narration := "Payment for invoice " + i.InvoiceID
+		if p.Reference != "" {
+			narration += ", " + p.Reference
+		}
+		txns = append(txns, Transaction{
+			Date:      p.Date,
+			Code:      i.InvoiceID,
+			Payee:     payee,
+			Narration: narration,
+			Postings: []Posting{
+				posting(bank, p.Amount.Round(2)),
+				posting(acc.Receivable, p.Amount.Round(2).Neg()),
+			},
+		})
+	}
+	return txns, nil
+}
+
+// paid returns the total of the recorded payments of the invoice.
+func (i *Invoice) paid() decimal.Decimal {
+	var total decimal.Decimal
+	for _, p := range i.values.Payments {
+		if p.Invoice == i.InvoiceID {
+			total = total.Add(p.Amount)
+		}
+	}
+	return total
+}
+
+// nonZero returns the postings with non-zero amounts.
+func nonZero(pp []Posting) []Posting {
+	var ret []Posting
+	for _, p := range pp {
+		if !p.Amount.IsZero() {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// Transactions returns the journal transactions of all invoices in the
+// invoice number order.
+func (invs *Invoices) Transactions() ([]Transaction, error) {
+	var txns []Transaction
+	for _, id := range invs.IDs() {
+		tt, err := invs.Get(id).Transactions()
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, tt...)
+	}
+	return txns, nil
+}
+
+// JournalFormat returns the journal format of the invoice config, or
+// ledger, if it's not set.
+func (v *InvoiceValues) JournalFormat() string {
+	if v.Journal == nil || v.Journal.Format == "" {
+		return JournalLedger
+	}
+	return v.Journal.Format
+}
+
+// WriteJournal writes the transactions to w in the journal format, sorted by
+// date.  If open is true, beancount journal starts with the open directives of
+// all accounts, they should be omitted, if the output is appended to the
+// journal, that already has the accounts open.
+func WriteJournal(w io.Writer, format string, txns []Transaction, open bool) error {
+	if err := ValidJournalFormat(format); err != nil {
+		return err
+	}
+	if format == "" {
+		format = JournalLedger
+	}
+	sorted := make([]Transaction, len(txns))
+	copy(sorted, txns)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	buf := bufio.NewWriter(w)
+	opened := open && format == JournalBeancount && len(sorted) > 0
+	if opened {
+		writeOpen(buf, sorted)
+	}
+	for n, t := range sorted {
+		if n > 0 || opened {
+			buf.WriteString("\n")
+		}
+		switch format {
+		case JournalBeancount:
+			fmt.Fprintf(buf, "%s * %s %s\n  invoice: %s\n", t.Date.Format("2006-01-02"), quote(t.Payee), quote(t.Narration), quote(t.Code))
+		case JournalHledger:
+			fmt.Fprintf(buf, "%s * (%s) %s | %s\n", t.Date.Format("2006-01-02"), t.Code, t.Payee, t.Narration)
+		default:
+			fmt.Fprintf(buf, "%s * (%s) %s\n    ; %s\n", t.Date.Format("2006/01/02"), t.Code, t.Payee, t.Narration)
+		}
+		for _, p := range t.Postings {
+			acc := p.Account
+			if format == JournalBeancount {
+				acc = beancountAccount(acc)
+			}
+			amount := p.Amount.StringFixed(2) + " " + p.Currency
+			pad := journalAmountCol - utf8.RuneCountInString(acc) - len(amount)
+			if pad < 2 {
+				pad = 2 // at least two spaces separate the account and the amount
+			}
+			fmt.Fprintf(buf, "    %s%s%s\n", acc, strings.Repeat(" ", pad), amount)
+		}
+	}
+	return buf.Flush()
+}
+
+// writeOpen writes the beancount open directives of all accounts and
+// currencies of the transactions, dated with the first transaction date.
+func writeOpen(w io.Writer, txns []Transaction) {
+	currencies := make(map[string]map[string]bool)
+	for _, t := range txns {
+		for _, p := range t.Postings {
+			acc := beancountAccount(p.Account)
+			if currencies[acc] == nil {
+				currencies[acc] = make(map[string]bool)
+			}
+			currencies[acc][p.Currency] = true
+		}
+	}
+	accounts := make([]string, 0, len(currencies))
+	for acc := range currencies {
+		accounts = append(accounts, acc)
+	}
+	sortNatural(accounts)
+	date := txns[0].Date.Format("2006-01-02")
+	for _, acc := range accounts {
+		var cc []string
+		for c := range currencies[acc] {
+			cc = append(cc, c)
+		}
+		sort.Strings(cc)
+		fmt.Fprintf(w, "%s open %s %s\n", date, acc, strings.Join(cc, ","))
+	}
+}
+
+// beancountAccount returns the valid beancount account name: each component
+// starts with a capital letter or digit, and has letters, digits and dashes.
+func beancountAccount(name string) string {
+	parts := strings.Split(name, ":")
+	for n, part := range parts {
+		part = accountComponent(part)
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		parts[n] = string(r)
+	}
+	return strings.Join(parts, ":")
+}
+
+// quote returns the beancount string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
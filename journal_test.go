@@ -0,0 +1,208 @@
+package sheet2inv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+func testJournalInvoice() *Invoice {
+	inv := testSheetInvoice()
+	inv.values.InvoiceFields.Date = time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	inv.values.Payments = []Payment{
+		{Invoice: "1", Date: time.Date(2020, 4, 10, 0, 0, 0, 0, time.UTC), Amount: dec("200"), Reference: "wire 42"},
+		{Invoice: "2", Date: time.Date(2020, 4, 11, 0, 0, 0, 0, time.UTC), Amount: dec("1")},
+	}
+	return inv
+}
+
+func TestInvoice_Transactions(t *testing.T) {
+	tests := []struct {
+		name     string
+		journal  *Journal
+		status   string
+		shipping decimal.Decimal
+		wantTxns int
+		want     map[string]string // account balances
+	}{
+		{"default accounts", nil, "", decimal.Zero, 2, map[string]string{
+			"Assets:Receivable:ACME-Inc": "295",
+			"Income:Consulting":          "-450",
+			"Liabilities:Tax":            "-45",
+			"Assets:Bank":                "200",
+		}},
+		{"client accounts", &Journal{Receivable: "Assets:AR:{client}", Revenue: "Income:{client}", Adjustments: "Liabilities:Unearned:{client}", Tax: "Liabilities:VAT", Bank: "Assets:Checking"}, "", decimal.Zero, 2, map[string]string{
+			"Assets:AR:ACME-Inc":            "295",
+			"Income:ACME-Inc":               "-550",
+			"Liabilities:Unearned:ACME-Inc": "100",
+			"Liabilities:VAT":               "-45",
+			"Assets:Checking":               "200",
+		}},
+		{"shipping", nil, "", dec("10"), 2, map[string]string{
+			"Assets:Receivable:ACME-Inc": "305",
+			"Income:Consulting":          "-460",
+			"Liabilities:Tax":            "-45",
+			"Assets:Bank":                "200",
+		}},
+		{"draft", nil, forms.StatusDraft, decimal.Zero, 0, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := testJournalInvoice()
+			inv.values.Journal = tt.journal
+			inv.values.InvoiceFields.Status = tt.status
+			inv.values.Shipping = tt.shipping
+			txns, err := inv.Transactions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(txns) != tt.wantTxns {
+				t.Fatalf("Transactions() = %d transactions, want %d", len(txns), tt.wantTxns)
+			}
+			balances := make(map[string]string)
+			for _, txn := range txns {
+				sum := dec("0")
+				for _, p := range txn.Postings {
+					if p.Currency != defCurrency {
+						t.Errorf("%s: currency = %s, want %s", p.Account, p.Currency, defCurrency)
+					}
+					sum = sum.Add(p.Amount)
+					if b, ok := balances[p.Account]; ok {
+						balances[p.Account] = dec(b).Add(p.Amount).String()
+					} else {
+						balances[p.Account] = p.Amount.String()
+					}
+				}
+				if !sum.IsZero() {
+					t.Errorf("transaction %q does not balance: %s", txn.Narration, sum)
+				}
+			}
+			if len(balances) != len(tt.want) {
+				t.Errorf("balances = %v, want %v", balances, tt.want)
+			}
+			for acc, want := range tt.want {
+				if got, ok := balances[acc]; !ok || !dec(got).Equal(dec(want)) {
+					t.Errorf("balance %s = %s, want %s", acc, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJournal(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{JournalLedger, `2020/03/31 * (1) ACME, Inc.
+    ; Invoice 1
+    Assets:Receivable:ACME-Inc            495.00 USD
+    Income:Consulting                    -550.00 USD
+    Income:Consulting                     100.00 USD
+    Liabilities:Tax                       -45.00 USD
+
+2020/04/10 * (1) ACME, Inc.
+    ; Payment for invoice 1, wire 42
+    Assets:Bank                           200.00 USD
+    Assets:Receivable:ACME-Inc           -200.00 USD
+`},
+		{JournalHledger, `2020-03-31 * (1) ACME, Inc. | Invoice 1
+    Assets:Receivable:ACME-Inc            495.00 USD
+    Income:Consulting                    -550.00 USD
+    Income:Consulting                     100.00 USD
+    Liabilities:Tax                       -45.00 USD
+
+2020-04-10 * (1) ACME, Inc. | Payment for invoice 1, wire 42
+    Assets:Bank                           200.00 USD
+    Assets:Receivable:ACME-Inc           -200.00 USD
+`},
+		{JournalBeancount, `2020-03-31 open Assets:Bank USD
+2020-03-31 open Assets:Receivable:ACME-Inc USD
+2020-03-31 open Income:Consulting USD
+2020-03-31 open Liabilities:Tax USD
+
+2020-03-31 * "ACME, Inc." "Invoice 1"
+  invoice: "1"
+    Assets:Receivable:ACME-Inc            495.00 USD
+    Income:Consulting                    -550.00 USD
+    Income:Consulting                     100.00 USD
+    Liabilities:Tax                       -45.00 USD
+
+2020-04-10 * "ACME, Inc." "Payment for invoice 1, wire 42"
+  invoice: "1"
+    Assets:Bank                           200.00 USD
+    Assets:Receivable:ACME-Inc           -200.00 USD
+`},
+	}
+	txns, err := testJournalInvoice().Transactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// transactions are sorted by date.
+	txns[0], txns[1] = txns[1], txns[0]
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteJournal(&buf, tt.format, txns, true); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteJournal() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+	if err := WriteJournal(&bytes.Buffer{}, "gnucash", txns, true); err == nil {
+		t.Error("WriteJournal() expected format error")
+	}
+
+	// appending to the journal with the accounts already open.
+	var buf bytes.Buffer
+	if err := WriteJournal(&buf, JournalBeancount, txns, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, " open ") || !strings.HasPrefix(got, `2020-03-31 * "ACME, Inc." "Invoice 1"`) {
+		t.Errorf("WriteJournal() without open =\n%s\nwant the transactions only", got)
+	}
+}
+
+func Test_beancountAccount(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Assets:Receivable:ACME-Inc", "Assets:Receivable:ACME-Inc"},
+		{"assets:bank:main account", "Assets:Bank:Main-account"},
+		{"Income:müller & co", "Income:Müller-co"},
+		{"Expenses::x", "Expenses:Unknown:X"},
+	}
+	for _, tt := range tests {
+		if got := beancountAccount(tt.name); got != tt.want {
+			t.Errorf("beancountAccount(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPayment_validate(t *testing.T) {
+	date := time.Date(2020, 4, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		p       Payment
+		wantErr bool
+	}{
+		{"ok", Payment{Invoice: "1", Date: date, Amount: dec("10")}, false},
+		{"no invoice", Payment{Date: date, Amount: dec("10")}, true},
+		{"no date", Payment{Invoice: "1", Amount: dec("10")}, true},
+		{"negative", Payment{Invoice: "1", Date: date, Amount: dec("-10")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.p.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Payment.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
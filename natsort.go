@@ -0,0 +1,60 @@
+package sheet2inv
+
+import "sort"
+
+// naturalLess reports whether a sorts before b in the natural order: digit
+// runs are compared as numbers, so that "PROJ-9" sorts before "PROJ-10".
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			ni, nj := digits(a, i), digits(b, j)
+			x, y := trimZeros(a[i:ni]), trimZeros(b[j:nj])
+			if len(x) != len(y) {
+				return len(x) < len(y)
+			}
+			if x != y {
+				return x < y
+			}
+			i, j = ni, nj
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	// naturally equal, i.e. "A-01" and "A-1".
+	return a < b
+}
+
+// sortNatural sorts the strings in the natural order.
+func sortNatural(ss []string) {
+	sort.Slice(ss, func(i, j int) bool {
+		return naturalLess(ss[i], ss[j])
+	})
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digits returns the end of the digit run, starting at i.
+func digits(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// trimZeros trims the leading zeros of the number.
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
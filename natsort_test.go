@@ -0,0 +1,41 @@
+package sheet2inv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_naturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"PROJ-9", "PROJ-10", true},
+		{"PROJ-10", "PROJ-9", false},
+		{"PROJ-10", "PROJ-10", false},
+		{"A-2", "B-1", true},
+		{"A-1", "A-1a", true},
+		{"A-01", "A-1", true}, // naturally equal, ordered as strings
+		{"A-1", "A-01", false},
+		{"A-007", "A-10", true},
+		{"001", "A-1", true},
+		{"", "A", true},
+		{"2020-W9", "2020-W10", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"<"+tt.b, func(t *testing.T) {
+			if got := naturalLess(tt.a, tt.b); got != tt.want {
+				t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortNatural(t *testing.T) {
+	got := []string{"PROJ-10", "PROJ-9", "ABC-100", "PROJ-1", "ABC-20"}
+	sortNatural(got)
+	want := []string{"ABC-20", "ABC-100", "PROJ-1", "PROJ-9", "PROJ-10"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortNatural() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+package sheet2inv
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/rusq/sheet2inv/forms"
+	"gopkg.in/yaml.v3"
+)
+
+// AllClients selects all client profiles.
+const AllClients = "all"
+
+// Profiles is the multi-client configuration.  Seller details are shared
+// between all client profiles, and each client profile has its own bill-to,
+// rates, tax, spreadsheet and output settings.
+type Profiles struct {
+	Seller  Seller                      `yaml:"seller"`
+	Clients map[string]*TimesheetConfig `yaml:"clients"`
+	Routing []*Route                    `yaml:"routing,omitempty"` // timesheet row routing rules
+
+	single bool // loaded from a single client config
+}
+
+// Seller contains seller details, shared between the client profiles.
+// Client profile values, if set, take precedence.
+type Seller struct {
+	Address   forms.Address `yaml:",omitempty"`
+	Bank      string        `yaml:",omitempty"`
+	Account   string        `yaml:",omitempty"`
+	IBAN      string        `yaml:"iban,omitempty"`
+	BIC       string        `yaml:"bic,omitempty"`
+	Image     string        `yaml:",omitempty"`
+	ImageData string        `yaml:"image_data,omitempty"` // base64 encoded logo image
+}
+
+// NewProfilesFromFile loads the client profiles from file.  The file can be
+// either a multi-client configuration, or a single client timesheet config,
+// in which case it is loaded as the only profile with an empty name.
+func NewProfilesFromFile(filename string) (*Profiles, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Clients yaml.Node `yaml:"clients"`
+	}
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Clients.IsZero() {
+		cfg, err := readConfig(filename)
+		if err != nil {
+			return nil, err
+		}
+		return &Profiles{Clients: map[string]*TimesheetConfig{"": cfg}, single: true}, nil
+	}
+
+	var p Profiles
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Clients) == 0 {
+		return nil, errors.New("no client profiles defined")
+	}
+	for _, name := range p.Names() {
+		cfg := p.Clients[name]
+		if cfg == nil {
+			return nil, fmt.Errorf("client %q: empty profile", name)
+		}
+		cfg.client = name
+		cfg.multiClient = len(p.Clients) > 1
+		if cfg.Values != nil {
+			p.Seller.apply(&cfg.Values.InvoiceFields)
+		}
+		if err := cfg.init(); err != nil {
+			return nil, fmt.Errorf("client %q: %s", name, err)
+		}
+	}
+	if err := p.checkOutputNames(); err != nil {
+		return nil, err
+	}
+	for _, r := range p.Routing {
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+		if _, ok := p.Clients[r.Client]; !ok {
+			return nil, fmt.Errorf("routing: unknown client: %q", r.Client)
+		}
+	}
+	return &p, nil
+}
+
+// checkOutputNames returns an error if the output file names of two client
+// profiles do not depend on the client, and the invoices of one client would
+// overwrite the invoices of the other.
+func (p *Profiles) checkOutputNames() error {
+	seen := make(map[string]string)
+	for _, name := range p.Names() {
+		o := p.Clients[name].output()
+		if strings.Contains(o.Name, "{client}") {
+			continue
+		}
+		pattern := filepath.Join(o.Dir, o.Name)
+		if other, ok := seen[pattern]; ok {
+			return fmt.Errorf("clients %q and %q: same output file name %q, add {client} to the output name", other, name, pattern)
+		}
+		seen[pattern] = name
+	}
+	return nil
+}
+
+// apply sets the seller details on the invoice fields, unless they are
+// already set.
+func (s *Seller) apply(fields *forms.InvoiceFields) {
+	if reflect.DeepEqual(fields.Address, forms.Address{}) {
+		fields.Address = s.Address
+	}
+	if fields.Bank == "" {
+		fields.Bank = s.Bank
+	}
+	if fields.Account == "" {
+		fields.Account = s.Account
+	}
+	if fields.IBAN == "" {
+		fields.IBAN = s.IBAN
+	}
+	if fields.BIC == "" {
+		fields.BIC = s.BIC
+	}
+	if fields.Image == "" && fields.ImageData == "" {
+		fields.Image, fields.ImageData = s.Image, s.ImageData
+	}
+}
+
+// strip resets the invoice fields that are equal to the seller details,
+// so that they are not duplicated in the saved client profile.  It returns
+// the function that restores them.  The address is compared as a whole: an
+// address that differs from the seller's in any field is kept in full, as
+// apply only fills in an empty address.
+func (s *Seller) strip(fields *forms.InvoiceFields) (restore func()) {
+	saved := *fields
+	if reflect.DeepEqual(fields.Address, s.Address) {
+		fields.Address = forms.Address{}
+	}
+	if fields.Bank == s.Bank {
+		fields.Bank = ""
+	}
+	if fields.Account == s.Account {
+		fields.Account = ""
+	}
+	if fields.IBAN == s.IBAN {
+		fields.IBAN = ""
+	}
+	if fields.BIC == s.BIC {
+		fields.BIC = ""
+	}
+	if fields.Image == s.Image && fields.ImageData == s.ImageData {
+		fields.Image, fields.ImageData = "", ""
+	}
+	return func() {
+		fields.Address, fields.Bank, fields.Account, fields.Image = saved.Address, saved.Bank, saved.Account, saved.Image
+		fields.ImageData = saved.ImageData
+		fields.IBAN, fields.BIC = saved.IBAN, saved.BIC
+	}
+}
+
+// Names returns the client profile names in the natural order.
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Clients))
+	for name := range p.Clients {
+		names = append(names, name)
+	}
+	sortNatural(names)
+	return names
+}
+
+// Select returns the client profile names from the comma separated list.
+// Empty list or "all" selects all profiles.
+func (p *Profiles) Select(list string) ([]string, error) {
+	if list == "" || list == AllClients {
+		return p.Names(), nil
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := p.Clients[name]; !ok {
+			return nil, fmt.Errorf("unknown client: %q", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Get returns the client profile config.
+func (p *Profiles) Get(name string) *TimesheetConfig {
+	return p.Clients[name]
+}
+
+// Save saves the profiles to a file on disk.
+func (p *Profiles) Save(filename string) error {
+	if p.single {
+		return saveConfig(filename, p.Clients[""])
+	}
+	// clients are encoded one by one, so that the seller details are
+	// stripped from a single client profile at a time.
+	clients := make(map[string]*yaml.Node, len(p.Clients))
+	for name, cfg := range p.Clients {
+		restore := func() {}
+		if cfg.Values != nil {
+			restore = p.Seller.strip(&cfg.Values.InvoiceFields)
+		}
+		var node yaml.Node
+		err := node.Encode(cfg)
+		restore()
+		if err != nil {
+			return fmt.Errorf("client %q: %s", name, err)
+		}
+		clients[name] = &node
+	}
+	data, err := yaml.Marshal(struct {
+		Seller  Seller                `yaml:"seller"`
+		Clients map[string]*yaml.Node `yaml:"clients"`
+		Routing []*Route              `yaml:"routing,omitempty"`
+	}{p.Seller, clients, p.Routing})
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing config: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package sheet2inv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `
+seller:
+  address:
+    name: Seller Ltd
+  bank: Seller Bank
+  account: "12-3456"
+clients:
+  acme:
+    spreadsheet:
+      id: sheet1
+      range: A:E
+      columns: {time_start: A, time_end: B, invoice: C, description: D, issue: E}
+    invoice:
+      hourly_rate: 100
+      invoice_fields:
+        bill_to:
+          name: ACME
+  globex:
+    spreadsheet:
+      id: sheet1
+      range: A:E
+      columns: {time_start: A, time_end: B, invoice: C, description: D, issue: E}
+    invoice:
+      hourly_rate: 150
+      invoice_fields:
+        bank: Other Bank
+        bill_to:
+          name: Globex
+    output:
+      dir: globex
+      name: "{client}-{id}"
+`
+
+func TestNewProfilesFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fields.yaml")
+	if err := ioutil.WriteFile(filename, []byte(testProfiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewProfilesFromFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.Names(), []string{"acme", "globex"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	acme, globex := p.Get("acme").Values.InvoiceFields, p.Get("globex").Values.InvoiceFields
+	if acme.Address.Name != "Seller Ltd" || acme.Bank != "Seller Bank" {
+		t.Errorf("seller details are not applied: %+v", acme)
+	}
+	if globex.Bank != "Other Bank" || globex.Account != "12-3456" {
+		t.Errorf("client overrides are not preserved: %+v", globex)
+	}
+	if got, want := p.Get("globex").Filename("42", ".pdf"), filepath.Join("globex", "globex-42.pdf"); got != want {
+		t.Errorf("Filename() = %q, want %q", got, want)
+	}
+	if got, want := p.Get("acme").Filename("42", ".pdf"), "invoice-acme-42.pdf"; got != want {
+		t.Errorf("Filename() = %q, want the default name with the client %q", got, want)
+	}
+	if _, err := p.Select("acme,initech"); err == nil {
+		t.Errorf("Select() expected error for unknown client")
+	}
+
+	if err := p.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "Seller Bank"); n != 1 {
+		t.Errorf("seller bank is saved %d times, want 1", n)
+	}
+	if p.Get("acme").Values.InvoiceFields.Bank != "Seller Bank" {
+		t.Errorf("seller details are not restored after save")
+	}
+}
+
+func TestNewProfilesFromFile_outputNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fields.yaml")
+
+	const client = `
+  %s:
+    spreadsheet:
+      id: sheet1
+      range: A:E
+      columns: {time_start: A, time_end: B, invoice: C, description: D, issue: E}
+    invoice:
+      hourly_rate: 100
+    output: %s
+`
+	tests := []struct {
+		name    string
+		acme    string
+		globex  string
+		wantErr bool
+	}{
+		{"default names", "{}", "{}", false},
+		{"client in the name", `{name: "{client}-{id}"}`, `{name: "{client}-{id}"}`, false},
+		{"different directories", `{name: "{id}"}`, `{dir: globex, name: "{id}"}`, false},
+		{"same name", `{name: "{id}"}`, `{name: "{id}"}`, true},
+		{"same as the single client default", `{name: "invoice-{id}"}`, `{name: "invoice-{id}"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "clients:" + fmt.Sprintf(client, "acme", tt.acme) + fmt.Sprintf(client, "globex", tt.globex)
+			if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewProfilesFromFile(filename)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewProfilesFromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfiles_Save_partialSeller(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "fields.yaml")
+	if err := ioutil.WriteFile(filename, []byte(testProfiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProfilesFromFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// globex address differs from the seller's in one field only, and the
+	// account matches the seller's, while the bank does not.
+	globex := &p.Get("globex").Values.InvoiceFields
+	globex.Address.Email = "billing@seller.example"
+
+	if err := p.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "Seller Ltd"); n != 2 {
+		t.Errorf("seller name is saved %d times, want 2: in the seller and in the full globex address", n)
+	}
+	if n := strings.Count(string(data), "12-3456"); n != 1 {
+		t.Errorf("seller account is saved %d times, want 1", n)
+	}
+
+	saved, err := NewProfilesFromFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range p.Names() {
+		got, want := saved.Get(name).Values.InvoiceFields, p.Get(name).Values.InvoiceFields
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("client %q: saved fields = %+v, want %+v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package sheet2inv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// Schedule frequencies
+const (
+	Monthly   = "monthly"
+	Quarterly = "quarterly"
+)
+
+const periodFmt = "2006-01"
+
+// RecurringInvoice is a fixed fee invoice, that is issued on schedule.
+type RecurringInvoice struct {
+	Name     string        // client name, used as the invoice number prefix
+	BillTo   forms.Address `yaml:"bill_to"`
+	Items    []RecurringItem
+	Schedule Schedule
+	Issued   []string `yaml:",omitempty"` // issued periods, i.e. 2020-01
+}
+
+// RecurringItem is the line item of the recurring invoice.
+type RecurringItem struct {
+	Description string
+	Qty         decimal.Decimal
+	Price       decimal.Decimal
+}
+
+// Schedule is the recurring invoice schedule.
+type Schedule struct {
+	Every  string    // monthly or quarterly
+	Day    int       // day of the month the invoice is issued on
+	DueDay int       `yaml:"due_day,omitempty"` // day of the month for due date, defaults to invoice due_day
+	Start  time.Time // first invoice date
+}
+
+func (r *RecurringInvoice) validate() error {
+	if r.Name == "" {
+		return errors.New("recurring invoice name is empty")
+	}
+	switch r.Schedule.Every {
+	case "":
+		r.Schedule.Every = Monthly
+	case Monthly, Quarterly:
+	default:
+		return fmt.Errorf("recurring invoice %q: invalid schedule: %q", r.Name, r.Schedule.Every)
+	}
+	if r.Schedule.Day < 1 || 31 < r.Schedule.Day {
+		return fmt.Errorf("recurring invoice %q: invalid day", r.Name)
+	}
+	if r.Schedule.Start.IsZero() {
+		return fmt.Errorf("recurring invoice %q: start date is missing", r.Name)
+	}
+	if len(r.Items) == 0 {
+		return fmt.Errorf("recurring invoice %q: no items", r.Name)
+	}
+	return nil
+}
+
+// months returns the number of months between the invoices.
+func (s *Schedule) months() int {
+	if s.Every == Quarterly {
+		return 3
+	}
+	return 1
+}
+
+// dates returns the invoice dates from the start up to and including now.
+func (s *Schedule) dates(now time.Time) []time.Time {
+	var dates []time.Time
+	for mo := 0; ; mo += s.months() {
+		first := time.Date(s.Start.Year(), s.Start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, mo, 0)
+		day := s.Day
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		date := first.AddDate(0, 0, day-1)
+		if date.After(now) {
+			break
+		}
+		if date.Before(s.Start) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+func (r *RecurringInvoice) isIssued(period string) bool {
+	for _, p := range r.Issued {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurringInvoice) markIssued(period string) {
+	if !r.isIssued(period) {
+		r.Issued = append(r.Issued, period)
+	}
+}
+
+// Due returns invoices that are due since the last run as of now.
+func (r *RecurringInvoice) Due(values *InvoiceValues, now time.Time) []*Invoice {
+	dueDay := r.Schedule.DueDay
+	if dueDay == 0 {
+		dueDay = values.PrevMonthDueDay
+	}
+	var invs []*Invoice
+	for _, date := range r.Schedule.dates(now) {
+		start, end, due := periodDates(date, r.Schedule.months(), dueDay)
+		if r.isIssued(start.Format(periodFmt)) {
+			continue
+		}
+		v := *values
+		v.Retainer = nil
+		v.Budgets = nil
+		v.InvoiceFields.BillTo = r.BillTo
+		v.InvoiceFields.Date = date
+		v.InvoiceFields.PeriodStart = start
+		v.InvoiceFields.PeriodEnd = end
+		v.InvoiceFields.Due = due
+		if dueDay == 0 {
+			v.InvoiceFields.Due = date
+		}
+
+		inv := NewInvoice(&v, fmt.Sprintf("%s-%s", r.Name, start.Format("200601")), nil)
+		inv.recurring = r
+		for _, item := range r.Items {
+			inv.AddFixed(item.Description, item.Qty, item.Price)
+		}
+		invs = append(invs, inv)
+	}
+	return invs
+}
+
+// RecurringDue returns all recurring invoices that are due as of now.
+func (cfg *TimesheetConfig) RecurringDue(now time.Time) []*Invoice {
+	var invs []*Invoice
+	for _, r := range cfg.Recurring {
+		invs = append(invs, r.Due(cfg.Values, now)...)
+	}
+	return invs
+}
@@ -0,0 +1,116 @@
+package sheet2inv
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSchedule_dates(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		now      time.Time
+		want     []time.Time
+	}{
+		{"monthly",
+			Schedule{Every: Monthly, Day: 5, Start: date(2020, 1, 1)},
+			date(2020, 3, 4),
+			[]time.Time{date(2020, 1, 5), date(2020, 2, 5)},
+		},
+		{"monthly, start after the day",
+			Schedule{Every: Monthly, Day: 5, Start: date(2020, 1, 10)},
+			date(2020, 3, 5),
+			[]time.Time{date(2020, 2, 5), date(2020, 3, 5)},
+		},
+		{"quarterly, end of month",
+			Schedule{Every: Quarterly, Day: 31, Start: date(2020, 1, 1)},
+			date(2020, 12, 1),
+			[]time.Time{date(2020, 1, 31), date(2020, 4, 30), date(2020, 7, 31), date(2020, 10, 31)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.dates(tt.now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Schedule.dates() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("Schedule.dates()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecurringInvoice_Due(t *testing.T) {
+	r := &RecurringInvoice{
+		Name:     "ACME",
+		Items:    []RecurringItem{{Description: "Hosting", Qty: dec("1"), Price: dec("500")}},
+		Schedule: Schedule{Every: Monthly, Day: 1, DueDay: 15, Start: date(2020, 1, 1)},
+		Issued:   []string{"2019-12"},
+	}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	values := &InvoiceValues{}
+	invs := r.Due(values, date(2020, 2, 10))
+	if len(invs) != 1 {
+		t.Fatalf("Due() returned %d invoices, want 1", len(invs))
+	}
+	inv := invs[0]
+	if inv.InvoiceID != "ACME-202001" {
+		t.Errorf("InvoiceID = %q, want ACME-202001", inv.InvoiceID)
+	}
+	if !inv.Total.Equal(dec("500")) {
+		t.Errorf("Total = %v, want 500", inv.Total)
+	}
+	inv.Commit()
+	if invs := r.Due(values, date(2020, 2, 10)); len(invs) != 0 {
+		t.Errorf("Due() after commit returned %d invoices, want 0", len(invs))
+	}
+}
+
+func TestRecurringInvoice_Due_dueDayBeforeDay(t *testing.T) {
+	r := &RecurringInvoice{
+		Name:     "ACME",
+		Items:    []RecurringItem{{Description: "Hosting", Qty: dec("1"), Price: dec("500")}},
+		Schedule: Schedule{Every: Monthly, Day: 25, DueDay: 10, Start: date(2020, 1, 1)},
+	}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	invs := r.Due(&InvoiceValues{}, date(2020, 1, 26))
+	if len(invs) != 1 {
+		t.Fatalf("Due() returned %d invoices, want 1", len(invs))
+	}
+	fields := invs[0].values.InvoiceFields
+	if want := date(2020, 2, 10); !fields.Due.Equal(want) {
+		t.Errorf("Due = %s, want %s after the invoice date %s", fields.Due, want, fields.Date)
+	}
+}
+
+func TestRecurringInvoice_Due_shortMonth(t *testing.T) {
+	r := &RecurringInvoice{
+		Name:     "ACME",
+		Items:    []RecurringItem{{Description: "Hosting", Qty: dec("1"), Price: dec("500")}},
+		Schedule: Schedule{Every: Monthly, Day: 1, DueDay: 31, Start: date(2021, 2, 1)},
+	}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	invs := r.Due(&InvoiceValues{}, date(2021, 4, 2))
+	want := []time.Time{date(2021, 2, 28), date(2021, 3, 31), date(2021, 4, 30)}
+	if len(invs) != len(want) {
+		t.Fatalf("Due() returned %d invoices, want %d", len(invs), len(want))
+	}
+	for i, inv := range invs {
+		if due := inv.values.InvoiceFields.Due; !due.Equal(want[i]) {
+			t.Errorf("invoice %d: Due = %s, want %s", i, due, want[i])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package sheet2inv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+// Retainer units
+const (
+	RetainerHours = "hours" // balance is in hours
+	RetainerMoney = "money" // balance is in invoice currency
+)
+
+// Retainer is a prepaid block of hours or money, that invoices are drawn
+// down from.  The balance is saved to the config after each run, so that
+// the next period starts from the right figure.
+type Retainer struct {
+	Unit        string          `yaml:"unit"`                   // "hours" or "money"
+	Initial     decimal.Decimal `yaml:"initial"`                // initial balance
+	Balance     decimal.Decimal `yaml:"balance"`                // remaining balance
+	OverageRate decimal.Decimal `yaml:"overage_rate,omitempty"` // hourly rate beyond the retainer
+	History     []RetainerDraw  `yaml:"history,omitempty"`
+}
+
+// RetainerDraw is a single drawdown from the retainer.
+type RetainerDraw struct {
+	Invoice string
+	Date    time.Time
+	Used    decimal.Decimal
+	Balance decimal.Decimal // balance after the drawdown
+}
+
+// RetainerUsage is the retainer usage for one invoice.
+type RetainerUsage struct {
+	Opening decimal.Decimal // balance before the invoice
+	Used    decimal.Decimal // drawn from the balance, in retainer units
+	Closing decimal.Decimal // balance after the invoice
+
+	Hours   decimal.Decimal // hours used this period
+	Overage decimal.Decimal // hours beyond the retainer
+	Credit  decimal.Decimal // amount covered by the retainer
+	Charge  decimal.Decimal // amount billed for the overage
+}
+
+func (r *Retainer) validate() error {
+	switch r.Unit {
+	case "":
+		r.Unit = RetainerHours
+	case RetainerHours, RetainerMoney:
+	default:
+		return fmt.Errorf("invalid retainer unit: %q", r.Unit)
+	}
+	if r.Initial.IsNegative() {
+		return errors.New("retainer initial balance can't be negative")
+	}
+	if r.Balance.IsZero() && len(r.History) == 0 {
+		r.Balance = r.Initial
+	}
+	return nil
+}
+
+// lastDraw returns the index of the drawdown for the invoice or -1.
+func (r *Retainer) lastDraw(invoiceID string) int {
+	for i := len(r.History) - 1; i >= 0; i-- {
+		if r.History[i].Invoice == invoiceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// opening returns the balance before the invoice.  If the invoice has
+// already been drawn down on previous run, it returns the balance before
+// that drawdown.
+func (r *Retainer) opening(invoiceID string) decimal.Decimal {
+	if idx := r.lastDraw(invoiceID); idx >= 0 {
+		d := r.History[idx]
+		return d.Balance.Add(d.Used)
+	}
+	return r.Balance
+}
+
+// usage calculates the retainer usage for the invoice with the given hours
+// and total amount.
+func (r *Retainer) usage(invoiceID string, hours, total decimal.Decimal) *RetainerUsage {
+	u := RetainerUsage{
+		Opening: r.opening(invoiceID),
+		Hours:   hours,
+	}
+	if u.Opening.IsNegative() {
+		u.Opening = decimal.Zero
+	}
+	var remaining decimal.Decimal // amount not covered by the retainer
+	switch r.Unit {
+	case RetainerMoney:
+		u.Used = decimal.Min(total, u.Opening)
+		u.Credit = u.Used
+		remaining = total.Sub(u.Credit)
+		if total.IsPositive() {
+			u.Overage = hours.Mul(remaining).Div(total)
+		}
+	default:
+		u.Used = decimal.Min(hours, u.Opening)
+		u.Overage = hours.Sub(u.Used)
+		if hours.IsPositive() {
+			u.Credit = total.Mul(u.Used).Div(hours)
+		}
+		remaining = total.Sub(u.Credit)
+	}
+	u.Closing = u.Opening.Sub(u.Used)
+	if r.OverageRate.IsPositive() {
+		u.Charge = u.Overage.Mul(r.OverageRate)
+	} else {
+		u.Charge = remaining
+	}
+	return &u
+}
+
+// draw records the usage for the invoice and updates the balance.
+func (r *Retainer) draw(invoiceID string, date time.Time, u *RetainerUsage) {
+	d := RetainerDraw{Invoice: invoiceID, Date: date, Used: u.Used, Balance: u.Closing}
+	if idx := r.lastDraw(invoiceID); idx >= 0 {
+		// invoice is regenerated, replacing the previous drawdown.
+		r.Balance = r.Balance.Add(r.History[idx].Used).Sub(u.Used)
+		r.History[idx] = d
+		return
+	}
+	r.Balance = u.Closing
+	r.History = append(r.History, d)
+}
+
+// adjustments returns the invoice adjustments for the usage.  The retainer
+// credit is subtracted from the invoice, and if the overage rate differs
+// from the line rates, the difference is added.
+func (u *RetainerUsage) adjustments(unit string, total decimal.Decimal) []Adjustment {
+	var adj []Adjustment
+	if !u.Used.IsZero() || !u.Credit.IsZero() {
+		adj = append(adj, Adjustment{Kind: AdjustRetainer, Quantity: u.Used, Unit: unit, Amount: u.Credit.Neg()})
+	}
+	// the overage is charged at the overage rate even if the retainer is
+	// exhausted.
+	if diff := u.Charge.Sub(total.Sub(u.Credit)); !diff.IsZero() {
+		adj = append(adj, Adjustment{Kind: AdjustOverage, Quantity: u.Overage, Unit: RetainerHours, Amount: diff})
+	}
+	return adj
+}
+
+// Remark returns the human readable retainer status line.
+func (u *RetainerUsage) Remark(loc *forms.Locale, unit, currency string) string {
+	return loc.Format(forms.LabelRetainerRemark, formatRetainer(loc, unit, currency, u.Used), formatRetainer(loc, unit, currency, u.Closing))
+}
+
+// formatRetainer formats the retainer amount in its unit.
+func formatRetainer(loc *forms.Locale, unit, currency string, d decimal.Decimal) string {
+	if unit == RetainerMoney {
+		return loc.Amount(d, currency)
+	}
+	return loc.Hours(d)
+}
@@ -0,0 +1,145 @@
+package sheet2inv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rusq/sheet2inv/forms"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestRetainer_usage(t *testing.T) {
+	type args struct {
+		hours string
+		total string
+	}
+	tests := []struct {
+		name        string
+		retainer    Retainer
+		args        args
+		wantUsed    string
+		wantClosing string
+		wantOverage string
+		wantCharge  string
+	}{
+		{"hours within retainer",
+			Retainer{Unit: RetainerHours, Balance: dec("20")},
+			args{"10", "1000"},
+			"10", "10", "0", "0",
+		},
+		{"hours overage at line rate",
+			Retainer{Unit: RetainerHours, Balance: dec("8")},
+			args{"10", "1000"},
+			"8", "0", "2", "200",
+		},
+		{"hours overage at overage rate",
+			Retainer{Unit: RetainerHours, Balance: dec("8"), OverageRate: dec("150")},
+			args{"10", "1000"},
+			"8", "0", "2", "300",
+		},
+		{"money overage",
+			Retainer{Unit: RetainerMoney, Balance: dec("500")},
+			args{"10", "1000"},
+			"500", "0", "5", "500",
+		},
+		{"empty retainer",
+			Retainer{Unit: RetainerHours},
+			args{"10", "1000"},
+			"0", "0", "10", "1000",
+		},
+		{"exhausted retainer at overage rate",
+			Retainer{Unit: RetainerHours, OverageRate: dec("150")},
+			args{"10", "1000"},
+			"0", "0", "10", "1500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.retainer.usage("1", dec(tt.args.hours), dec(tt.args.total))
+			if !u.Used.Equal(dec(tt.wantUsed)) {
+				t.Errorf("Retainer.usage() Used = %v, want %v", u.Used, tt.wantUsed)
+			}
+			if !u.Closing.Equal(dec(tt.wantClosing)) {
+				t.Errorf("Retainer.usage() Closing = %v, want %v", u.Closing, tt.wantClosing)
+			}
+			if !u.Overage.Equal(dec(tt.wantOverage)) {
+				t.Errorf("Retainer.usage() Overage = %v, want %v", u.Overage, tt.wantOverage)
+			}
+			if !u.Charge.Equal(dec(tt.wantCharge)) {
+				t.Errorf("Retainer.usage() Charge = %v, want %v", u.Charge, tt.wantCharge)
+			}
+			// net amount billed must be equal to the overage charge.
+			net := dec(tt.args.total)
+			for _, adj := range u.adjustments(tt.retainer.Unit, dec(tt.args.total)) {
+				net = net.Add(adj.Amount)
+			}
+			if !net.Equal(u.Charge) {
+				t.Errorf("net = %v, want %v", net, u.Charge)
+			}
+		})
+	}
+}
+
+func TestRetainer_draw(t *testing.T) {
+	r := Retainer{Unit: RetainerHours, Initial: dec("40")}
+	if err := r.validate(); err != nil {
+		t.Fatal(err)
+	}
+	r.draw("1", time.Time{}, r.usage("1", dec("10"), dec("1000")))
+	if !r.Balance.Equal(dec("30")) {
+		t.Errorf("balance = %v, want 30", r.Balance)
+	}
+	// regenerating the same invoice must not draw down twice.
+	r.draw("1", time.Time{}, r.usage("1", dec("12"), dec("1200")))
+	if !r.Balance.Equal(dec("28")) {
+		t.Errorf("balance after regeneration = %v, want 28", r.Balance)
+	}
+	if len(r.History) != 1 {
+		t.Errorf("history len = %d, want 1", len(r.History))
+	}
+}
+
+func TestRetainerUsage_adjustments(t *testing.T) {
+	tests := []struct {
+		name       string
+		lang       string
+		retainer   Retainer
+		want       []string
+		wantRemark string
+	}{
+		{"english hours", "en",
+			Retainer{Unit: RetainerHours, Balance: dec("8"), OverageRate: dec("150")},
+			[]string{"RETAINER (8.00 hrs)", "OVERAGE (2.00 hrs)"},
+			"Retainer: 8.00 hrs used this period / 0.00 hrs remaining.",
+		},
+		{"german money", "de",
+			Retainer{Unit: RetainerMoney, Balance: dec("1500")},
+			[]string{"VORAUSZAHLUNG (1.000,00 €)"},
+			"Vorauszahlung: 1.000,00 € in diesem Zeitraum verbraucht / 500,00 € verbleibend.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := forms.NewLocale(tt.lang, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := tt.retainer.usage("1", dec("10"), dec("1000"))
+			var got []string
+			for _, adj := range u.adjustments(tt.retainer.Unit, dec("1000")) {
+				got = append(got, adj.name(loc, "EUR"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RetainerUsage.adjustments() = %q, want %q", got, tt.want)
+			}
+			if got := u.Remark(loc, tt.retainer.Unit, "EUR"); got != tt.wantRemark {
+				t.Errorf("RetainerUsage.Remark() = %q, want %q", got, tt.wantRemark)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package sheet2inv
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Route is the timesheet routing rule.  It assigns the timesheet items to
+// the client, if all of the set conditions match.  The client column value,
+// if present, takes precedence over the rules.
+type Route struct {
+	Client      string
+	Project     string `yaml:",omitempty"` // Jira project key, i.e. "ACME" matches "ACME-42"
+	Issue       string `yaml:",omitempty"` // regexp on the issue
+	Description string `yaml:",omitempty"` // regexp on the description
+
+	issue *regexp.Regexp
+	descr *regexp.Regexp
+}
+
+// Unrouted is the timesheet item that didn't match any of the routing
+// rules.
+type Unrouted struct {
+	Invoice  string
+	Start    time.Time
+	Item     Item
+	Duration time.Duration
+	Reason   string
+}
+
+func (r *Route) compile() (err error) {
+	if r.Client == "" {
+		return errors.New("routing: client is empty")
+	}
+	if r.Project == "" && r.Issue == "" && r.Description == "" {
+		return fmt.Errorf("routing: client %q: no conditions", r.Client)
+	}
+	if r.Issue != "" {
+		if r.issue, err = regexp.Compile(r.Issue); err != nil {
+			return fmt.Errorf("routing: client %q: %s", r.Client, err)
+		}
+	}
+	if r.Description != "" {
+		if r.descr, err = regexp.Compile(r.Description); err != nil {
+			return fmt.Errorf("routing: client %q: %s", r.Client, err)
+		}
+	}
+	return nil
+}
+
+func (r *Route) matches(item *Item) bool {
+	if r.Project != "" && !strings.HasPrefix(item.Issue, r.Project+"-") {
+		return false
+	}
+	if r.issue != nil && !r.issue.MatchString(item.Issue) {
+		return false
+	}
+	if r.descr != nil && !r.descr.MatchString(item.Description) {
+		return false
+	}
+	return true
+}
+
+// route returns the client for the item.  The reason is set, if the item
+// can't be routed.
+func (p *Profiles) route(item *Item) (client string, reason string) {
+	if item.Client != "" {
+		if _, ok := p.Clients[item.Client]; !ok {
+			return "", fmt.Sprintf("unknown client %q", item.Client)
+		}
+		return item.Client, ""
+	}
+	for _, r := range p.Routing {
+		if r.matches(item) {
+			return r.Client, ""
+		}
+	}
+	return "", "no matching rule"
+}
+
+// Routed returns true if the routing is configured, or any of the client
+// spreadsheets has the client column.  The single client config is never
+// routed, all its rows belong to the only profile, that has no name to
+// match the client column.
+func (p *Profiles) Routed() bool {
+	if p.single {
+		return false
+	}
+	if len(p.Routing) > 0 {
+		return true
+	}
+	for _, cfg := range p.Clients {
+		if cfg.Spreadsheet != nil && cfg.Spreadsheet.Columns.Client != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteRows parses the rows of the spreadsheet and routes the items to the
+// clients that use the same spreadsheet.  Entries that contain items of
+// several clients are split between them, and items that can't be routed
+// are returned in the "not routed" report.
+func (p *Profiles) RouteRows(sheet *Spreadsheet, rows [][]interface{}, invoiceID string) (map[string]*Timesheet, []Unrouted, error) {
+	src := *sheet
+	src.filter = nil
+	source, err := NewFromRows(&TimesheetConfig{Spreadsheet: &src, Values: &InvoiceValues{}}, rows, invoiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	timesheets, unrouted := p.Route(source, func(cfg *TimesheetConfig) bool {
+		return cfg.Spreadsheet.ID == sheet.ID && cfg.Spreadsheet.Range == sheet.Range
+	})
+	return timesheets, unrouted, nil
+}
+
+// Route routes the items of the timesheet to the clients, for which the
+// accept function returns true.  Items of other clients, and of the
+// invoices that the client spreadsheet filter skips, are returned as not
+// routed.
+func (p *Profiles) Route(source *Timesheet, accept func(cfg *TimesheetConfig) bool) (map[string]*Timesheet, []Unrouted) {
+	var (
+		timesheets = make(map[string]*Timesheet)
+		unrouted   []Unrouted
+	)
+	for _, e := range source.Entries {
+		e.Recalculate()
+		var (
+			order    []string
+			byClient = make(map[string][]Item)
+		)
+		for _, item := range e.Items {
+			client, reason := p.route(&item)
+			if reason != "" {
+				unrouted = append(unrouted, Unrouted{Invoice: e.Invoice, Start: e.Start, Item: item, Duration: item.duration, Reason: reason})
+				continue
+			}
+			if _, ok := byClient[client]; !ok {
+				order = append(order, client)
+			}
+			byClient[client] = append(byClient[client], item)
+		}
+		for _, client := range order {
+			cfg := p.Clients[client]
+			var reason string
+			switch {
+			case !accept(cfg):
+				reason = fmt.Sprintf("client %q is not selected", client)
+			case !cfg.Spreadsheet.matches(e.Invoice):
+				reason = fmt.Sprintf("client %q: filtered by the spreadsheet filter", client)
+			}
+			if reason != "" {
+				for _, item := range byClient[client] {
+					unrouted = append(unrouted, Unrouted{Invoice: e.Invoice, Start: e.Start, Item: item, Duration: item.duration, Reason: reason})
+				}
+				continue
+			}
+			ts, ok := timesheets[client]
+			if !ok {
+				ts = New(cfg)
+				timesheets[client] = ts
+			}
+			routed := *e
+			routed.Items = byClient[client]
+			routed.split = len(e.Items)
+			ts.Add(&routed)
+		}
+	}
+	return timesheets, unrouted
+}
+
+// String returns the "not routed" report line.
+func (u Unrouted) String() string {
+	return fmt.Sprintf("not routed: invoice %q, %s, %s: %s (%.2f hrs): %s",
+		u.Invoice, u.Start.Format("2006-01-02 15:04"), u.Item.Issue, u.Item.Description, u.Duration.Hours(), u.Reason)
+}
@@ -0,0 +1,133 @@
+package sheet2inv
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestProfiles_Route(t *testing.T) {
+	sheet := &Spreadsheet{ID: "1", Range: "A:F"}
+	p := &Profiles{
+		Clients: map[string]*TimesheetConfig{
+			"acme":   {Spreadsheet: sheet, Values: &InvoiceValues{Rate: dec("100")}},
+			"globex": {Spreadsheet: sheet, Values: &InvoiceValues{Rate: dec("200")}},
+		},
+		Routing: []*Route{
+			{Client: "acme", Project: "ACME"},
+			{Client: "globex", Description: "(?i)globex"},
+		},
+	}
+	for _, r := range p.Routing {
+		if err := r.compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := date(2020, 1, 1)
+	source := New(&TimesheetConfig{Values: &InvoiceValues{}})
+	source.Add(&TsEntry{Invoice: "1", Start: start, End: start.Add(4 * time.Hour), Items: []Item{
+		{Issue: "ACME-1", Description: "fix"},
+		{Issue: "X-1", Description: "Globex support"},
+		{Issue: "Y-1", Description: "lunch"},
+		{Issue: "Z-1", Description: "meeting", Client: "acme"},
+	}})
+
+	timesheets, unrouted := p.Route(source, func(*TimesheetConfig) bool { return true })
+	if len(unrouted) != 1 || unrouted[0].Item.Issue != "Y-1" {
+		t.Errorf("unrouted = %v, want Y-1", unrouted)
+	}
+	acme := timesheets["acme"].Invoices(nil).Get("1")
+	if !acme.Total.Equal(dec("200")) {
+		t.Errorf("acme total = %v, want 200", acme.Total)
+	}
+	if len(acme.Entries) != 2 {
+		t.Errorf("acme entries = %d, want 2", len(acme.Entries))
+	}
+	globex := timesheets["globex"].Invoices(nil).Get("1")
+	if !globex.Total.Equal(dec("200")) {
+		t.Errorf("globex total = %v, want 200", globex.Total)
+	}
+}
+
+func TestProfiles_Route_clientColumn(t *testing.T) {
+	sheet := &Spreadsheet{ID: "1", Range: "A:F", Columns: Columns{Client: "F"}}
+	p := &Profiles{
+		Clients: map[string]*TimesheetConfig{
+			"acme":   {Spreadsheet: sheet, Values: &InvoiceValues{Rate: dec("100")}},
+			"globex": {Spreadsheet: sheet, Values: &InvoiceValues{Rate: dec("200")}},
+		},
+	}
+	if !p.Routed() {
+		t.Fatal("Routed() = false, want true for the client column")
+	}
+
+	start := date(2020, 1, 1)
+	source := New(&TimesheetConfig{Values: &InvoiceValues{}})
+	source.Add(&TsEntry{Invoice: "1", Start: start, End: start.Add(2 * time.Hour), Items: []Item{
+		{Issue: "A-1", Description: "fix", Client: "acme"},
+		{Issue: "B-1", Description: "support", Client: "globex"},
+	}})
+	timesheets, unrouted := p.Route(source, func(*TimesheetConfig) bool { return true })
+	if len(unrouted) != 0 {
+		t.Errorf("unrouted = %v, want none", unrouted)
+	}
+	for client, want := range map[string]string{"acme": "100", "globex": "200"} {
+		if got := timesheets[client].Invoices(nil).Get("1").Total; !got.Equal(dec(want)) {
+			t.Errorf("%s total = %v, want %v", client, got, want)
+		}
+	}
+
+	sheet.Columns.Client = ""
+	if p.Routed() {
+		t.Error("Routed() = true, want false without rules and client column")
+	}
+
+	sheet.Columns.Client = "F"
+	single := &Profiles{Clients: map[string]*TimesheetConfig{"": {Spreadsheet: sheet}}, single: true}
+	if single.Routed() {
+		t.Error("Routed() = true, want false for the single client config")
+	}
+}
+
+func TestProfiles_Route_skipped(t *testing.T) {
+	sheet := &Spreadsheet{ID: "1", Range: "A:F", Columns: Columns{Client: "F"}}
+	other := &Spreadsheet{ID: "2", Range: "A:F", Columns: Columns{Client: "F"}}
+	filtered := &Spreadsheet{ID: "1", Range: "A:F", Columns: Columns{Client: "F"}, filter: regexp.MustCompile(`^2`)}
+	p := &Profiles{
+		Clients: map[string]*TimesheetConfig{
+			"acme":    {Spreadsheet: sheet, Values: &InvoiceValues{Rate: dec("100")}},
+			"globex":  {Spreadsheet: other, Values: &InvoiceValues{Rate: dec("200")}},
+			"initech": {Spreadsheet: filtered, Values: &InvoiceValues{Rate: dec("300")}},
+		},
+	}
+
+	start := date(2020, 1, 1)
+	source := New(&TimesheetConfig{Values: &InvoiceValues{}})
+	source.Add(&TsEntry{Invoice: "1", Start: start, End: start.Add(3 * time.Hour), Items: []Item{
+		{Issue: "A-1", Description: "fix", Client: "acme"},
+		{Issue: "G-1", Description: "support", Client: "globex"},
+		{Issue: "I-1", Description: "report", Client: "initech"},
+	}})
+	timesheets, unrouted := p.Route(source, func(cfg *TimesheetConfig) bool {
+		return cfg.Spreadsheet.ID == sheet.ID
+	})
+	if len(timesheets) != 1 || timesheets["acme"] == nil {
+		t.Errorf("timesheets = %v, want acme only", timesheets)
+	}
+	want := map[string]string{
+		"G-1": `client "globex" is not selected`,
+		"I-1": `client "initech": filtered by the spreadsheet filter`,
+	}
+	if len(unrouted) != len(want) {
+		t.Fatalf("unrouted = %v, want %d items", unrouted, len(want))
+	}
+	for _, u := range unrouted {
+		if u.Reason != want[u.Item.Issue] {
+			t.Errorf("%s reason = %q, want %q", u.Item.Issue, u.Reason, want[u.Item.Issue])
+		}
+		if u.Duration != time.Hour {
+			t.Errorf("%s duration = %v, want 1h", u.Item.Issue, u.Duration)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package sheet2inv
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/rusq/sheet2inv/bugtracker"
+	"github.com/rusq/sheet2inv/forms"
 	"github.com/shopspring/decimal"
 	"google.golang.org/api/sheets/v4"
 	"gopkg.in/yaml.v3"
@@ -51,6 +53,7 @@ type TsEntry struct {
 
 	rate       decimal.Decimal // hourly rate for this item
 	multiplier decimal.Decimal // multiplier
+	split      int             // number of items in the source entry, if the entry was routed
 }
 
 // Item is an paricular task/issue/ticket entry within one timesheet Item
@@ -58,6 +61,7 @@ type TsEntry struct {
 type Item struct {
 	Issue       string `json:",omitempty" yaml:",omitempty"`
 	Description string `json:",omitempty" yaml:",omitempty"`
+	Client      string `json:",omitempty" yaml:",omitempty"` // value of the client column
 
 	duration time.Duration
 }
@@ -68,29 +72,48 @@ func asString(v interface{}) string {
 
 // NewFromSheets creates timesheet from Google Sheets.
 func NewFromSheets(srv *sheets.Service, cfg *TimesheetConfig, invoiceID string) (*Timesheet, error) {
+	rows, err := FetchRows(srv, cfg.Spreadsheet)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromRows(cfg, rows, invoiceID)
+}
+
+// FetchRows retrieves the timesheet rows from Google Sheets.
+func FetchRows(srv *sheets.Service, sheet *Spreadsheet) ([][]interface{}, error) {
 	// Prints the names and majors of students in a sample spreadsheet:
 	// https://docs.google.com/spreadsheets/d/1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms/edit
 	resp, err := srv.Spreadsheets.Values.
-		Get(cfg.Spreadsheet.ID, cfg.Spreadsheet.Range).
+		Get(sheet.ID, sheet.Range).
 		ValueRenderOption(valueRenderOption).
 		DateTimeRenderOption(datetimeRenderOption).
 		Do()
 	if err != nil {
-		log.Fatalf("Unable to retrieve data from sheet: %v", err)
+		return nil, fmt.Errorf("unable to retrieve data from sheet: %v", err)
 	}
+	return resp.Values, nil
+}
 
+// NewFromRows creates timesheet from the spreadsheet rows.  If invoiceID is
+// not empty, only rows of that invoice are added.  Rows that don't match
+// the spreadsheet filter are skipped.
+func NewFromRows(cfg *TimesheetConfig, rows [][]interface{}, invoiceID string) (*Timesheet, error) {
 	timesheet := New(cfg)
 
-	if len(resp.Values) == 0 {
+	if len(rows) == 0 {
 		fmt.Println("No data found.")
-	} else {
-		for i, row := range resp.Values {
-			if invoiceID != "" && asString(value(row, idxInvoice)) != invoiceID {
-				continue
-			}
-			if err := timesheet.AddRow(row); err != nil {
-				return nil, fmt.Errorf("row: %d: %s", i, err)
-			}
+		return timesheet, nil
+	}
+	for i, row := range rows {
+		inv := asString(value(row, cfg.Spreadsheet.Columns.inv))
+		if invoiceID != "" && inv != invoiceID {
+			continue
+		}
+		if !cfg.Spreadsheet.matches(inv) {
+			continue
+		}
+		if err := timesheet.AddRow(row); err != nil {
+			return nil, fmt.Errorf("row: %d: %s", i, err)
 		}
 	}
 	return timesheet, nil
@@ -165,7 +188,11 @@ func (e *TsEntry) Recalculate() *TsEntry {
 		e.Duration = e.End.Sub(e.Start)
 	}
 	// tasks duration
-	dur := time.Duration(e.Duration.Nanoseconds()/int64(len(e.Items))) * time.Nanosecond
+	n := len(e.Items)
+	if e.split > 0 {
+		n = e.split
+	}
+	dur := time.Duration(e.Duration.Nanoseconds()/int64(n)) * time.Nanosecond
 
 	for i := range e.Items {
 		e.Items[i].duration = dur
@@ -194,6 +221,9 @@ func (ts *Timesheet) parse(row []interface{}) (*TsEntry, error) {
 		Issue:       asString(value(row, cols.issue)),
 		Description: asString(value(row, cols.descr)),
 	}
+	if cols.client >= 0 {
+		tr.Items[0].Client = asString(value(row, cols.client))
+	}
 
 	return &tr, nil
 }
@@ -259,18 +289,89 @@ func readConfig(filename string) (*TimesheetConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
-	if err := cfg.Spreadsheet.Columns.resolve(); err != nil {
+	if err := cfg.init(); err != nil {
 		return nil, err
 	}
-	if err := cfg.Values.adjustDates(); err != nil {
-		return nil, err
+	return &cfg, nil
+}
+
+// init resolves columns, validates and initialises the configuration.
+func (cfg *TimesheetConfig) init() error {
+	if cfg.Spreadsheet == nil || cfg.Values == nil {
+		return errors.New("spreadsheet or invoice section is missing")
+	}
+	if err := cfg.Spreadsheet.Columns.resolve(); err != nil {
+		return err
+	}
+	if err := cfg.Spreadsheet.compile(); err != nil {
+		return err
+	}
+	if err := cfg.Values.adjustDates(time.Now()); err != nil {
+		return err
+	}
+
+	if _, err := forms.NewLocale(cfg.Values.InvoiceFields.Language, cfg.Values.InvoiceFields.SecondLanguage); err != nil {
+		return err
+	}
+	if err := cfg.Values.Theme.Validate(); err != nil {
+		return err
+	}
+	if err := forms.ValidStatus(cfg.Values.InvoiceFields.Status); err != nil {
+		return err
+	}
+	if err := cfg.Values.InvoiceFields.Stamp.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.Values.InvoiceFields.ValidateLogo(); err != nil {
+		return err
+	}
+	if err := validAppendix(cfg.Values.Appendix); err != nil {
+		return err
+	}
+	if err := cfg.Values.Grouping.validate(); err != nil {
+		return err
+	}
+	if err := validLineSort(cfg.Values.LineSort); err != nil {
+		return err
+	}
+	if err := cfg.Values.Journal.validate(); err != nil {
+		return err
+	}
+	for n := range cfg.Values.Payments {
+		if err := cfg.Values.Payments[n].validate(); err != nil {
+			return err
+		}
+	}
+	if err := validRenderer(cfg.Values.Renderer); err != nil {
+		return err
+	}
+	if err := cfg.SignOptions().Validate(); err != nil {
+		return err
+	}
+
+	if cfg.Values.Retainer != nil {
+		if err := cfg.Values.Retainer.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range cfg.Values.Budgets {
+		if err := b.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range cfg.Recurring {
+		if err := r.validate(); err != nil {
+			return err
+		}
 	}
 
 	if cfg.Values.IssueSummary == nil {
 		cfg.Values.IssueSummary = make(map[string]string)
 	}
 
-	return &cfg, nil
+	return nil
 }
 
 // SaveConfig saves the configuration to a file on disk.
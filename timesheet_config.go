@@ -2,6 +2,9 @@ package sheet2inv
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -12,18 +15,45 @@ import (
 // TimesheetConfig is the configuration of the Invoice output.
 type TimesheetConfig struct {
 	Spreadsheet *Spreadsheet
-	Values      *InvoiceValues `yaml:"invoice"`
+	Values      *InvoiceValues      `yaml:"invoice"`
+	Recurring   []*RecurringInvoice `yaml:"recurring,omitempty"` // fixed fee invoices
+	Output      *Output             `yaml:"output,omitempty"`
+
+	client      string // client profile name
+	multiClient bool   // one of several client profiles
+}
+
+// Output is the invoice output settings.
+type Output struct {
+	Dir  string `yaml:",omitempty"` // output directory
+	Name string `yaml:",omitempty"` // file name pattern, {id} and {client} are replaced with invoice number and client name
+
+	HTMLTemplates []string `yaml:"html_templates,omitempty"` // html template files, default template is used if empty
+	FacturX       string   `yaml:"facturx,omitempty"`        // Factur-X profile, if set, pdf invoices embed the CII xml
+
+	Sign *forms.SignOptions `yaml:"sign,omitempty"` // pdf signature options, invoices are signed if the key is given
 }
 
 // InvoiceParameters contains invoice parameters.
 type InvoiceValues struct {
 	Rate            decimal.Decimal `yaml:"hourly_rate"`
 	Tax             decimal.Decimal `yaml:"tax_rate"`
+	TaxCategory     string          `yaml:"tax_category,omitempty"` // e-invoice tax category, defaults to "S", or "Z" for zero tax
+	Currency        string          `yaml:"currency,omitempty"`     // ISO 4217 currency code, defaults to USD
 	Shipping        decimal.Decimal
 	UsePrevMonth    bool                `yaml:"use_previous_month"` // if defined, date fields are ignored
 	PrevMonthDueDay int                 `yaml:"due_day"`            // day of the month for due date
 	InvoiceFields   forms.InvoiceFields `yaml:"invoice_fields"`
 	IssueSummary    map[string]string   `yaml:"issue_summary,omitempty"`
+	Retainer        *Retainer           `yaml:"retainer,omitempty"`  // prepaid hours or money
+	Budgets         []*Budget           `yaml:"budgets,omitempty"`   // not-to-exceed limits
+	Theme           *forms.Theme        `yaml:"theme,omitempty"`     // pdf invoice style
+	Renderer        string              `yaml:"renderer,omitempty"`  // pdf renderer: "gofpdf" (default) or "unipdf"
+	Appendix        string              `yaml:"appendix,omitempty"`  // timesheet appendix detail level: "day" or "entry"
+	Grouping        *Grouping           `yaml:"grouping,omitempty"`  // invoice line grouping, one line per issue by default
+	LineSort        string              `yaml:"line_sort,omitempty"` // invoice line order: "key" (default), "date", "hours" or "amount", "-" prefix for descending
+	Journal         *Journal            `yaml:"journal,omitempty"`   // accounting journal accounts
+	Payments        []Payment           `yaml:"payments,omitempty"`  // received payments
 }
 
 // Spreadsheet is the source spreadsheet parameters.
@@ -31,6 +61,26 @@ type Spreadsheet struct {
 	ID      string
 	Range   string
 	Columns Columns
+	Filter  string `yaml:",omitempty"` // regexp, rows with Invoice column not matching it are skipped
+
+	filter *regexp.Regexp
+}
+
+func (s *Spreadsheet) compile() error {
+	if s.Filter == "" {
+		return nil
+	}
+	re, err := regexp.Compile(s.Filter)
+	if err != nil {
+		return fmt.Errorf("spreadsheet filter: %s", err)
+	}
+	s.filter = re
+	return nil
+}
+
+// matches returns true if the invoice column value matches the filter.
+func (s *Spreadsheet) matches(invoice string) bool {
+	return s.filter == nil || s.filter.MatchString(invoice)
 }
 
 // Columns is the column index within the spreadsheet.
@@ -40,13 +90,15 @@ type Columns struct {
 	Invoice     string
 	Description string
 	Issue       string
+	Client      string `yaml:",omitempty"` // optional client column, used for routing
 
 	// calculated column indexes
-	start int
-	end   int
-	inv   int
-	descr int
-	issue int
+	start  int
+	end    int
+	inv    int
+	descr  int
+	issue  int
+	client int
 }
 
 func (ci *Columns) resolve() (err error) {
@@ -65,6 +117,10 @@ func (ci *Columns) resolve() (err error) {
 	ci.inv = ci.char2int(ci.Invoice)
 	ci.descr = ci.char2int(ci.Description)
 	ci.issue = ci.char2int(ci.Issue)
+	ci.client = -1
+	if ci.Client != "" {
+		ci.client = ci.char2int(ci.Client)
+	}
 
 	return
 }
@@ -80,23 +136,102 @@ func (*Columns) char2int(char string) int {
 	return int(char[0] - 'A')
 }
 
-func (v *InvoiceValues) adjustDates() error {
+// adjustDates sets the invoice dates for the previous month as of now, if
+// use_prev_month is set.
+func (v *InvoiceValues) adjustDates(now time.Time) error {
 	if !v.UsePrevMonth {
 		return nil
 	}
 	if v.PrevMonthDueDay < 1 || 31 < v.PrevMonthDueDay {
 		return errors.New("invalid prev_month_due_day")
 	}
-	today := time.Now()
-	thisMoStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
-	end := thisMoStart.Add(-24 * time.Hour)
-	start := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
-	due := time.Date(today.Year(), today.Month(), v.PrevMonthDueDay, 0, 0, 0, 0, time.UTC)
+	start, end, due := periodDates(now, 1, v.PrevMonthDueDay)
 
-	v.InvoiceFields.Date = today
+	v.InvoiceFields.Date = now
 	v.InvoiceFields.PeriodStart = start
 	v.InvoiceFields.PeriodEnd = end
 	v.InvoiceFields.Due = due
 
 	return nil
 }
+
+// periodDates returns the period of the given number of months, ending
+// before the month of the invoice date, and the due date on dueDay of the
+// invoice date month, or of the next month, if dueDay is before the invoice
+// date.  The due day is clamped to the last day of the due month.
+func periodDates(date time.Time, months int, dueDay int) (start, end, due time.Time) {
+	thisMoStart := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end = thisMoStart.Add(-24 * time.Hour)
+	start = thisMoStart.AddDate(0, -months, 0)
+	dueMonth := thisMoStart
+	if dueDay < date.Day() {
+		dueMonth = dueMonth.AddDate(0, 1, 0)
+	}
+	if last := dueMonth.AddDate(0, 1, -1).Day(); last < dueDay {
+		dueDay = last
+	}
+	due = dueMonth.AddDate(0, 0, dueDay-1)
+	return
+}
+
+// Default output file names, the client name is included if there are
+// several client profiles, so that their invoices do not overwrite each
+// other.
+const (
+	defOutputName       = "invoice-{id}"
+	defClientOutputName = "invoice-{client}-{id}"
+)
+
+// Filename returns the output file name for the invoice with the
+// extension ext, i.e. ".pdf".
+func (cfg *TimesheetConfig) Filename(invoiceID, ext string) string {
+	o := cfg.output()
+	name := strings.NewReplacer("{id}", invoiceID, "{client}", cfg.client).Replace(o.Name)
+	return filepath.Join(o.Dir, name+ext)
+}
+
+// output returns the output settings with the default file name.
+func (cfg *TimesheetConfig) output() Output {
+	var o Output
+	if cfg.Output != nil {
+		o = *cfg.Output
+	}
+	if o.Name == "" {
+		o.Name = defOutputName
+		if cfg.multiClient {
+			o.Name = defClientOutputName
+		}
+	}
+	return o
+}
+
+// HTMLTemplates returns the html template files.
+func (cfg *TimesheetConfig) HTMLTemplates() []string {
+	if cfg.Output == nil {
+		return nil
+	}
+	return cfg.Output.HTMLTemplates
+}
+
+// FacturX returns the Factur-X profile for pdf invoices, or empty string,
+// if the plain pdf should be generated.
+func (cfg *TimesheetConfig) FacturX() string {
+	if cfg.Output == nil {
+		return ""
+	}
+	return cfg.Output.FacturX
+}
+
+// SignOptions returns the pdf signature options, or nil, if the defaults
+// should be used.
+func (cfg *TimesheetConfig) SignOptions() *forms.SignOptions {
+	if cfg.Output == nil {
+		return nil
+	}
+	return cfg.Output.Sign
+}
+
+// Save saves the configuration to a file on disk.
+func (cfg *TimesheetConfig) Save(filename string) error {
+	return saveConfig(filename, cfg)
+}
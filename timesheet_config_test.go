@@ -2,6 +2,7 @@ package sheet2inv
 
 import (
 	"testing"
+	"time"
 )
 
 func TestColumns_resolve(t *testing.T) {
@@ -95,3 +96,67 @@ func TestColumns_char2int(t *testing.T) {
 		})
 	}
 }
+
+func Test_periodDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		date      time.Time
+		months    int
+		dueDay    int
+		wantStart time.Time
+		wantEnd   time.Time
+		wantDue   time.Time
+	}{
+		{"due this month", date(2020, 3, 1), 1, 15, date(2020, 2, 1), date(2020, 2, 29), date(2020, 3, 15)},
+		{"due on the invoice day", date(2020, 3, 15), 1, 15, date(2020, 2, 1), date(2020, 2, 29), date(2020, 3, 15)},
+		{"due next month", date(2020, 3, 20), 1, 5, date(2020, 2, 1), date(2020, 2, 29), date(2020, 4, 5)},
+		{"due next year", date(2020, 12, 20), 3, 5, date(2020, 9, 1), date(2020, 11, 30), date(2021, 1, 5)},
+		{"due day past february", date(2020, 2, 10), 1, 31, date(2020, 1, 1), date(2020, 1, 31), date(2020, 2, 29)},
+		{"due day past april, next month", date(2021, 3, 31), 1, 30, date(2021, 2, 1), date(2021, 2, 28), date(2021, 4, 30)},
+		{"due day past june, this month", date(2020, 6, 30), 1, 31, date(2020, 5, 1), date(2020, 5, 31), date(2020, 6, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, due := periodDates(tt.date, tt.months, tt.dueDay)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) || !due.Equal(tt.wantDue) {
+				t.Errorf("periodDates() = %s, %s, %s, want %s, %s, %s", start, end, due, tt.wantStart, tt.wantEnd, tt.wantDue)
+			}
+			if due.Before(tt.date) {
+				t.Errorf("due date %s is before the invoice date %s", due, tt.date)
+			}
+		})
+	}
+}
+
+func TestInvoiceValues_adjustDates(t *testing.T) {
+	tests := []struct {
+		name    string
+		now     time.Time
+		dueDay  int
+		wantDue time.Time
+		wantErr bool
+	}{
+		{"due this month", date(2020, 3, 1), 15, date(2020, 3, 15), false},
+		{"due day past february", date(2021, 2, 1), 31, date(2021, 2, 28), false},
+		{"due on the invoice day", date(2020, 10, 31), 31, date(2020, 10, 31), false},
+		{"due day past april, next month", date(2020, 3, 31), 30, date(2020, 4, 30), false},
+		{"invalid due day", date(2020, 3, 1), 32, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &InvoiceValues{UsePrevMonth: true, PrevMonthDueDay: tt.dueDay}
+			if err := v.adjustDates(tt.now); (err != nil) != tt.wantErr {
+				t.Fatalf("adjustDates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !v.InvoiceFields.Due.Equal(tt.wantDue) {
+				t.Errorf("Due = %s, want %s", v.InvoiceFields.Due, tt.wantDue)
+			}
+			if !v.InvoiceFields.Date.Equal(tt.now) {
+				t.Errorf("Date = %s, want %s", v.InvoiceFields.Date, tt.now)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package sheet2inv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// xlsxSheet is the worksheet of the Office Open XML workbook.
+type xlsxSheet struct {
+	Name string
+	Rows [][]xlsxCell
+}
+
+// xlsxCell is the worksheet cell.  Numeric cells hold the number in Value.
+type xlsxCell struct {
+	Value   string
+	Numeric bool
+	Amount  bool // numeric cell with 2 decimal places
+	Bold    bool
+}
+
+// xlsx cell styles, indexes in the cellXfs of xlsxStyles.
+const (
+	xlsxStyleBold   = 1
+	xlsxStyleAmount = 2 // bold amount is xlsxStyleBold + xlsxStyleAmount
+)
+
+const (
+	xlsxMaxSheetName = 31 // Excel limit
+	xlsxMaxColWidth  = 60
+)
+
+func xlsxHeader(names ...string) []xlsxCell {
+	cells := make([]xlsxCell, len(names))
+	for i, name := range names {
+		cells[i] = xlsxCell{Value: name, Bold: true}
+	}
+	return cells
+}
+
+func xlsxText(s string) xlsxCell {
+	return xlsxCell{Value: s}
+}
+
+// xlsxNumber returns the numeric cell, rounded to 2 decimal places.
+func xlsxNumber(d decimal.Decimal) xlsxCell {
+	return xlsxCell{Value: d.Round(2).String(), Numeric: true}
+}
+
+func xlsxAmount(d decimal.Decimal) xlsxCell {
+	return xlsxCell{Value: d.StringFixed(2), Numeric: true, Amount: true}
+}
+
+func xlsxBold(c xlsxCell) xlsxCell {
+	c.Bold = true
+	return c
+}
+
+func (c *xlsxCell) style() int {
+	var s int
+	if c.Bold {
+		s += xlsxStyleBold
+	}
+	if c.Amount {
+		s += xlsxStyleAmount
+	}
+	return s
+}
+
+// sheetName returns the valid worksheet name, that is not in use, and marks
+// it as used.
+func sheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "Client"
+	}
+	base := truncateRunes(name, xlsxMaxSheetName)
+	name = base
+	for n := 2; used[strings.ToLower(name)]; n++ {
+		sfx := fmt.Sprintf(" (%d)", n)
+		name = truncateRunes(base, xlsxMaxSheetName-len(sfx)) + sfx
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// truncateRunes truncates s to n runes.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// colName returns the column name of the zero-based column index, i.e. "A",
+// "Z", "AA".
+func colName(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// writeXLSX writes the sheets to w as the Office Open XML workbook.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	var (
+		types   bytes.Buffer
+		book    bytes.Buffer
+		rels    bytes.Buffer
+		entries = make([][2]string, 0, len(sheets)+5)
+	)
+	types.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	book.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sh := range sheets {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&book, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sh.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		entries = append(entries, [2]string{fmt.Sprintf("xl/worksheets/sheet%d.xml", n), sh.xml()})
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+	types.WriteString(`</Types>`)
+	book.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	entries = append([][2]string{
+		{"[Content_Types].xml", types.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", book.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}, entries...)
+
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		f, err := zw.Create(e[0])
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, e[1]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xml returns the worksheet xml.  The first row is frozen, column widths
+// fit the content.
+func (sh *xlsxSheet) xml() string {
+	var widths []int
+	for _, row := range sh.Rows {
+		for i, c := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(c.Value) + 2; n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	if len(widths) > 0 {
+		buf.WriteString(`<cols>`)
+		for i, w := range widths {
+			if w > xlsxMaxColWidth {
+				w = xlsxMaxColWidth
+			}
+			fmt.Fprintf(&buf, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, w)
+		}
+		buf.WriteString(`</cols>`)
+	}
+	buf.WriteString(`<sheetData>`)
+	for r, row := range sh.Rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := colName(c) + strconv.Itoa(r+1)
+			style := ""
+			if s := cell.style(); s != 0 {
+				style = fmt.Sprintf(` s="%d"`, s)
+			}
+			switch {
+			case cell.Value == "":
+				if style != "" {
+					fmt.Fprintf(&buf, `<c r="%s"%s/>`, ref, style)
+				}
+			case cell.Numeric:
+				fmt.Fprintf(&buf, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell.Value)
+			default:
+				fmt.Fprintf(&buf, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(cell.Value))
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.String()
+}
+
+// xmlEscape returns s with the xml special characters escaped.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// xlsxStyles is the workbook stylesheet: normal, bold, amount with 2 decimal
+// places, and bold amount.
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="2" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`